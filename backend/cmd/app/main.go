@@ -4,10 +4,16 @@ import (
 	"backend/config"
 	"backend/internal/database"
 	"backend/internal/handlers"
+	authmiddleware "backend/internal/middleware"
+	"backend/internal/rbac"
 	"backend/internal/routes"
+	"backend/internal/services"
 	"backend/pkg/middleware"
+	"backend/pkg/utils"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/recover"
@@ -22,9 +28,41 @@ func main() {
 	// Load configuration
 	config.LoadConfig()
 
+	// Load JWT signing keys (ห้าม start ถ้าไม่มี key นอก development)
+	if err := utils.InitJWTKeys(config.IsDevelopment()); err != nil {
+		log.Fatal("Failed to load JWT keys: ", err)
+	}
+	if utils.ActiveKeyID() == utils.EphemeralKeyID {
+		log.Println("Warning: JWT_KEY_DIR has no keys, using an ephemeral development key (tokens reset on restart)")
+	} else {
+		log.Printf("JWT signing key loaded (kid=%s)", utils.ActiveKeyID())
+	}
+
+	// Reload keys on SIGHUP (key rotation โดยไม่ต้อง restart)
+	go func() {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		for range hup {
+			if err := utils.ReloadJWTKeys(); err != nil {
+				log.Printf("JWT key reload failed, keeping current keys: %v", err)
+				continue
+			}
+			log.Printf("JWT keys reloaded (kid=%s)", utils.ActiveKeyID())
+		}
+	}()
+
 	// Connect to database
 	database.ConnectDB()
 
+	// ปิด role grant ที่หมดอายุตามเวลา
+	services.StartRoleGrantScheduler()
+
+	// เผยแพร่ project ที่ตั้งเวลาไว้
+	services.StartProjectPublishScheduler()
+
+	// External login providers (OIDC)
+	services.InitOIDCProviders()
+
 	// Initialize R2 Upload Service
 	if err := handlers.InitR2Service(); err != nil {
 		log.Printf("Warning: R2 Service not initialized: %v", err)
@@ -35,8 +73,10 @@ func main() {
 		handlers.InitCleanupService()
 	}
 
-	// Initialize Fiber app
-	app := fiber.New()
+	// Initialize Fiber app - error ทุกชนิดส่งกลับใน envelope เดียวกัน (utils.ErrorHandler)
+	app := fiber.New(fiber.Config{
+		ErrorHandler: utils.ErrorHandler,
+	})
 
 	// CORS Middleware
 	app.Use(middleware.CORS())
@@ -49,6 +89,16 @@ func main() {
 	// Setup routes
 	routes.SetupRoutes(app)
 
+	// สร้าง/อัปเดตตาราง permissions ตามที่ routes ประกาศไว้
+	if err := rbac.Sync(); err != nil {
+		log.Fatal("Failed to sync permissions: ", err)
+	}
+
+	// route ที่อ้าง permission slug ที่ไม่มีอยู่จริงจะไม่มีใครเข้าได้ - ให้ fail ตั้งแต่ start
+	if err := authmiddleware.VerifyRoutePermissions(); err != nil {
+		log.Fatal("Permission check failed: ", err)
+	}
+
 	// Start server
 	port := os.Getenv("PORT")
 	if port == "" {
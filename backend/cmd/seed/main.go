@@ -6,6 +6,8 @@ import (
 
 	"backend/internal/database"
 	"backend/internal/models"
+	"backend/internal/rbac"
+	_ "backend/internal/routes" // ประกาศ permissions ของระบบ (rbac.Define)
 
 	"github.com/joho/godotenv"
 )
@@ -23,9 +25,14 @@ func main() {
 		log.Fatal("DB_URL is not set")
 	}
 
-	// Connect to DB (this creates tables & seeds RBAC automatically via seedRBAC in db.go)
+	// Connect to DB (this creates tables & seeds roles/menus automatically via seedRBAC in db.go)
 	database.ConnectDB()
 
+	// Permissions ประกาศไว้กับ routes - sync ก่อนกำหนด role
+	if err := rbac.Sync(); err != nil {
+		log.Fatal("Failed to sync permissions: ", err)
+	}
+
 	// Additional Logic: Promote specific user to Super Admin
 	// Try to find "admin" or whichever user we want
 	username := "admin" // Hardcoded for now, or use args
@@ -2,6 +2,8 @@ package config
 
 import (
 	"log"
+	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -15,3 +17,10 @@ func LoadConfig() {
 		}
 	}
 }
+
+// IsDevelopment คืน true เมื่อ APP_ENV=development
+// ค่าเริ่มต้น (ไม่ได้ตั้ง APP_ENV) ถือเป็น production เพื่อความปลอดภัย
+func IsDevelopment() bool {
+	env := strings.ToLower(os.Getenv("APP_ENV"))
+	return env == "development" || env == "dev"
+}
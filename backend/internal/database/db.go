@@ -1,12 +1,14 @@
 package database
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"strings"
 	"time"
 
 	"backend/internal/models"
+	"backend/pkg/utils"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -38,6 +40,31 @@ func ConnectDB() {
 
 	log.Println("Connected to Supabase PostgreSQL database successfully")
 
+	// users ที่มีอยู่ก่อนเพิ่ม email verification ถือว่ายืนยันแล้ว (ตรวจก่อน migrate สร้าง column)
+	backfillEmailVerified := DB.Migrator().HasTable(&models.User{}) && !DB.Migrator().HasColumn(&models.User{}, "EmailVerified")
+	// อายุรหัสผ่านของ users เดิมเริ่มนับจากวันที่เพิ่ม column (ไม่ให้หมดอายุทันที)
+	backfillPasswordChangedAt := DB.Migrator().HasTable(&models.User{}) && !DB.Migrator().HasColumn(&models.User{}, "PasswordChangedAt")
+	// employees เดิมเก็บแผนกเป็นชื่อ - จับคู่กับตาราง departments หลังเพิ่ม column
+	backfillEmployeeDepartment := DB.Migrator().HasTable(&models.Employee{}) && !DB.Migrator().HasColumn(&models.Employee{}, "DepartmentID")
+	// projects เดิมยังไม่มี slug - สร้างจากชื่อหลังเพิ่ม column
+	backfillProjectSlug := DB.Migrator().HasTable(&models.Project{}) && !DB.Migrator().HasColumn(&models.Project{}, "Slug")
+	// projects เดิมเก็บหมวดหมู่เป็นชื่อ - จับคู่กับตาราง categories หลังเพิ่ม column (หลัง seed หมวดหมู่)
+	backfillProjectCategory := DB.Migrator().HasTable(&models.Project{}) && !DB.Migrator().HasColumn(&models.Project{}, "CategoryID")
+	// projects เดิมที่เปิดใช้งานอยู่แสดงบนเว็บแล้ว - ถือว่าเผยแพร่แล้ว (ที่เหลือเป็น draft)
+	backfillProjectPublish := DB.Migrator().HasTable(&models.Project{}) && !DB.Migrator().HasColumn(&models.Project{}, "PublishStatus")
+	// projects เดิมยังไม่มี revision - เก็บสถานะปัจจุบันเป็น revision แรก (หลัง backfill อื่นของ projects)
+	backfillProjectRevision := DB.Migrator().HasTable(&models.Project{}) && !DB.Migrator().HasTable(&models.ProjectRevision{})
+
+	// projects ที่ชี้ไปหมวดหมู่ที่ถูกลบไปแล้ว (ก่อนมี foreign key) - ปลดออกก่อน migrate สร้าง constraint ไม่งั้น migrate ล้ม
+	// ชื่อหมวดหมู่ (projects.category) ยังอยู่ แสดงผลได้เหมือนเดิม
+	if DB.Migrator().HasColumn(&models.Project{}, "CategoryID") && DB.Migrator().HasTable(&models.Category{}) &&
+		!DB.Migrator().HasConstraint(&models.Project{}, "CategoryRef") {
+		result := DB.Exec("UPDATE projects SET category_id = NULL WHERE category_id IS NOT NULL AND category_id NOT IN (SELECT id FROM categories)")
+		if result.RowsAffected > 0 {
+			log.Printf("Unlinked %d projects from deleted categories", result.RowsAffected)
+		}
+	}
+
 	// Auto Migrate
 	err = DB.AutoMigrate(
 		&models.Role{}, &models.Permission{}, &models.Menu{}, // RBAC tables
@@ -47,19 +74,167 @@ func ConnectDB() {
 		&models.Setting{},                        // Settings
 		&models.Category{},                       // Categories
 		&models.Department{}, &models.Position{}, // HR Master Data
-		&models.PasswordReset{}, // Password Reset Tokens
+		&models.PasswordReset{},          // Password Reset Tokens
+		&models.RefreshToken{},           // Refresh Tokens (rotation + revocation)
+		&models.Session{},                // Login Sessions (per device)
+		&models.LoginThrottle{},          // Failed login counters (lockout / backoff)
+		&models.EmailVerification{},      // Email verification tokens
+		&models.Invitation{},             // Registration invitations
+		&models.MagicLink{},              // Passwordless login links
+		&models.UserIdentity{},           // OIDC linked accounts
+		&models.OAuthState{},             // OIDC pending logins (state / nonce / PKCE)
+		&models.PasswordHistory{},        // Previous password hashes (reuse check)
+		&models.RolePermissionScope{},    // Department / reporting-line limits on role permissions
+		&models.UserRole{},               // Additional / time-bound role grants
+		&models.MenuTranslation{},        // Menu titles per locale
+		&models.ProjectSlugHistory{},     // Previous project slugs (redirect old links)
+		&models.ProjectRevision{},        // Project content snapshots (history / restore)
+		&models.UsedTwoFactorChallenge{}, // Spent 2FA challenge tokens (single use)
 	)
 	if err != nil {
 		log.Fatal("Failed to migrate database: ", err)
 	}
 	log.Println("Database migration completed")
 
+	if backfillEmailVerified {
+		result := DB.Model(&models.User{}).Where("email_verified = ?", false).
+			Updates(map[string]interface{}{"email_verified": true, "email_verified_at": time.Now()})
+		log.Printf("Marked %d existing users as email verified", result.RowsAffected)
+	}
+	if backfillPasswordChangedAt {
+		result := DB.Model(&models.User{}).Where("password_changed_at IS NULL").Update("password_changed_at", time.Now())
+		log.Printf("Started password age for %d existing users", result.RowsAffected)
+	}
+	if backfillEmployeeDepartment {
+		result := DB.Exec(`UPDATE employees SET department_id = departments.id FROM departments
+			WHERE employees.department_id IS NULL AND LOWER(employees.department) = LOWER(departments.name)`)
+		log.Printf("Linked %d existing employees to departments", result.RowsAffected)
+	}
+	if backfillProjectSlug {
+		backfillProjectSlugs()
+	}
+	if backfillProjectPublish {
+		result := DB.Model(&models.Project{}).Where("is_active = ?", true).
+			Updates(map[string]interface{}{"publish_status": models.ProjectPublished, "publish_at": gorm.Expr("created_at"), "published_at": gorm.Expr("created_at")})
+		log.Printf("Marked %d existing projects as published", result.RowsAffected)
+	}
+
+	// index สำหรับค้นหา projects
+	setupProjectSearch()
+
 	// Seed RBAC Data
 	seedRBAC()
 	// Seed Settings
 	seedSettings()
 	// Seed Categories
 	seedCategories()
+
+	if backfillProjectCategory {
+		backfillProjectCategories()
+	}
+	if backfillProjectRevision {
+		backfillProjectRevisions()
+	}
+}
+
+// backfillProjectSlugs สร้าง slug ให้ projects ที่มีอยู่ก่อนเพิ่ม column (ชื่อซ้ำกันเติม -2, -3, ...)
+func backfillProjectSlugs() {
+	var projects []models.Project
+	DB.Where("slug IS NULL OR slug = ''").Order("id asc").Find(&projects)
+
+	used := map[string]bool{}
+	for _, project := range projects {
+		base := utils.Slugify(project.Title)
+		if base == "" {
+			base = fmt.Sprintf("project-%d", project.ID)
+		}
+		slug := base
+		for n := 2; used[slug]; n++ {
+			slug = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[slug] = true
+		DB.Model(&models.Project{}).Where("id = ?", project.ID).Update("slug", slug)
+	}
+	log.Printf("Generated slugs for %d existing projects", len(projects))
+}
+
+// backfillProjectRevisions เก็บข้อมูลปัจจุบันของ projects ที่มีอยู่เป็น revision 1 (baseline)
+func backfillProjectRevisions() {
+	var projects []models.Project
+	DB.Find(&projects)
+	for _, project := range projects {
+		DB.Create(&models.ProjectRevision{
+			ProjectID: project.ID,
+			Revision:  1,
+			Action:    models.ProjectRevisionBaseline,
+			Snapshot:  project.Snapshot(),
+		})
+	}
+	log.Printf("Recorded baseline revisions for %d existing projects", len(projects))
+}
+
+// setupProjectSearch สร้าง index ค้นหา projects: full-text (ภาษาที่เว้นวรรค) + trigram (ภาษาไทย / คำบางส่วน)
+// ถ้าเปิด pg_trgm ไม่ได้ (ไม่มีสิทธิ์) การค้นหายังใช้ได้ แค่ ILIKE จะไม่มี index
+func setupProjectSearch() {
+	if err := DB.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		log.Printf("Warning: pg_trgm extension unavailable, project search runs without trigram index: %v", err)
+	} else if err := DB.Exec("CREATE INDEX IF NOT EXISTS idx_projects_search_trgm ON projects USING gin ((" + models.ProjectSearchDocument + ") gin_trgm_ops)").Error; err != nil {
+		log.Printf("Warning: failed to create project trigram index: %v", err)
+	}
+	if err := DB.Exec("CREATE INDEX IF NOT EXISTS idx_projects_search_fts ON projects USING gin (to_tsvector('simple', " + models.ProjectSearchDocument + "))").Error; err != nil {
+		log.Printf("Warning: failed to create project full-text index: %v", err)
+	}
+}
+
+// backfillProjectCategories ผูก projects เดิมกับตาราง categories จากชื่อหมวดหมู่ที่เก็บเป็นข้อความ
+// ชื่อที่ยังไม่มีในตารางจะสร้างหมวดหมู่ใหม่ให้ (projects ไม่หลุดหมวดหมู่)
+func backfillProjectCategories() {
+	var names []string
+	DB.Model(&models.Project{}).Where("category_id IS NULL AND category <> ''").Distinct().Pluck("category", &names)
+
+	var linked int64
+	for _, name := range names {
+		trimmed := strings.TrimSpace(name)
+		if trimmed == "" {
+			continue
+		}
+
+		var category models.Category
+		query := DB.Where("LOWER(name) = LOWER(?)", trimmed)
+		if slug := utils.Slugify(trimmed); slug != "" {
+			query = query.Or("slug = ?", slug)
+		}
+		if err := query.First(&category).Error; err != nil {
+			category = models.Category{Name: trimmed, Slug: uniqueCategorySlug(trimmed), IsActive: true}
+			if err := DB.Create(&category).Error; err != nil {
+				log.Printf("Failed to create category %q for existing projects: %v", trimmed, err)
+				continue
+			}
+			log.Printf("Created category %q for existing projects", trimmed)
+		}
+
+		result := DB.Model(&models.Project{}).Where("category_id IS NULL AND category = ?", name).
+			Updates(map[string]interface{}{"category_id": category.ID, "category": category.Name})
+		linked += result.RowsAffected
+	}
+	log.Printf("Linked %d existing projects to categories", linked)
+}
+
+// uniqueCategorySlug slug ของหมวดหมู่ที่ยังไม่ถูกใช้ (ซ้ำเติม -2, -3, ...)
+func uniqueCategorySlug(name string) string {
+	base := utils.Slugify(name)
+	if base == "" {
+		base = "category"
+	}
+	slug := base
+	for n := 2; ; n++ {
+		var count int64
+		DB.Model(&models.Category{}).Where("slug = ?", slug).Count(&count)
+		if count == 0 {
+			return slug
+		}
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
 }
 
 func seedSettings() {
@@ -70,6 +245,21 @@ func seedSettings() {
 		{Key: "site_tagline_en", Value: "Architectural & Space Design Studio", Description: "Tagline (English)", Group: "general", IsPublic: true},
 		{Key: "maintenance_mode", Value: "false", Description: "Turn on maintenance mode", Type: "boolean", Group: "general", IsPublic: true},
 
+		// Auth
+		{Key: "registration_mode", Value: "open", Description: "Self-registration: open, invite_only, approval_required or closed", Group: "auth", IsPublic: true},
+		{Key: "registration_allowed_domains", Value: "", Description: "Comma-separated email domains allowed to self-register (empty = any, *.example.com for subdomains)", Type: "textarea", Group: "auth", IsPublic: false},
+		{Key: "email_verification_mode", Value: "block", Description: "Unverified self-registered accounts: off, restrict (login allowed, HR/admin blocked) or block (login blocked)", Group: "auth", IsPublic: false},
+
+		// Password policy
+		{Key: "password_min_length", Value: "8", Description: "Minimum password length (characters)", Type: "number", Group: "security", IsPublic: true},
+		{Key: "password_require_lowercase", Value: "true", Description: "Require at least one lowercase letter", Type: "boolean", Group: "security", IsPublic: true},
+		{Key: "password_require_uppercase", Value: "true", Description: "Require at least one uppercase letter", Type: "boolean", Group: "security", IsPublic: true},
+		{Key: "password_require_digit", Value: "true", Description: "Require at least one digit", Type: "boolean", Group: "security", IsPublic: true},
+		{Key: "password_require_special", Value: "true", Description: "Require at least one special character", Type: "boolean", Group: "security", IsPublic: true},
+		{Key: "password_max_age_days", Value: "0", Description: "Days before a password expires and must be changed (0 = never)", Type: "number", Group: "security", IsPublic: false},
+		{Key: "password_history_depth", Value: "5", Description: "Number of previous passwords that cannot be reused (0 = off)", Type: "number", Group: "security", IsPublic: false},
+		{Key: "password_breach_check", Value: "true", Description: "Reject passwords found in the offline breached-password list (PASSWORD_BREACH_DIR)", Type: "boolean", Group: "security", IsPublic: false},
+
 		// SEO
 		{Key: "site_description", Value: "1931 Co., Ltd. is a premier architectural design studio based in Thailand.", Description: "Meta description for SEO", Type: "textarea", Group: "seo", IsPublic: true},
 		{Key: "seo_keywords", Value: "Architecture, Design, Interior Design, Thailand, Bangkok, Sustainable Design", Description: "Comma-separated keywords", Type: "textarea", Group: "seo", IsPublic: true},
@@ -113,86 +303,159 @@ func seedSettings() {
 	}
 }
 
-func seedRBAC() {
-	// 1. Create Permissions
-	permissions := []models.Permission{
-		{Slug: "admin.access", Description: "Access Admin Panel"},
-		{Slug: "dashboard.view", Description: "View Dashboard"},
-		{Slug: "users.view", Description: "View Users"},
-		{Slug: "users.manage", Description: "Create, Edit, Delete Users"},
-		{Slug: "roles.view", Description: "View Roles"},
-		{Slug: "roles.manage", Description: "Create, Edit, Delete Roles"},
-		{Slug: "menus.view", Description: "View Menus"},
-		{Slug: "menus.manage", Description: "Create, Edit, Delete Menus"},
-		{Slug: "settings.view", Description: "View Settings"},
-		{Slug: "settings.manage", Description: "Manage Settings"},
-		{Slug: "audit_logs.view", Description: "View Audit Logs"},
-		{Slug: "projects.view", Description: "View Projects"},
-		{Slug: "projects.manage", Description: "Create, Edit, Delete Projects"},
-		{Slug: "categories.view", Description: "View Categories"},
-		{Slug: "categories.manage", Description: "Create, Edit, Delete Categories"},
-		{Slug: "attendance.view", Description: "View Attendance"},
-		{Slug: "attendance.manage", Description: "Manage Attendance"},
-		{Slug: "leaves.view", Description: "View Leaves"},
-		{Slug: "leaves.manage", Description: "Manage Leave Requests"},
-	}
-
+// SyncPermissions สร้าง/อัปเดต permissions ตาม registry ของ routes (ดู rbac.Sync)
+// แล้วกำหนด permissions ให้ role เริ่มต้น - ไม่ลบ permission ที่ไม่มีใน registry (ดู /permissions/matrix)
+func SyncPermissions(permissions []models.Permission) error {
 	for _, p := range permissions {
 		var perm models.Permission
-		if err := DB.Where(models.Permission{Slug: p.Slug}).FirstOrCreate(&perm).Error; err != nil {
-			log.Printf("Error finding/creating permission %s: %v", p.Slug, err)
-			continue
+		result := DB.Where(models.Permission{Slug: p.Slug}).FirstOrCreate(&perm)
+		if result.Error != nil {
+			return fmt.Errorf("finding/creating permission %s: %w", p.Slug, result.Error)
 		}
-		if err := DB.Model(&perm).Updates(p).Error; err != nil {
-			log.Printf("Error updating permission %s: %v", p.Slug, err)
+		if err := DB.Model(&perm).Updates(models.Permission{Description: p.Description, Group: p.Group}).Error; err != nil {
+			return fmt.Errorf("updating permission %s: %w", p.Slug, err)
+		}
+		// permission ใหม่ที่แยกมาจาก permission เดิม - ให้ role ที่มีของเดิมอยู่แล้ว (ครั้งเดียวตอนสร้าง)
+		if result.RowsAffected > 0 {
+			if err := grantSuccessorPermission(perm, permissionPredecessors[p.Slug]); err != nil {
+				return fmt.Errorf("granting permission %s: %w", p.Slug, err)
+			}
 		}
 	}
 
-	// 2. Create Roles
-	roles := []models.Role{
-		{Name: "Super Admin", Description: "Full Access"},
-		{Name: "User", Description: "Standard User Access"},
-	}
-
-	for _, r := range roles {
+	for _, seed := range defaultRoles {
 		var role models.Role
-		if err := DB.Where(models.Role{Name: r.Name}).FirstOrCreate(&role).Error; err != nil {
-			log.Printf("Error finding/creating role %s: %v", r.Name, err)
+		if err := DB.Preload("Permissions").Where("name = ?", seed.Name).First(&role).Error; err != nil {
+			continue
+		}
+
+		var perms []models.Permission
+		switch {
+		case seed.AllPermissions:
+			// Super Admin gets ALL permissions (รันทุกครั้งเพื่อให้ได้ permissions ใหม่)
+			DB.Find(&perms)
+		case seed.Reset || len(role.Permissions) == 0:
+			// role อื่นกำหนดครั้งแรกเท่านั้น (ไม่ทับที่ admin แก้ไว้) ยกเว้น Reset
+			DB.Where("slug IN ?", seed.Permissions).Find(&perms)
+		default:
 			continue
 		}
-		if err := DB.Model(&role).Updates(r).Error; err != nil {
-			log.Printf("Error updating role %s: %v", r.Name, err)
+		if err := DB.Model(&role).Association("Permissions").Replace(perms); err != nil {
+			log.Printf("Error assigning permissions to %s: %v", seed.Name, err)
+		} else {
+			log.Printf("Assigned %d permissions to %s role", len(perms), seed.Name)
 		}
 	}
+	return nil
+}
 
-	// 3. Assign Permissions to Roles
-	// Super Admin gets ALL permissions (รันทุกครั้งเพื่อให้ได้ permissions ใหม่)
-	var superAdmin models.Role
-	if err := DB.Where("name = ?", "Super Admin").First(&superAdmin).Error; err == nil {
-		var allPerms []models.Permission
-		DB.Find(&allPerms)
-		if err := DB.Model(&superAdmin).Association("Permissions").Replace(allPerms); err != nil {
-			log.Printf("Error assigning permissions to Super Admin: %v", err)
-		} else {
-			log.Printf("Assigned %d permissions to Super Admin role", len(allPerms))
+// permissionPredecessors permission ที่ route เคยใช้ก่อนถูกแยกเป็น permission เฉพาะ
+// ตอน permission ใหม่ถูกสร้าง role ที่มี permission เดิม (รวม role ที่ admin สร้างเอง) จะได้ permission ใหม่ด้วย
+// เพื่อไม่ให้เสียสิทธิ์ที่เคยมีหลัง deploy
+var permissionPredecessors = map[string][]string{
+	"employees.view":     {"users.view", "users.manage"},
+	"employees.manage":   {"users.manage"},
+	"departments.manage": {"users.manage"},
+	"positions.manage":   {"users.manage"},
+}
+
+// grantSuccessorPermission ให้ permission กับทุก role ที่มี permission เดิมในรายการ
+// scope ของ permission เดิม (แผนก / สายบังคับบัญชา) ใช้กับ permission ใหม่ด้วย
+func grantSuccessorPermission(perm models.Permission, predecessors []string) error {
+	if len(predecessors) == 0 {
+		return nil
+	}
+
+	var legacy []models.Permission
+	if err := DB.Where("slug IN ?", predecessors).Find(&legacy).Error; err != nil {
+		return err
+	}
+	for _, old := range legacy {
+		var roles []models.Role
+		if err := DB.Joins("JOIN role_permissions ON role_permissions.role_id = roles.id").
+			Where("role_permissions.permission_id = ?", old.ID).Find(&roles).Error; err != nil {
+			return err
+		}
+		for _, role := range roles {
+			var held int64
+			DB.Table("role_permissions").Where("role_id = ? AND permission_id = ?", role.ID, perm.ID).Count(&held)
+			if held > 0 {
+				continue
+			}
+			if err := DB.Model(&role).Association("Permissions").Append(&perm); err != nil {
+				return err
+			}
+
+			var scopes []models.RolePermissionScope
+			if err := DB.Where("role_id = ? AND permission_id = ?", role.ID, old.ID).Find(&scopes).Error; err != nil {
+				return err
+			}
+			for _, scope := range scopes {
+				scope.ID = 0
+				scope.PermissionID = perm.ID
+				scope.Permission = models.Permission{}
+				if err := DB.Omit("Permission", "Department").Create(&scope).Error; err != nil {
+					return err
+				}
+			}
+			log.Printf("Granted %s to role %s (had %s)", perm.Slug, role.Name, old.Slug)
 		}
 	}
+	return nil
+}
+
+// roleSeed role เริ่มต้น - Parent สืบทอด permissions (ดู services.EffectivePermissions)
+// Permissions ระบุเฉพาะที่เพิ่มจาก parent
+type roleSeed struct {
+	Name           string
+	Description    string
+	Parent         string
+	Permissions    []string
+	AllPermissions bool // ได้ทุก permission (sync ทุกครั้ง)
+	Reset          bool // กำหนด permissions ใหม่ทุกครั้งที่ start
+}
+
+var defaultRoles = []roleSeed{
+	{Name: "Super Admin", Description: "Full Access", AllPermissions: true},
+	{Name: "User", Description: "Standard User Access", Permissions: []string{"dashboard.view"}, Reset: true},
+	{Name: "HR Staff", Description: "View employees, attendance and leave requests", Parent: "User",
+		Permissions: []string{"admin.access", "employees.view", "attendance.view", "leaves.view"}},
+	{Name: "HR Manager", Description: "Manage employees, HR master data and approve leave", Parent: "HR Staff",
+		Permissions: []string{"employees.manage", "attendance.manage", "leaves.manage", "departments.manage", "positions.manage"}},
+}
+
+func seedRBAC() {
+	// 1. Permissions ประกาศพร้อม routes (internal/routes/permissions.go) และ sync ด้วย rbac.Sync
 
-	// User gets limited permissions (e.g. just dashboard)
-	var userRole models.Role
-	if err := DB.Where("name = ?", "User").First(&userRole).Error; err == nil {
-		var userPerms []models.Permission
-		DB.Where("slug IN ?", []string{"dashboard.view"}).Find(&userPerms)
-		DB.Model(&userRole).Association("Permissions").Replace(userPerms)
+	// 2. Create Roles (ตามลำดับ - parent ต้องมาก่อน)
+	for _, seed := range defaultRoles {
+		var role models.Role
+		result := DB.Where(models.Role{Name: seed.Name}).FirstOrCreate(&role)
+		if result.Error != nil {
+			log.Printf("Error finding/creating role %s: %v", seed.Name, result.Error)
+			continue
+		}
+		if err := DB.Model(&role).Updates(models.Role{Description: seed.Description}).Error; err != nil {
+			log.Printf("Error updating role %s: %v", seed.Name, err)
+		}
+		// ตั้ง parent เฉพาะตอนสร้าง role ใหม่ - ไม่ทับโครงสร้างที่ admin แก้ไว้
+		if seed.Parent != "" && result.RowsAffected > 0 {
+			var parent models.Role
+			if err := DB.Where("name = ?", seed.Parent).First(&parent).Error; err == nil {
+				DB.Model(&role).Update("parent_id", parent.ID)
+			}
+		}
 	}
 
+	// 3. Role permissions ถูกกำหนดใน SyncPermissions
+
 	// 4. Seed Menus
 	menus := []models.Menu{
 		{Path: "/admin", Title: "Dashboard", Icon: "LayoutDashboard", PermissionSlug: "dashboard.view", Order: 1},
 		{Path: "/admin/users", Title: "Users", Icon: "User", PermissionSlug: "users.view", Order: 2},
-		{Path: "/admin/employees", Title: "Employees", Icon: "Briefcase", PermissionSlug: "users.manage", Order: 3},
-		{Path: "/admin/hr/departments", Title: "Departments", Icon: "Building", PermissionSlug: "users.manage", Order: 4},
-		{Path: "/admin/hr/positions", Title: "Positions", Icon: "BadgeCheck", PermissionSlug: "users.manage", Order: 5},
+		{Path: "/admin/employees", Title: "Employees", Icon: "Briefcase", PermissionSlug: "employees.view", Order: 3},
+		{Path: "/admin/hr/departments", Title: "Departments", Icon: "Building", PermissionSlug: "departments.manage", Order: 4},
+		{Path: "/admin/hr/positions", Title: "Positions", Icon: "BadgeCheck", PermissionSlug: "positions.manage", Order: 5},
 		{Path: "/admin/projects", Title: "Projects", Icon: "FolderKanban", PermissionSlug: "projects.view", Order: 6},
 		{Path: "/admin/categories", Title: "Categories", Icon: "Tags", PermissionSlug: "categories.view", Order: 7},
 		{Path: "/admin/roles", Title: "Roles", Icon: "Shield", PermissionSlug: "roles.view", Order: 8},
@@ -206,13 +469,21 @@ func seedRBAC() {
 
 	for _, m := range menus {
 		var menu models.Menu
-		if err := DB.Where(models.Menu{Path: m.Path}).FirstOrCreate(&menu).Error; err != nil {
+		if err := DB.Where(models.Menu{Path: m.Path}).Attrs(models.Menu{Order: m.Order}).FirstOrCreate(&menu).Error; err != nil {
 			log.Printf("Error finding/creating menu %s: %v", m.Path, err)
 			continue
 		}
-		if err := DB.Model(&menu).Updates(m).Error; err != nil {
+		// order กำหนดตอนสร้างเท่านั้น - หลังจากนั้นเป็นของหน้าจัดเมนู (drag-and-drop)
+		if err := DB.Model(&menu).Omit("Order").Updates(m).Error; err != nil {
 			log.Printf("Error updating menu %s: %v", m.Path, err)
 		}
+
+		// ชื่อภาษาไทย - สร้างครั้งแรกเท่านั้น (แก้ไขต่อได้ในหน้าจัดเมนู)
+		if title, ok := menuTitlesTH[m.Path]; ok {
+			DB.Where(models.MenuTranslation{MenuID: menu.ID, Locale: "th"}).
+				Attrs(models.MenuTranslation{Title: title}).
+				FirstOrCreate(&models.MenuTranslation{})
+		}
 	}
 
 	// 5. Seed Admin Employee
@@ -235,6 +506,24 @@ func seedRBAC() {
 	}
 }
 
+// menuTitlesTH ชื่อเมนูภาษาไทยของเมนูที่ seed (Menu.Title เป็นภาษาอังกฤษ)
+var menuTitlesTH = map[string]string{
+	"/admin":                "แดชบอร์ด",
+	"/admin/users":          "ผู้ใช้งาน",
+	"/admin/employees":      "พนักงาน",
+	"/admin/hr/departments": "แผนก",
+	"/admin/hr/positions":   "ตำแหน่ง",
+	"/admin/projects":       "โปรเจกต์",
+	"/admin/categories":     "หมวดหมู่",
+	"/admin/roles":          "บทบาทและสิทธิ์",
+	"/admin/menus":          "เมนู",
+	"/admin/settings":       "ตั้งค่า",
+	"/admin/attendance":     "การลงเวลา",
+	"/admin/leaves":         "การลา",
+	"/admin/audit-logs":     "บันทึกการใช้งาน",
+	"/admin/profile":        "โปรไฟล์ของฉัน",
+}
+
 func seedCategories() {
 	categories := []models.Category{
 		{Name: "Architecture", Slug: "architecture", SortOrder: 1, IsActive: true},
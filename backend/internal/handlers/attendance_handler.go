@@ -3,7 +3,9 @@ package handlers
 import (
 	"backend/internal/database"
 	"backend/internal/models"
+	"backend/internal/services"
 	"backend/pkg/utils"
+	"errors"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -30,12 +32,12 @@ func CheckIn(c *fiber.Ctx) error {
 
 	var employee models.Employee
 	if err := database.DB.Where("user_id = ?", intUID).First(&employee).Error; err != nil {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Employee profile not found"})
+		return utils.SendAppError(c, utils.ErrEmployeeProfileNotFound)
 	}
 
 	var req CheckInOutRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 
 	today := time.Now().Truncate(24 * time.Hour) // Midnight today
@@ -43,7 +45,7 @@ func CheckIn(c *fiber.Ctx) error {
 	// Check if already checked in today
 	var existing models.Attendance
 	if err := database.DB.Where("employee_id = ? AND date = ?", employee.ID, today).First(&existing).Error; err == nil {
-		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Already checked in today"})
+		return utils.SendAppError(c, utils.ErrAlreadyCheckedIn)
 	}
 
 	now := time.Now()
@@ -63,7 +65,7 @@ func CheckIn(c *fiber.Ctx) error {
 	}
 
 	if err := database.DB.Create(&attendance).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check in"})
+		return utils.SendAppError(c, utils.ErrCheckInFailed)
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(attendance)
@@ -79,12 +81,12 @@ func CheckOut(c *fiber.Ctx) error {
 
 	var employee models.Employee
 	if err := database.DB.Where("user_id = ?", uid).First(&employee).Error; err != nil {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Employee profile not found"})
+		return utils.SendAppError(c, utils.ErrEmployeeProfileNotFound)
 	}
 
 	var req CheckInOutRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 
 	today := time.Now().Truncate(24 * time.Hour)
@@ -92,13 +94,13 @@ func CheckOut(c *fiber.Ctx) error {
 	var attendance models.Attendance
 	if err := database.DB.Where("employee_id = ? AND date = ?", employee.ID, today).First(&attendance).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "No check-in record found for today"})
+			return utils.SendAppError(c, utils.ErrNoCheckIn)
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Database error"})
+		return utils.SendAppError(c, utils.ErrDatabase)
 	}
 
 	if attendance.CheckOutTime != nil {
-		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Already checked out"})
+		return utils.SendAppError(c, utils.ErrAlreadyCheckedOut)
 	}
 
 	now := time.Now()
@@ -126,7 +128,7 @@ func GetAttendanceHistory(c *fiber.Ctx) error {
 
 	var employee models.Employee
 	if err := database.DB.Where("user_id = ?", uid).First(&employee).Error; err != nil {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Employee profile not found"})
+		return utils.SendAppError(c, utils.ErrEmployeeProfileNotFound)
 	}
 
 	var history []models.Attendance
@@ -145,7 +147,7 @@ func GetTodayAttendanceStatus(c *fiber.Ctx) error {
 
 	var employee models.Employee
 	if err := database.DB.Where("user_id = ?", uid).First(&employee).Error; err != nil {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Employee profile not found"})
+		return utils.SendAppError(c, utils.ErrEmployeeProfileNotFound)
 	}
 
 	today := time.Now().Truncate(24 * time.Hour)
@@ -154,7 +156,7 @@ func GetTodayAttendanceStatus(c *fiber.Ctx) error {
 		if err == gorm.ErrRecordNotFound {
 			return c.JSON(fiber.Map{"status": "Not Checked In"})
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Database error"})
+		return utils.SendAppError(c, utils.ErrDatabase)
 	}
 
 	status := "Checked In"
@@ -170,7 +172,22 @@ func GetTodayAttendanceStatus(c *fiber.Ctx) error {
 
 // GetEmployeeAttendance (Admin)
 func GetEmployeeAttendance(c *fiber.Ctx) error {
-	employeeID := c.Params("id")
+	employeeID, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrEmployeeIDInvalid)
+	}
+
+	scope, err := employeeScope(c, "attendance.view")
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrScopeResolve)
+	}
+	if err := services.EmployeeScopeAllows(scope, uint(employeeID)); err != nil {
+		if errors.Is(err, services.ErrOutOfScope) {
+			return sendOutOfScope(c)
+		}
+		return utils.SendAppError(c, utils.ErrEmployeeNotFound)
+	}
+
 	var history []models.Attendance
 	database.DB.Where("employee_id = ?", employeeID).Order("date desc").Limit(30).Find(&history)
 	return c.JSON(history)
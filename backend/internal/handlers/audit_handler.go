@@ -25,6 +25,7 @@ func GetAuditLogs(c *fiber.Ctx) error {
 	page := c.QueryInt("page", 1)
 	limit := c.QueryInt("limit", 10)
 	search := c.Query("search")
+	impersonatorID := c.QueryInt("impersonator_id", 0)
 	offset := (page - 1) * limit
 
 	var logs []models.AuditLog
@@ -36,6 +37,9 @@ func GetAuditLogs(c *fiber.Ctx) error {
 		searchTerm := "%" + search + "%"
 		query = query.Where("action ILIKE ? OR details ILIKE ? OR entity_type ILIKE ?", searchTerm, searchTerm, searchTerm)
 	}
+	if impersonatorID > 0 {
+		query = query.Where("impersonator_id = ?", impersonatorID)
+	}
 
 	query.Count(&total)
 
@@ -58,6 +62,9 @@ func GetAuditLogs(c *fiber.Ctx) error {
 	if search != "" {
 		filters["search"] = search
 	}
+	if impersonatorID > 0 {
+		filters["impersonator_id"] = impersonatorID
+	}
 
 	return utils.SendSuccessWithPagination(c, logs, pagination, filters, "Audit logs retrieved successfully")
 }
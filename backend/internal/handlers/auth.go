@@ -8,10 +8,13 @@ import (
 	"backend/pkg/utils"
 	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type LoginInput struct {
@@ -29,6 +32,8 @@ type RegisterInput struct {
 	Address   string `json:"address"`
 	LineID    string `json:"line_id"`
 	Info      string `json:"info"`
+
+	InviteToken string `json:"invite_token"` // จำเป็นเมื่อ registration_mode = invite_only
 }
 
 // Login godoc
@@ -41,45 +46,94 @@ type RegisterInput struct {
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
 // @Router /auth/login [post]
 func Login(c *fiber.Ctx) error {
 	var input LoginInput
 	if err := c.BodyParser(&input); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
 	}
 
 	var user models.User
 	if err := database.DB.Preload("Role.Permissions").Where("username = ?", input.Username).First(&user).Error; err != nil {
-		return utils.SendError(c, fiber.StatusUnauthorized, errors.New("invalid username or password"))
+		if block := services.CheckLoginAllowed(0, c.IP()); block != nil {
+			return sendLoginBlocked(c, block)
+		}
+		recordLoginFailure(c, nil, "password")
+		return utils.SendAppError(c, utils.ErrInvalidCredentials)
+	}
+
+	// ตรวจ lockout / backoff ก่อนตรวจรหัสผ่าน
+	if block := services.CheckLoginAllowed(user.ID, c.IP()); block != nil {
+		return sendLoginBlocked(c, block)
 	}
 
 	if !user.Active {
-		return utils.SendError(c, fiber.StatusUnauthorized, errors.New("user is inactive"))
+		return utils.SendAppError(c, utils.ErrUserInactive)
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
-		return utils.SendError(c, fiber.StatusUnauthorized, errors.New("invalid username or password"))
+		recordLoginFailure(c, &user, "password")
+		return utils.SendAppError(c, utils.ErrInvalidCredentials)
+	}
+
+	return beginLogin(c, &user, "password")
+}
+
+// beginLogin เรียกหลังตรวจสอบ credential ขั้นแรกผ่านแล้ว
+// ถ้า user เปิด 2FA ไว้จะคืน challenge token แทน token จริง (ต้องแลกที่ /auth/login/2fa)
+func beginLogin(c *fiber.Ctx, user *models.User, method string) error {
+	switch user.ApprovalStatus {
+	case models.ApprovalPending:
+		return utils.SendAppError(c, utils.ErrAccountPending)
+	case models.ApprovalRejected:
+		return utils.SendAppError(c, utils.ErrAccountRejected)
+	}
+
+	if !user.EmailVerified && services.EmailVerificationMode() == services.EmailVerificationBlock {
+		return utils.SendAppError(c, utils.ErrEmailNotVerified)
+	}
+
+	if user.TwoFactorEnabled {
+		challenge, err := utils.GenerateChallengeToken(user.ID, user.Username, utils.PurposeTwoFactor, method, services.TwoFactorChallengeTTL)
+		if err != nil {
+			return utils.SendAppError(c, utils.ErrTokenGeneration)
+		}
+
+		services.CreateAuditLog(c, "TWO_FACTOR_CHALLENGE", user.ID, "user", map[string]string{"username": user.Username, "method": method}, user.ID)
+
+		return utils.SendSuccess(c, fiber.Map{
+			"two_factor_required": true,
+			"challenge_token":     challenge,
+			"expires_in":          int(services.TwoFactorChallengeTTL.Seconds()),
+		}, "Two-factor authentication required")
 	}
 
+	return completeLogin(c, user, method)
+}
+
+// completeLogin ออก token + session และส่ง response ของการ login (ใช้ร่วมกันทุกช่องทาง login)
+// ⚠️ user ต้อง preload Role.Permissions มาก่อน
+func completeLogin(c *fiber.Ctx, user *models.User, method string) error {
 	// Store previous login time before updating
 	previousLastLogin := user.LastLogin
 
 	// Update last login time
 	now := time.Now()
 	user.LastLogin = &now
-	database.DB.Save(&user)
+	database.DB.Model(user).Update("last_login", now)
 
-	// Flatten permissions
-	permissions := []string{}
-	if user.RoleID != nil {
-		for _, p := range user.Role.Permissions {
-			permissions = append(permissions, p.Slug)
-		}
-	}
+	// login สำเร็จ - ล้างตัวนับการ login ผิด
+	services.ResetLoginFailures(user.ID)
 
-	accessToken, refreshToken, err := utils.GenerateTokens(user.ID, user.Username)
+	permissions := services.PermissionSlugs(user)
+
+	tokens, err := services.IssueTokens(user, sessionMeta(c, method))
 	if err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not generate tokens"))
+		return utils.SendAppError(c, utils.ErrTokenGeneration)
 	}
 
 	// Send login notification email (async)
@@ -94,28 +148,75 @@ func Login(c *fiber.Ctx) error {
 	}()
 
 	// Audit Log
-	services.CreateAuditLog(c, "USER_LOGIN", user.ID, "user", map[string]string{"username": user.Username}, user.ID)
+	action := "USER_LOGIN"
+	if method != "password" {
+		action = "USER_LOGIN_" + strings.ToUpper(method)
+	}
+	services.CreateAuditLog(c, action, user.ID, "user", map[string]string{"username": user.Username}, user.ID)
 
 	return utils.SendSuccess(c, fiber.Map{
-		"token":         accessToken,
-		"refresh_token": refreshToken,
+		"token":                     tokens.AccessToken,
+		"refresh_token":             tokens.RefreshToken,
+		"two_factor_setup_required": services.TwoFactorRequiredByRole(user) && !user.TwoFactorEnabled,
+		"password_change_required":  services.PasswordChangeRequired(user),
 		"user": fiber.Map{
-			"id":          user.ID,
-			"username":    user.Username,
-			"email":       user.Email,
-			"first_name":  user.FirstName,
-			"last_name":   user.LastName,
-			"role":        user.Role.Name,
-			"phone":       user.Phone,
-			"address":     user.Address,
-			"line_id":     user.LineID,
-			"info":        user.Info,
-			"permissions": permissions,
-			"last_login":  previousLastLogin,
+			"id":                 user.ID,
+			"username":           user.Username,
+			"email":              user.Email,
+			"first_name":         user.FirstName,
+			"last_name":          user.LastName,
+			"role":               user.Role.Name,
+			"phone":              user.Phone,
+			"address":            user.Address,
+			"line_id":            user.LineID,
+			"info":               user.Info,
+			"permissions":        permissions,
+			"pin_enabled":        user.PINEnabled,
+			"two_factor_enabled": user.TwoFactorEnabled,
+			"last_login":         previousLastLogin,
 		},
 	}, "Login successful")
 }
 
+// frontendURL base URL ของ frontend สำหรับสร้าง link ใน email
+// ⚠️ ห้ามใช้ Origin ของ request ตรง ๆ - endpoint สาธารณะ (เช่น magic link) จะส่ง token ไปยัง host ที่ผู้เรียกกำหนดได้
+func frontendURL(c *fiber.Ctx) string {
+	return pickFrontendURL(os.Getenv("FRONTEND_URL"), os.Getenv("ALLOWED_ORIGINS"), c.Get("Origin"))
+}
+
+// pickFrontendURL ใช้ FRONTEND_URL ถ้าตั้งไว้ ไม่งั้นใช้ Origin เฉพาะที่อยู่ใน ALLOWED_ORIGINS
+// (Origin อื่น = origin แรกใน list)
+func pickFrontendURL(configured, allowedOrigins, origin string) string {
+	if configured = strings.TrimSpace(configured); configured != "" {
+		return strings.TrimRight(configured, "/")
+	}
+
+	var allowed []string
+	for _, o := range strings.Split(allowedOrigins, ",") {
+		if o = strings.TrimRight(strings.TrimSpace(o), "/"); o != "" && o != "*" {
+			allowed = append(allowed, o)
+		}
+	}
+	for _, o := range allowed {
+		if strings.EqualFold(o, origin) {
+			return o
+		}
+	}
+	if len(allowed) > 0 {
+		return allowed[0]
+	}
+	return "http://localhost:3000" // Default
+}
+
+// sessionMeta อ่านข้อมูลอุปกรณ์จาก request สำหรับบันทึก session
+func sessionMeta(c *fiber.Ctx, method string) services.SessionMeta {
+	return services.SessionMeta{
+		IPAddress:   c.IP(),
+		UserAgent:   c.Get("User-Agent"),
+		LoginMethod: method,
+	}
+}
+
 // Register godoc
 // @Summary Register user
 // @Description Register a new user
@@ -130,38 +231,68 @@ func Login(c *fiber.Ctx) error {
 func Register(c *fiber.Ctx) error {
 	var input RegisterInput
 	if err := c.BodyParser(&input); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	}
+
+	mode := services.RegistrationMode()
+	if mode == services.RegistrationClosed {
+		return utils.SendAppError(c, utils.ErrRegistrationClosed)
+	}
+
+	// Invitation (บังคับเมื่อ invite_only) - ผู้ที่ได้รับเชิญไม่ต้องผ่าน domain allow-list และการอนุมัติ
+	var invitation *models.Invitation
+	if input.InviteToken != "" {
+		inv, err := services.FindValidInvitation(input.InviteToken)
+		if err != nil {
+			return utils.SendAppError(c, utils.ErrInvitationInvalid)
+		}
+		if inv.Email != "" && !strings.EqualFold(inv.Email, strings.TrimSpace(input.Email)) {
+			return utils.SendAppError(c, utils.ErrInvitationMismatch)
+		}
+		invitation = inv
+	}
+
+	approval := models.ApprovalApproved
+	if invitation == nil {
+		status, err := services.SelfRegistrationStatus(input.Email)
+		if err != nil {
+			return utils.SendError(c, fiber.StatusForbidden, err)
+		}
+		approval = status
 	}
 
 	// Check if user exists (username or email)
 	var existingUser models.User
 	if err := database.DB.Where("username = ? OR email = ?", input.Username, input.Email).First(&existingUser).Error; err == nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("username or email already exists"))
+		return utils.SendAppError(c, utils.ErrUsernameOrEmailTaken)
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
-	if err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not hash password"))
+	if err := services.ValidateNewPassword(nil, input.Password); err != nil {
+		return sendPasswordPolicyError(c, err)
 	}
 
-	// Default Role (User)
+	// Default Role (User) - invitation กำหนด role ไว้ล่วงหน้าได้
 	var role models.Role
-	// Try to find the user role
-	if err := database.DB.Where("name = ?", "user").First(&role).Error; err != nil {
+	if invitation != nil && invitation.RoleID != nil {
+		role = invitation.Role
+	} else if err := database.DB.Where("name = ?", "user").First(&role).Error; err != nil {
 		// If role doesn't exist, we might want to handle it or let it run without role
 	}
 
 	user := models.User{
-		Username:  input.Username,
-		Password:  string(hashedPassword),
-		Email:     input.Email,
-		FirstName: input.FirstName,
-		LastName:  input.LastName,
-		Active:    true,
-		Phone:     input.Phone,
-		Address:   input.Address,
-		LineID:    input.LineID,
-		Info:      input.Info,
+		Username:       input.Username,
+		Email:          input.Email,
+		FirstName:      input.FirstName,
+		LastName:       input.LastName,
+		Active:         true,
+		Phone:          input.Phone,
+		Address:        input.Address,
+		LineID:         input.LineID,
+		Info:           input.Info,
+		ApprovalStatus: approval,
 	}
 
 	// Only assign role if found
@@ -169,12 +300,49 @@ func Register(c *fiber.Ctx) error {
 		user.RoleID = &role.ID
 	}
 
-	if err := database.DB.Create(&user).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not create user"))
+	// invite ที่ส่งไปยัง email ที่ระบุ = ยืนยันอีเมลแล้วในตัว
+	if invitation != nil && invitation.Email != "" {
+		now := time.Now()
+		user.EmailVerified = true
+		user.EmailVerifiedAt = &now
+	}
+
+	if err := services.SetUserPassword(database.DB, &user, input.Password, false); err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not hash password: %w", err)))
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		if invitation != nil {
+			return services.ConsumeInvitation(tx, invitation.ID, user.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrInvitationInvalid) {
+			return utils.SendAppError(c, utils.ErrInvitationInvalid)
+		}
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not create user: %w", err)))
+	}
+
+	// Audit Log
+	details := map[string]interface{}{"username": user.Username, "email": user.Email, "mode": mode, "approval_status": user.ApprovalStatus}
+	if invitation != nil {
+		details["invitation_id"] = invitation.ID
+	}
+	services.CreateAuditLog(c, "USER_REGISTER", user.ID, "user", details, user.ID)
+
+	verificationRequired := !user.EmailVerified && services.EmailVerificationMode() != services.EmailVerificationOff
+	if verificationRequired {
+		sendVerificationEmail(c, &user)
 	}
 
 	return utils.SendCreated(c, fiber.Map{
-		"message": "User created successfully",
+		"message":                     "User created successfully",
+		"email_verification_required": verificationRequired,
+		"approval_required":           user.ApprovalStatus == models.ApprovalPending,
 		"user": fiber.Map{
 			"id":          user.ID,
 			"username":    user.Username,
@@ -209,34 +377,99 @@ type RefreshTokenInput struct {
 func RefreshToken(c *fiber.Ctx) error {
 	var input RefreshTokenInput
 	if err := c.BodyParser(&input); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
 	}
 
-	claims, err := utils.ParseToken(input.RefreshToken)
+	user, tokens, record, err := services.RotateRefreshToken(input.RefreshToken, sessionMeta(c, ""))
 	if err != nil {
-		return utils.SendError(c, fiber.StatusUnauthorized, errors.New("invalid or expired refresh token"))
+		if errors.Is(err, services.ErrRefreshTokenReused) {
+			// token ที่ rotate ไปแล้วถูกนำกลับมาใช้ - อาจถูกขโมย จึง revoke ทั้ง family
+			services.CreateAuditLog(c, "REFRESH_TOKEN_REUSE", record.UserID, "user", map[string]string{"family_id": record.FamilyID}, record.UserID)
+			return utils.SendAppError(c, utils.ErrRefreshTokenRevoked)
+		}
+		if user != nil && !user.Active {
+			return utils.SendAppError(c, utils.ErrUserInactive)
+		}
+		if errors.Is(err, services.ErrRefreshTokenStale) {
+			return utils.SendAppError(c, utils.ErrTokenVersionChanged)
+		}
+		if errors.Is(err, services.ErrRefreshTokenInvalid) {
+			return utils.SendError(c, fiber.StatusUnauthorized, err)
+		}
+		return utils.SendAppError(c, utils.ErrTokenGeneration)
 	}
 
-	// Verify user still exists and is active
-	var user models.User
-	if err := database.DB.First(&user, claims.UserID).Error; err != nil {
-		return utils.SendError(c, fiber.StatusUnauthorized, errors.New("user not found"))
+	return utils.SendSuccess(c, fiber.Map{
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+	}, "Token refreshed successfully")
+}
+
+type LogoutInput struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// Logout godoc
+// @Summary Logout
+// @Description Revoke the refresh token (and its rotation family) of the current session
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param input body LogoutInput true "Refresh Token"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /auth/logout [post]
+func Logout(c *fiber.Ctx) error {
+	var input LogoutInput
+	if err := c.BodyParser(&input); err != nil {
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
 	}
 
-	if !user.Active {
-		return utils.SendError(c, fiber.StatusUnauthorized, errors.New("user is inactive"))
+	record, err := services.RevokeRefreshToken(input.RefreshToken)
+	if err != nil {
+		if errors.Is(err, services.ErrRefreshTokenInvalid) {
+			// ไม่เปิดเผยว่า token มีอยู่จริงหรือไม่ - logout ถือว่าสำเร็จเสมอ
+			return utils.SendSuccess(c, nil, utils.MsgLoggedOut)
+		}
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not logout: %w", err)))
+	}
+
+	services.CreateAuditLog(c, "USER_LOGOUT", record.UserID, "user", map[string]string{"family_id": record.FamilyID}, record.UserID)
+
+	return utils.SendSuccess(c, nil, utils.MsgLoggedOut)
+}
+
+// LogoutAll godoc
+// @Summary Logout from all devices
+// @Description Revoke every refresh token of the current user
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /auth/logout-all [post]
+func LogoutAll(c *fiber.Ctx) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusUnauthorized, err)
 	}
 
-	// Generate new tokens
-	accessToken, newRefreshToken, err := utils.GenerateTokens(user.ID, user.Username)
+	revoked, err := services.RevokeAllSessions(userID)
 	if err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not generate tokens"))
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not logout: %w", err)))
 	}
 
+	services.CreateAuditLog(c, "USER_LOGOUT_ALL", userID, "user", map[string]int64{"revoked_sessions": revoked}, userID)
+
 	return utils.SendSuccess(c, fiber.Map{
-		"token":         accessToken,
-		"refresh_token": newRefreshToken,
-	}, "Token refreshed successfully")
+		"revoked_sessions": revoked,
+	}, "Logged out from all devices")
 }
 
 type ChangePasswordInput struct {
@@ -244,6 +477,15 @@ type ChangePasswordInput struct {
 	NewPassword string `json:"new_password"`
 }
 
+// sendPasswordPolicyError ตอบกลับเมื่อรหัสผ่านไม่ผ่าน policy - details = รายการ requirements ที่ไม่ผ่าน (frontend แสดงเป็นรายการ)
+func sendPasswordPolicyError(c *fiber.Ctx, err error) error {
+	var policyErr *services.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not validate password: %w", err)))
+	}
+	return utils.SendAppError(c, utils.ErrPasswordPolicy.WithDetails(policyErr.Messages))
+}
+
 // ChangePassword godoc
 // @Summary Change password
 // @Description Change user password with strength validation
@@ -264,43 +506,32 @@ func ChangePassword(c *fiber.Ctx) error {
 	var input ChangePasswordInput
 
 	if err := c.BodyParser(&input); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("user not found"))
+		return utils.SendAppError(c, utils.ErrUserNotFound)
 	}
 
 	// Verify old password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.OldPassword)); err != nil {
-		return utils.SendError(c, fiber.StatusUnauthorized, errors.New("incorrect old password"))
+		return utils.SendAppError(c, utils.ErrIncorrectOldPassword)
 	}
 
-	// Validate password strength
-	strengthResult := utils.ValidatePasswordStrength(input.NewPassword)
-	if !strengthResult.IsValid {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"message":  "Password does not meet strength requirements",
-				"messages": strengthResult.Messages,
-			},
-		})
+	// Validate password policy (strength, breached list, history)
+	if err := services.ValidateNewPassword(&user, input.NewPassword); err != nil {
+		return sendPasswordPolicyError(c, err)
 	}
 
-	// Hash new password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
-	if err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("failed to hash password"))
+	if err := services.SetUserPassword(database.DB, &user, input.NewPassword, false); err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("failed to update password: %w", err)))
 	}
 
-	user.Password = string(hashedPassword)
-	if err := database.DB.Save(&user).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("failed to update password"))
-	}
+	services.CreateAuditLog(c, "PASSWORD_CHANGED", user.ID, "user", nil, user.ID)
 
-	return utils.SendSuccess(c, nil, "Password changed successfully")
+	// token ปัจจุบันอาจยังมี flag pwd_change - client เรียก /auth/refresh เพื่อรับ token ใหม่
+	return utils.SendSuccess(c, nil, utils.MsgPasswordChanged)
 }
 
 // ============== PIN Handlers ==============
@@ -330,40 +561,42 @@ func SetPin(c *fiber.Ctx) error {
 
 	var input SetPinInput
 	if err := c.BodyParser(&input); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
 	}
 
 	// Validate PIN format
-	valid, msg := utils.ValidatePIN(input.PIN)
-	if !valid {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New(msg))
+	if valid, _ := utils.ValidatePIN(input.PIN); !valid {
+		return utils.SendAppError(c, utils.ErrPINInvalid)
 	}
 
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("user not found"))
+		return utils.SendAppError(c, utils.ErrUserNotFound)
 	}
 
 	// Verify password before allowing PIN setup
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
-		return utils.SendError(c, fiber.StatusUnauthorized, errors.New("incorrect password"))
+		return utils.SendAppError(c, utils.ErrIncorrectPassword)
 	}
 
 	// Hash PIN
 	hashedPIN, err := bcrypt.GenerateFromPassword([]byte(input.PIN), bcrypt.DefaultCost)
 	if err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("failed to hash PIN"))
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("failed to hash PIN: %w", err)))
 	}
 
 	user.PIN = string(hashedPIN)
 	user.PINEnabled = true
 	if err := database.DB.Save(&user).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("failed to save PIN"))
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("failed to save PIN: %w", err)))
 	}
 
 	services.CreateAuditLog(c, "PIN_SET", user.ID, "user", nil, user.ID)
 
-	return utils.SendSuccess(c, nil, "PIN set successfully")
+	return utils.SendSuccess(c, nil, utils.MsgPINSet)
 }
 
 // DisablePin godoc
@@ -384,18 +617,18 @@ func DisablePin(c *fiber.Ctx) error {
 
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("user not found"))
+		return utils.SendAppError(c, utils.ErrUserNotFound)
 	}
 
 	user.PIN = ""
 	user.PINEnabled = false
 	if err := database.DB.Save(&user).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("failed to disable PIN"))
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("failed to disable PIN: %w", err)))
 	}
 
 	services.CreateAuditLog(c, "PIN_DISABLED", user.ID, "user", nil, user.ID)
 
-	return utils.SendSuccess(c, nil, "PIN disabled successfully")
+	return utils.SendSuccess(c, nil, utils.MsgPINDisabled)
 }
 
 type LoginWithPinInput struct {
@@ -413,81 +646,45 @@ type LoginWithPinInput struct {
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
 // @Router /auth/login-pin [post]
 func LoginWithPin(c *fiber.Ctx) error {
 	var input LoginWithPinInput
 	if err := c.BodyParser(&input); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
 	}
 
 	var user models.User
 	if err := database.DB.Preload("Role.Permissions").Where("username = ?", input.Username).First(&user).Error; err != nil {
-		return utils.SendError(c, fiber.StatusUnauthorized, errors.New("invalid username or PIN"))
+		if block := services.CheckLoginAllowed(0, c.IP()); block != nil {
+			return sendLoginBlocked(c, block)
+		}
+		recordLoginFailure(c, nil, "pin")
+		return utils.SendAppError(c, utils.ErrInvalidPINCredentials)
+	}
+
+	if block := services.CheckLoginAllowed(user.ID, c.IP()); block != nil {
+		return sendLoginBlocked(c, block)
 	}
 
 	if !user.Active {
-		return utils.SendError(c, fiber.StatusUnauthorized, errors.New("user is inactive"))
+		return utils.SendAppError(c, utils.ErrUserInactive)
 	}
 
 	if !user.PINEnabled || user.PIN == "" {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("PIN login is not enabled for this account"))
+		return utils.SendAppError(c, utils.ErrPINLoginDisabled)
 	}
 
 	// Verify PIN
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PIN), []byte(input.PIN)); err != nil {
-		return utils.SendError(c, fiber.StatusUnauthorized, errors.New("invalid username or PIN"))
-	}
-
-	// Store previous login time before updating
-	previousLastLogin := user.LastLogin
-
-	// Update last login time
-	now := time.Now()
-	user.LastLogin = &now
-	database.DB.Save(&user)
-
-	// Flatten permissions
-	permissions := []string{}
-	if user.RoleID != nil {
-		for _, p := range user.Role.Permissions {
-			permissions = append(permissions, p.Slug)
-		}
-	}
-
-	accessToken, refreshToken, err := utils.GenerateTokens(user.ID, user.Username)
-	if err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not generate tokens"))
+		recordLoginFailure(c, &user, "pin")
+		return utils.SendAppError(c, utils.ErrInvalidPINCredentials)
 	}
 
-	// Send login notification email (async)
-	go func() {
-		if err := email.SendLoginNotification(user.Email, user.Username); err != nil {
-			fmt.Printf("Failed to send login email: %v\n", err)
-		}
-	}()
-
-	// Audit Log
-	services.CreateAuditLog(c, "USER_LOGIN_PIN", user.ID, "user", map[string]string{"username": user.Username}, user.ID)
-
-	return utils.SendSuccess(c, fiber.Map{
-		"token":         accessToken,
-		"refresh_token": refreshToken,
-		"user": fiber.Map{
-			"id":          user.ID,
-			"username":    user.Username,
-			"email":       user.Email,
-			"first_name":  user.FirstName,
-			"last_name":   user.LastName,
-			"role":        user.Role.Name,
-			"phone":       user.Phone,
-			"address":     user.Address,
-			"line_id":     user.LineID,
-			"info":        user.Info,
-			"permissions": permissions,
-			"pin_enabled": user.PINEnabled,
-			"last_login":  previousLastLogin,
-		},
-	}, "Login successful")
+	return beginLogin(c, &user, "pin")
 }
 
 // ============== Forgot Password Handlers ==============
@@ -509,20 +706,23 @@ type ForgotPasswordInput struct {
 func ForgotPassword(c *fiber.Ctx) error {
 	var input ForgotPasswordInput
 	if err := c.BodyParser(&input); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
 	}
 
 	// Find user by email
 	var user models.User
 	if err := database.DB.Where("email = ?", input.Email).First(&user).Error; err != nil {
 		// Don't reveal if email exists or not (security)
-		return utils.SendSuccess(c, nil, "If your email is registered, you will receive a password reset link")
+		return utils.SendSuccess(c, nil, utils.MsgPasswordResetRequested)
 	}
 
 	// Generate reset token
 	token, err := utils.GenerateResetToken()
 	if err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not generate reset token"))
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not generate reset token: %w", err)))
 	}
 
 	// Save reset token to database
@@ -537,15 +737,11 @@ func ForgotPassword(c *fiber.Ctx) error {
 	database.DB.Where("user_id = ? AND used = ?", user.ID, false).Delete(&models.PasswordReset{})
 
 	if err := database.DB.Create(&resetRecord).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not create reset token"))
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not create reset token: %w", err)))
 	}
 
 	// Build reset link (frontend URL)
-	frontendURL := "http://localhost:3000" // Default
-	if envURL := c.Get("Origin"); envURL != "" {
-		frontendURL = envURL
-	}
-	resetLink := fmt.Sprintf("%s/reset-password?token=%s", frontendURL, token)
+	resetLink := fmt.Sprintf("%s/reset-password?token=%s", frontendURL(c), token)
 
 	// Send email (async)
 	go func() {
@@ -556,7 +752,7 @@ func ForgotPassword(c *fiber.Ctx) error {
 		}
 	}()
 
-	return utils.SendSuccess(c, nil, "If your email is registered, you will receive a password reset link")
+	return utils.SendSuccess(c, nil, utils.MsgPasswordResetRequested)
 }
 
 // VerifyResetToken godoc
@@ -572,12 +768,12 @@ func ForgotPassword(c *fiber.Ctx) error {
 func VerifyResetToken(c *fiber.Ctx) error {
 	token := c.Params("token")
 	if token == "" {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("token is required"))
+		return utils.SendAppError(c, utils.ErrTokenRequired)
 	}
 
 	var resetRecord models.PasswordReset
 	if err := database.DB.Where("token = ? AND used = ? AND expires_at > ?", token, false, time.Now()).First(&resetRecord).Error; err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid or expired token"))
+		return utils.SendAppError(c, utils.ErrTokenInvalid)
 	}
 
 	return utils.SendSuccess(c, fiber.Map{
@@ -603,43 +799,32 @@ type ResetPasswordWithTokenInput struct {
 func ResetPassword(c *fiber.Ctx) error {
 	var input ResetPasswordWithTokenInput
 	if err := c.BodyParser(&input); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
 	}
 
 	// Find valid reset token
 	var resetRecord models.PasswordReset
 	if err := database.DB.Where("token = ? AND used = ? AND expires_at > ?", input.Token, false, time.Now()).First(&resetRecord).Error; err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid or expired token"))
-	}
-
-	// Validate password strength
-	strengthResult := utils.ValidatePasswordStrength(input.NewPassword)
-	if !strengthResult.IsValid {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"message":  "Password does not meet strength requirements",
-				"messages": strengthResult.Messages,
-			},
-		})
+		return utils.SendAppError(c, utils.ErrTokenInvalid)
 	}
 
 	// Find user
 	var user models.User
 	if err := database.DB.First(&user, resetRecord.UserID).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("user not found"))
+		return utils.SendAppError(c, utils.ErrUserNotFound)
 	}
 
-	// Hash new password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
-	if err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("failed to hash password"))
+	// Validate password policy (strength, breached list, history)
+	if err := services.ValidateNewPassword(&user, input.NewPassword); err != nil {
+		return sendPasswordPolicyError(c, err)
 	}
 
 	// Update user password
-	user.Password = string(hashedPassword)
-	if err := database.DB.Save(&user).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("failed to update password"))
+	if err := services.SetUserPassword(database.DB, &user, input.NewPassword, false); err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("failed to update password: %w", err)))
 	}
 
 	// Mark token as used
@@ -649,7 +834,7 @@ func ResetPassword(c *fiber.Ctx) error {
 	// Audit Log
 	services.CreateAuditLog(c, "PASSWORD_RESET", user.ID, "user", nil, user.ID)
 
-	return utils.SendSuccess(c, nil, "Password reset successfully. You can now login with your new password.")
+	return utils.SendSuccess(c, nil, utils.MsgPasswordResetLogin)
 }
 
 // GetPinStatus godoc
@@ -668,7 +853,7 @@ func GetPinStatus(c *fiber.Ctx) error {
 
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("user not found"))
+		return utils.SendAppError(c, utils.ErrUserNotFound)
 	}
 
 	return utils.SendSuccess(c, fiber.Map{
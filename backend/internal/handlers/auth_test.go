@@ -0,0 +1,27 @@
+package handlers
+
+import "testing"
+
+func TestPickFrontendURL(t *testing.T) {
+	allowed := "https://1931.co.th, https://admin.1931.co.th/"
+
+	tests := []struct {
+		name       string
+		configured string
+		allowed    string
+		origin     string
+		want       string
+	}{
+		{"configured wins over origin", "https://app.1931.co.th/", allowed, "https://admin.1931.co.th", "https://app.1931.co.th"},
+		{"allowed origin", "", allowed, "https://admin.1931.co.th", "https://admin.1931.co.th"},
+		{"foreign origin falls back", "", allowed, "https://evil.example", "https://1931.co.th"},
+		{"no origin", "", allowed, "", "https://1931.co.th"},
+		{"wildcard is not an origin", "", "*", "https://evil.example", "http://localhost:3000"},
+		{"nothing configured", "", "", "https://evil.example", "http://localhost:3000"},
+	}
+	for _, tt := range tests {
+		if got := pickFrontendURL(tt.configured, tt.allowed, tt.origin); got != tt.want {
+			t.Errorf("%s: pickFrontendURL = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -5,11 +5,11 @@ import (
 	"backend/internal/models"
 	"backend/internal/services"
 	"backend/pkg/utils"
-	"errors"
-	"regexp"
-	"strings"
+	"fmt"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GetCategories retrieves all categories (public)
@@ -24,7 +24,7 @@ import (
 func GetCategories(c *fiber.Ctx) error {
 	var categories []models.Category
 	if err := database.DB.Where("is_active = ?", true).Order("sort_order asc").Find(&categories).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not fetch categories"))
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch categories: %w", err)))
 	}
 	return utils.SendSuccess(c, categories, "Categories retrieved successfully")
 }
@@ -42,7 +42,7 @@ func GetCategories(c *fiber.Ctx) error {
 func GetAllCategories(c *fiber.Ctx) error {
 	var categories []models.Category
 	if err := database.DB.Order("sort_order asc").Find(&categories).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not fetch categories"))
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch categories: %w", err)))
 	}
 	return utils.SendSuccess(c, categories, "Categories retrieved successfully")
 }
@@ -62,7 +62,7 @@ func GetCategory(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var category models.Category
 	if err := database.DB.First(&category, id).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("category not found"))
+		return utils.SendAppError(c, utils.ErrCategoryNotFound)
 	}
 	return utils.SendSuccess(c, category, "Category retrieved successfully")
 }
@@ -83,26 +83,26 @@ func GetCategory(c *fiber.Ctx) error {
 func CreateCategory(c *fiber.Ctx) error {
 	category := new(models.Category)
 	if err := c.BodyParser(category); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 
 	if category.Name == "" {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("category name is required"))
+		return utils.SendAppError(c, utils.ErrNameRequired)
 	}
 
 	// Generate slug if not provided
 	if category.Slug == "" {
-		category.Slug = generateSlug(category.Name)
+		category.Slug = utils.Slugify(category.Name)
 	}
 
 	if err := database.DB.Create(&category).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not create category"))
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not create category: %w", err)))
 	}
 
 	// Audit Log
 	services.CreateAuditLog(c, "CATEGORY_CREATE", category.ID, "category", map[string]string{"name": category.Name})
 
-	return utils.SendCreated(c, category, "Category created successfully")
+	return utils.SendCreated(c, category, utils.MsgCategoryCreated)
 }
 
 // UpdateCategory modifies an existing category
@@ -123,58 +123,99 @@ func UpdateCategory(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var category models.Category
 	if err := database.DB.First(&category, id).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("category not found"))
+		return utils.SendAppError(c, utils.ErrCategoryNotFound)
 	}
 
 	updateData := new(models.Category)
 	if err := c.BodyParser(updateData); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 
 	// Update slug if name changed and slug not provided
 	if updateData.Name != "" && updateData.Name != category.Name && updateData.Slug == "" {
-		updateData.Slug = generateSlug(updateData.Name)
+		updateData.Slug = utils.Slugify(updateData.Name)
 	}
 
-	database.DB.Model(&category).Updates(updateData)
+	oldName := category.Name
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&category).Updates(updateData).Error; err != nil {
+			return err
+		}
+		// เปลี่ยนชื่อหมวดหมู่ - sync ชื่อที่เก็บไว้ใน projects ด้วย (ไม่ให้ projects หลุดหมวดหมู่)
+		if updateData.Name != "" && updateData.Name != oldName {
+			return tx.Model(&models.Project{}).Where("category_id = ?", category.ID).Update("category", updateData.Name).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not update category: %w", err)))
+	}
 
 	// Audit Log
 	services.CreateAuditLog(c, "CATEGORY_UPDATE", category.ID, "category", map[string]string{"name": category.Name})
 
-	return utils.SendSuccess(c, category, "Category updated successfully")
+	return utils.SendSuccess(c, category, utils.MsgCategoryUpdated)
 }
 
 // DeleteCategory removes a category
 // DeleteCategory godoc
 // @Summary Delete a category
-// @Description Delete a category
+// @Description Delete a category. Categories that still have projects are rejected unless reassign_to is given
 // @Tags Categories
 // @Produce json
 // @Param id path string true "Category ID"
+// @Param reassign_to query int false "Move the category's projects to this category ID before deleting"
 // @Success 200 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
 // @Security BearerAuth
 // @Router /api/admin/categories/{id} [delete]
 func DeleteCategory(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var category models.Category
 	if err := database.DB.First(&category, id).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("category not found"))
+		return utils.SendAppError(c, utils.ErrCategoryNotFound)
 	}
 
-	// Check if any projects use this category
+	// projects ที่ยังอยู่ในหมวดหมู่ต้องย้ายไปหมวดหมู่อื่นก่อน (reassign_to) ไม่งั้นห้ามลบ
+	// นับใน transaction หลัง lock หมวดหมู่ - project ที่สร้าง / ย้ายเข้ามาพร้อมกันต้องรอ (foreign key) จนลบเสร็จ
+	reassignTo := c.QueryInt("reassign_to", 0)
+	var target models.Category
 	var count int64
-	database.DB.Model(&models.Project{}).Where("category = ?", category.Name).Count(&count)
-	if count > 0 {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("cannot delete category with associated projects"))
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&category, category.ID).Error; err != nil {
+			return utils.ErrCategoryNotFound.Wrap(err)
+		}
+		if err := tx.Model(&models.Project{}).Where("category_id = ?", category.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			if reassignTo == 0 {
+				return utils.ErrCategoryHasProjects.WithDetails(fiber.Map{"projects": count})
+			}
+			if uint(reassignTo) == category.ID || tx.First(&target, reassignTo).Error != nil {
+				return utils.ErrCategoryNotFound.WithStatus(fiber.StatusBadRequest)
+			}
+			if _, err := services.ReassignCategoryProjects(tx, category.ID, &target); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&category).Error
+	})
+	if err != nil {
+		// AppError (หมวดหมู่ยังมี projects / หมวดหมู่ปลายทางไม่ถูกต้อง) ตอบตาม code ที่เหลือตอบ 500
+		return utils.SendError(c, fiber.StatusInternalServerError, err)
 	}
 
-	database.DB.Delete(&category)
-
 	// Audit Log
-	services.CreateAuditLog(c, "CATEGORY_DELETE", category.ID, "category", map[string]string{"name": category.Name})
+	details := map[string]interface{}{"name": category.Name}
+	if count > 0 {
+		details["reassigned_to"] = target.ID
+		details["projects"] = count
+	}
+	services.CreateAuditLog(c, "CATEGORY_DELETE", category.ID, "category", details)
 
-	return utils.SendSuccess(c, nil, "Category deleted successfully")
+	return utils.SendSuccess(c, nil, utils.MsgCategoryDeleted)
 }
 
 // UpdateCategoryOrder updates the sort order of categories
@@ -196,34 +237,17 @@ func UpdateCategoryOrder(c *fiber.Ctx) error {
 	}
 
 	if err := c.BodyParser(&orderData); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 
 	tx := database.DB.Begin()
 	for _, item := range orderData {
 		if err := tx.Model(&models.Category{}).Where("id = ?", item.ID).Update("sort_order", item.SortOrder).Error; err != nil {
 			tx.Rollback()
-			return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not update category order"))
+			return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not update category order: %w", err)))
 		}
 	}
 	tx.Commit()
 
 	return utils.SendSuccess(c, nil, "Category order updated successfully")
 }
-
-// generateSlug creates a URL-friendly slug from a string
-func generateSlug(s string) string {
-	// Convert to lowercase
-	slug := strings.ToLower(s)
-	// Replace spaces with hyphens
-	slug = strings.ReplaceAll(slug, " ", "-")
-	// Remove special characters
-	reg := regexp.MustCompile("[^a-z0-9-]")
-	slug = reg.ReplaceAllString(slug, "")
-	// Remove multiple hyphens
-	reg = regexp.MustCompile("-+")
-	slug = reg.ReplaceAllString(slug, "-")
-	// Trim hyphens from start and end
-	slug = strings.Trim(slug, "-")
-	return slug
-}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"backend/internal/services"
+	"backend/pkg/utils"
 	"log"
 
 	"github.com/gofiber/fiber/v2"
@@ -47,18 +48,12 @@ func CleanupOrphanedImages(c *fiber.Ctx) error {
 
 	cleanupService := services.GetCleanupService()
 	if cleanupService == nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Cleanup service ยังไม่ได้เริ่มต้น",
-		})
+		return utils.SendAppError(c, utils.ErrCleanupUnavailable)
 	}
 
 	result, err := cleanupService.CleanupOrphanedImages(dryRun)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": err.Error(),
-		})
+		return utils.SendAppError(c, utils.ErrCleanupFailed.Wrap(err))
 	}
 
 	message := "✅ Cleanup เสร็จสิ้น"
@@ -66,11 +61,7 @@ func CleanupOrphanedImages(c *fiber.Ctx) error {
 		message = "📋 Dry run เสร็จสิ้น (ไม่มีรูปถูกลบ - แค่รายงานผล)"
 	}
 
-	return c.JSON(fiber.Map{
-		"success": true,
-		"message": message,
-		"data":    result,
-	})
+	return utils.SendSuccess(c, result, message)
 }
 
 // GetCleanupStatus - ดูสถานะ cleanup service
@@ -90,12 +81,9 @@ func GetCleanupStatus(c *fiber.Ctx) error {
 		status = "✅ กำลังทำงาน"
 	}
 
-	return c.JSON(fiber.Map{
-		"success": true,
-		"data": fiber.Map{
-			"status":      status,
-			"schedule":    "0 3 * * * (ทุกวันเวลา 03:00 น.)",
-			"description": "ลบรูปใน R2 ที่ไม่ได้ถูกใช้งานโดย project ใดๆ",
-		},
-	})
+	return utils.SendSuccess(c, fiber.Map{
+		"status":      status,
+		"schedule":    "0 3 * * * (ทุกวันเวลา 03:00 น.)",
+		"description": "ลบรูปใน R2 ที่ไม่ได้ถูกใช้งานโดย project ใดๆ",
+	}, "")
 }
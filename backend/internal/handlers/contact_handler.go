@@ -5,7 +5,6 @@ import (
 	"backend/internal/services"
 	"backend/pkg/utils"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -15,8 +14,8 @@ func SubmitContact(c *fiber.Ctx) error {
 		return utils.SendError(c, fiber.StatusBadRequest, utils.ErrBadRequest)
 	}
 
-	validate := validator.New()
-	if err := validate.Struct(contact); err != nil {
+	// error รายช่อง (details) ตาม validate tag ของ models.Contact
+	if err := utils.ValidateStruct(contact); err != nil {
 		return utils.SendError(c, fiber.StatusBadRequest, err)
 	}
 
@@ -24,5 +23,5 @@ func SubmitContact(c *fiber.Ctx) error {
 		return utils.SendError(c, fiber.StatusInternalServerError, err)
 	}
 
-	return utils.SendCreated(c, contact, "Contact submitted successfully")
+	return utils.SendCreated(c, contact, utils.MsgContactSubmitted)
 }
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/internal/services"
+	"backend/pkg/email"
+	"backend/pkg/utils"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// sendVerificationEmail สร้าง token ยืนยันอีเมลและส่ง email (async)
+// คืน false ถ้าไม่ได้ส่ง (เช่น เพิ่งส่งไปไม่นาน)
+func sendVerificationEmail(c *fiber.Ctx, user *models.User) bool {
+	token, err := services.CreateEmailVerification(user)
+	if err != nil {
+		return false
+	}
+
+	verifyLink := fmt.Sprintf("%s/verify-email?token=%s", frontendURL(c), token)
+	go func() {
+		if err := email.SendVerificationEmail(user.Email, user.Username, verifyLink); err != nil {
+			fmt.Printf("Failed to send verification email: %v\n", err)
+		} else {
+			fmt.Println("Verification email sent successfully to:", user.Email)
+		}
+	}()
+	return true
+}
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Confirm the email address of a self-registered account using the token from the verification email
+// @Tags Auth
+// @Produce json
+// @Param token path string true "Verification token"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /auth/verify-email/{token} [post]
+func VerifyEmail(c *fiber.Ctx) error {
+	token := c.Params("token")
+	if token == "" {
+		return utils.SendAppError(c, utils.ErrTokenRequired)
+	}
+
+	user, err := services.VerifyEmailToken(token)
+	if err != nil {
+		if errors.Is(err, services.ErrEmailVerificationInvalid) {
+			return utils.SendError(c, fiber.StatusBadRequest, err)
+		}
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not verify email: %w", err)))
+	}
+
+	// Audit Log
+	services.CreateAuditLog(c, "EMAIL_VERIFIED", user.ID, "user", map[string]string{"email": user.Email}, user.ID)
+
+	return utils.SendSuccess(c, fiber.Map{
+		"email_verified": true,
+	}, "Email verified successfully. You can now login.")
+}
+
+type ResendVerificationInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResendVerification godoc
+// @Summary Resend verification email
+// @Description Send a new email verification link (response is the same whether or not the email exists)
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param input body ResendVerificationInput true "Email"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /auth/resend-verification [post]
+func ResendVerification(c *fiber.Ctx) error {
+	var input ResendVerificationInput
+	if err := c.BodyParser(&input); err != nil {
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	}
+
+	const message = "If your email is registered and not yet verified, you will receive a verification link"
+
+	// Don't reveal if email exists or is already verified (security)
+	var user models.User
+	if err := database.DB.Where("email = ?", strings.TrimSpace(input.Email)).First(&user).Error; err != nil {
+		return utils.SendSuccess(c, nil, message)
+	}
+	if user.EmailVerified || !user.Active {
+		return utils.SendSuccess(c, nil, message)
+	}
+
+	sendVerificationEmail(c, &user)
+
+	return utils.SendSuccess(c, nil, message)
+}
@@ -3,6 +3,8 @@ package handlers
 import (
 	"backend/internal/database"
 	"backend/internal/models"
+	"backend/pkg/utils"
+	"fmt"
 	"strconv"
 
 	"time"
@@ -11,32 +13,75 @@ import (
 	"gorm.io/gorm"
 )
 
+// assignEmployeeOrg ตั้งแผนก (sync ชื่อแผนกด้วย) และหัวหน้า - คืน error (400) ถ้าข้อมูลไม่ถูกต้อง
+func assignEmployeeOrg(employee *models.Employee, departmentID, managerID *uint) *utils.AppError {
+	if departmentID != nil {
+		var dept models.Department
+		if err := database.DB.First(&dept, *departmentID).Error; err != nil {
+			return utils.ErrEmployeeDepartment
+		}
+		employee.DepartmentID = &dept.ID
+		employee.Department = dept.Name
+	}
+
+	if managerID != nil {
+		if *managerID == 0 { // 0 = ไม่มีหัวหน้า
+			employee.ManagerID = nil
+			return nil
+		}
+		var manager models.Employee
+		if err := database.DB.First(&manager, *managerID).Error; err != nil {
+			return utils.ErrManagerNotFound
+		}
+		// กันสายบังคับบัญชาวน (ตัวเองเป็นหัวหน้าของหัวหน้าตัวเอง)
+		if employee.ID != 0 {
+			for cur := &manager; ; {
+				if cur.ID == employee.ID {
+					return utils.ErrManagerCycle
+				}
+				if cur.ManagerID == nil {
+					break
+				}
+				var next models.Employee
+				if err := database.DB.First(&next, *cur.ManagerID).Error; err != nil {
+					break
+				}
+				cur = &next
+			}
+		}
+		employee.ManagerID = &manager.ID
+	}
+	return nil
+}
+
 // CreateEmployee creates a new employee record
 func CreateEmployee(c *fiber.Ctx) error {
 	var input struct {
-		UserID     uint                  `json:"user_id"`
-		Position   string                `json:"position"`
-		Department string                `json:"department"`
-		StartDate  string                `json:"start_date"` // YYYY-MM-DD
-		Status     models.EmployeeStatus `json:"status"`
-		Salary     float64               `json:"salary"`
-		Documents  string                `json:"documents"`
+		UserID       uint                  `json:"user_id"`
+		Position     string                `json:"position"`
+		Department   string                `json:"department"`
+		DepartmentID *uint                 `json:"department_id"`
+		ManagerID    *uint                 `json:"manager_id"`
+		StartDate    string                `json:"start_date"` // YYYY-MM-DD
+		Status       models.EmployeeStatus `json:"status"`
+		Salary       float64               `json:"salary"`
+		Documents    string                `json:"documents"`
 	}
 
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 
 	// Validate User exists
 	var user models.User
 	if err := database.DB.First(&user, input.UserID).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		return utils.SendAppError(c, utils.ErrUserNotFound)
 	}
 
 	// Check if employee record already exists for this user
 	var existingEmployee models.Employee
 	if err := database.DB.Where("user_id = ?", input.UserID).First(&existingEmployee).Error; err == nil {
-		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Employee record already exists for this user"})
+		return utils.SendAppError(c, utils.ErrEmployeeExists)
 	}
 
 	// Parse StartDate
@@ -56,8 +101,21 @@ func CreateEmployee(c *fiber.Ctx) error {
 		Documents:  input.Documents,
 	}
 
+	if appErr := assignEmployeeOrg(&employee, input.DepartmentID, input.ManagerID); appErr != nil {
+		return utils.SendAppError(c, appErr)
+	}
+
+	// ผู้ที่มีสิทธิ์เฉพาะแผนก สร้างพนักงานได้เฉพาะในแผนกของตน
+	scope, err := employeeScope(c, "employees.manage")
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrScopeResolve)
+	}
+	if !scope.All && (employee.DepartmentID == nil || !scope.AllowsDepartment(*employee.DepartmentID)) {
+		return sendOutOfScope(c)
+	}
+
 	if err := database.DB.Create(&employee).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not create employee"})
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not create employee: %w", err)))
 	}
 
 	// Preload User for response
@@ -70,16 +128,22 @@ func CreateEmployee(c *fiber.Ctx) error {
 func GetEmployees(c *fiber.Ctx) error {
 	var employees []models.Employee
 
+	// แสดงเฉพาะพนักงานใน scope ของผู้ใช้ (แผนก/ลูกทีม)
+	scope, err := employeeScope(c, "employees.view", "employees.manage")
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrScopeResolve)
+	}
+
 	// Pagination
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	limit, _ := strconv.Atoi(c.Query("limit", "10"))
 	offset := (page - 1) * limit
 
 	var total int64
-	database.DB.Model(&models.Employee{}).Count(&total)
+	scope.Filter(database.DB.Model(&models.Employee{}), "id").Count(&total)
 
-	if err := database.DB.Preload("User").Offset(offset).Limit(limit).Find(&employees).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not fetch employees"})
+	if err := scope.Filter(database.DB.Preload("User"), "id").Offset(offset).Limit(limit).Find(&employees).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch employees: %w", err)))
 	}
 
 	return c.JSON(fiber.Map{
@@ -99,9 +163,17 @@ func GetEmployee(c *fiber.Ctx) error {
 
 	if err := database.DB.Preload("User").First(&employee, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Employee not found"})
+			return utils.SendAppError(c, utils.ErrEmployeeNotFound)
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Database error"})
+		return utils.SendAppError(c, utils.ErrDatabase)
+	}
+
+	scope, err := employeeScope(c, "employees.view", "employees.manage")
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrScopeResolve)
+	}
+	if !scope.Allows(&employee) {
+		return sendOutOfScope(c)
 	}
 
 	return c.JSON(employee)
@@ -113,20 +185,30 @@ func UpdateEmployee(c *fiber.Ctx) error {
 	var employee models.Employee
 
 	if err := database.DB.First(&employee, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Employee not found"})
+		return utils.SendAppError(c, utils.ErrEmployeeNotFound)
+	}
+
+	scope, err := employeeScope(c, "employees.manage")
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrScopeResolve)
+	}
+	if !scope.Allows(&employee) {
+		return sendOutOfScope(c)
 	}
 
 	var input struct {
-		Position   string                `json:"position"`
-		Department string                `json:"department"`
-		StartDate  string                `json:"start_date"`
-		Status     models.EmployeeStatus `json:"status"`
-		Salary     float64               `json:"salary"`
-		Documents  string                `json:"documents"`
+		Position     string                `json:"position"`
+		Department   string                `json:"department"`
+		DepartmentID *uint                 `json:"department_id"`
+		ManagerID    *uint                 `json:"manager_id"` // 0 = ไม่มีหัวหน้า
+		StartDate    string                `json:"start_date"`
+		Status       models.EmployeeStatus `json:"status"`
+		Salary       float64               `json:"salary"`
+		Documents    string                `json:"documents"`
 	}
 
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 
 	// Update fields if provided
@@ -149,6 +231,15 @@ func UpdateEmployee(c *fiber.Ctx) error {
 	if input.Documents != "" {
 		employee.Documents = input.Documents
 	}
+	previousDepartmentID := employee.DepartmentID
+	if appErr := assignEmployeeOrg(&employee, input.DepartmentID, input.ManagerID); appErr != nil {
+		return utils.SendAppError(c, appErr)
+	}
+	// ย้ายพนักงานไปแผนกนอก scope ของตัวเองไม่ได้
+	moved := input.DepartmentID != nil && (previousDepartmentID == nil || *previousDepartmentID != *input.DepartmentID)
+	if moved && !scope.AllowsDepartment(*employee.DepartmentID) {
+		return sendOutOfScope(c)
+	}
 
 	database.DB.Save(&employee)
 
@@ -156,15 +247,30 @@ func UpdateEmployee(c *fiber.Ctx) error {
 }
 
 // GetEmployeeByUserID retrieves employee by user ID
+// ดูของตัวเองได้เสมอ - ของคนอื่นต้องมี employees.view และพนักงานต้องอยู่ใน scope
 func GetEmployeeByUserID(c *fiber.Ctx) error {
 	userID, _ := strconv.Atoi(c.Params("userId"))
-	var employee models.Employee
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrUnauthorized)
+	}
 
+	var employee models.Employee
 	if err := database.DB.Preload("User").Where("user_id = ?", userID).First(&employee).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Employee profile not found"})
+			return utils.SendAppError(c, utils.ErrEmployeeProfileNotFound)
+		}
+		return utils.SendAppError(c, utils.ErrDatabase)
+	}
+
+	if claims.UserID != uint(userID) {
+		scope, err := employeeScope(c, "employees.view")
+		if err != nil {
+			return utils.SendAppError(c, utils.ErrScopeResolve)
+		}
+		if !scope.Allows(&employee) {
+			return sendOutOfScope(c)
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Database error"})
 	}
 
 	return c.JSON(employee)
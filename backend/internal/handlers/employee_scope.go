@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"backend/internal/services"
+	"backend/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// employeeScope หาขอบเขตพนักงานที่ผู้ใช้ปัจจุบันจัดการได้จาก permission ที่ระบุ
+func employeeScope(c *fiber.Ctx, slugs ...string) (*services.EmployeeScope, error) {
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		return nil, err
+	}
+	return services.ResolveEmployeeScope(claims, slugs...)
+}
+
+func sendOutOfScope(c *fiber.Ctx) error {
+	return utils.SendAppError(c, utils.ErrEmployeeOutOfScope)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/internal/services"
+	"backend/pkg/utils"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ImpersonateUser godoc
+// @Summary Impersonate a user
+// @Description Issue a short-lived access token to view the app as another user (no refresh token). Every audit log made with this token records both users.
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/users/{id}/impersonate [post]
+func ImpersonateUser(c *fiber.Ctx) error {
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrUnauthorized)
+	}
+
+	id := c.Params("id")
+	var user models.User
+	if err := database.DB.Preload("Role.Permissions").First(&user, id).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrUserNotFound)
+	}
+
+	impersonation, err := services.StartImpersonation(claims, &user)
+	switch {
+	case errors.Is(err, services.ErrImpersonateSelf), errors.Is(err, services.ErrImpersonateInactive):
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	case errors.Is(err, services.ErrImpersonateNested), errors.Is(err, services.ErrImpersonateEscalation):
+		return utils.SendError(c, fiber.StatusForbidden, err)
+	case err != nil:
+		return utils.SendAppError(c, utils.ErrTokenGeneration)
+	}
+
+	// Audit Log
+	services.CreateAuditLog(c, "IMPERSONATION_STARTED", user.ID, "user", map[string]interface{}{
+		"username":   user.Username,
+		"session_id": claims.SessionID,
+		"expires_at": impersonation.ExpiresAt,
+	})
+
+	return utils.SendSuccess(c, fiber.Map{
+		"access_token":    impersonation.Token,
+		"expires_at":      impersonation.ExpiresAt,
+		"expires_in":      int(services.ImpersonationTTL.Seconds()),
+		"impersonating":   true,
+		"impersonator_id": claims.UserID,
+		"user": fiber.Map{
+			"id":         user.ID,
+			"username":   user.Username,
+			"first_name": user.FirstName,
+			"last_name":  user.LastName,
+			"role":       user.Role.Name,
+		},
+	}, "Impersonation started")
+}
+
+// StopImpersonation godoc
+// @Summary Stop impersonating
+// @Description Record the end of an impersonation; the client discards the impersonation token and continues with its own token
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /auth/impersonation/stop [post]
+func StopImpersonation(c *fiber.Ctx) error {
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrUnauthorized)
+	}
+	if !claims.IsImpersonation() {
+		return utils.SendAppError(c, utils.ErrNotImpersonating)
+	}
+
+	// Audit Log
+	services.CreateAuditLog(c, "IMPERSONATION_ENDED", claims.UserID, "user", map[string]interface{}{
+		"username": claims.Username,
+	})
+
+	return utils.SendSuccess(c, nil, "Impersonation ended")
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"backend/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetJWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public keys for verifying access tokens issued by this API (RFC 7517)
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /.well-known/jwks.json [get]
+func GetJWKS(c *fiber.Ctx) error {
+	// ตอบตาม format มาตรฐาน {"keys": [...]} (ไม่ห่อด้วย SendSuccess) เพื่อให้ JWT library ของ service อื่นอ่านได้ตรง ๆ
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(fiber.Map{"keys": utils.JWKS()})
+}
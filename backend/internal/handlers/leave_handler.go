@@ -3,6 +3,10 @@ package handlers
 import (
 	"backend/internal/database"
 	"backend/internal/models"
+	"backend/internal/services"
+	"backend/pkg/utils"
+	"errors"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -13,15 +17,14 @@ import (
 // RequestLeave creates a new leave request
 func RequestLeave(c *fiber.Ctx) error {
 	// 1. Get Current User/Employee
-	userID := c.Locals("userID")
-	if userID == nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrUnauthorized)
 	}
-	uid := uint(userID.(float64))
 
 	var employee models.Employee
 	if err := database.DB.Where("user_id = ?", uid).First(&employee).Error; err != nil {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Employee profile not found"})
+		return utils.SendAppError(c, utils.ErrEmployeeProfileNotFound)
 	}
 
 	// 2. Parse Input
@@ -34,7 +37,7 @@ func RequestLeave(c *fiber.Ctx) error {
 	}
 
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 
 	startDate, _ := time.Parse("2006-01-02", input.StartDate)
@@ -44,7 +47,7 @@ func RequestLeave(c *fiber.Ctx) error {
 	// For production, should skip weekends/holidays
 	days := endDate.Sub(startDate).Hours()/24 + 1
 	if days <= 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid date range"})
+		return utils.SendAppError(c, utils.ErrLeaveDateRange)
 	}
 
 	// 3. Check Quota (Create if not exists for this year)
@@ -55,7 +58,7 @@ func RequestLeave(c *fiber.Ctx) error {
 		Year:       year,
 		// Limits default in model
 	}).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Quota error"})
+		return utils.SendAppError(c, utils.ErrLeaveQuota)
 	}
 
 	// 4. Validate limit
@@ -76,7 +79,7 @@ func RequestLeave(c *fiber.Ctx) error {
 	}
 
 	if err := database.DB.Create(&leaveRequest).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create request"})
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("failed to create request: %w", err)))
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(leaveRequest)
@@ -84,12 +87,14 @@ func RequestLeave(c *fiber.Ctx) error {
 
 // GetMyLeaves lists leaves for current user
 func GetMyLeaves(c *fiber.Ctx) error {
-	userID := c.Locals("userID")
-	uid := uint(userID.(float64))
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrUnauthorized)
+	}
 
 	var employee models.Employee
 	if err := database.DB.Where("user_id = ?", uid).First(&employee).Error; err != nil {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Employee profile not found"})
+		return utils.SendAppError(c, utils.ErrEmployeeProfileNotFound)
 	}
 
 	var leaves []models.LeaveRequest
@@ -98,10 +103,16 @@ func GetMyLeaves(c *fiber.Ctx) error {
 	return c.JSON(leaves)
 }
 
-// GetPendingLeaves (Admin/Manager)
+// GetPendingLeaves (Admin/Manager) - manager เห็นเฉพาะคำขอของพนักงานใน scope
 func GetPendingLeaves(c *fiber.Ctx) error {
+	scope, err := employeeScope(c, "leaves.view", "leaves.manage")
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrScopeResolve)
+	}
+
 	var leaves []models.LeaveRequest
-	database.DB.Preload("Employee.User").Where("status = ?", models.LeaveStatusPending).Order("created_at asc").Find(&leaves)
+	query := database.DB.Preload("Employee.User").Where("status = ?", models.LeaveStatusPending)
+	scope.Filter(query, "employee_id").Order("created_at asc").Find(&leaves)
 	return c.JSON(leaves)
 }
 
@@ -110,32 +121,51 @@ func ApproveRejectLeave(c *fiber.Ctx) error {
 	id, _ := strconv.Atoi(c.Params("id"))
 
 	// Approver
-	userID := c.Locals("userID")
-	approverID := uint(userID.(float64))
+	approverID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrUnauthorized)
+	}
 
 	var input struct {
 		Status  models.LeaveStatus `json:"status"` // Approved / Rejected
 		Comment string             `json:"comment"`
 	}
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 
 	if input.Status != models.LeaveStatusApproved && input.Status != models.LeaveStatusRejected {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid status"})
+		return utils.SendAppError(c, utils.ErrLeaveStatusInvalid)
 	}
 
 	var req models.LeaveRequest
-	if err := database.DB.First(&req, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Request not found"})
+	if err := database.DB.Preload("Employee").First(&req, id).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrLeaveNotFound)
 	}
 
 	if req.Status != models.LeaveStatusPending {
-		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Request already processed"})
+		return utils.SendAppError(c, utils.ErrLeaveProcessed)
+	}
+
+	// ห้ามพิจารณาคำขอของตัวเอง (manager ที่ scope ครอบแผนกตัวเองก็อนุมัติให้ตัวเองไม่ได้)
+	if req.Employee.UserID == approverID {
+		return utils.SendAppError(c, utils.ErrLeaveSelfApproval)
+	}
+
+	// manager อนุมัติได้เฉพาะคำขอของพนักงานใน scope
+	scope, err := employeeScope(c, "leaves.manage")
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrScopeResolve)
+	}
+	if err := services.EmployeeScopeAllows(scope, req.EmployeeID); err != nil {
+		if errors.Is(err, services.ErrOutOfScope) {
+			return sendOutOfScope(c)
+		}
+		return utils.SendAppError(c, utils.ErrEmployeeNotFound)
 	}
 
 	// Transaction for Quota update
-	err := database.DB.Transaction(func(tx *gorm.DB) error {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
 		req.Status = input.Status
 		req.ApproverID = &approverID
 		req.Comment = input.Comment
@@ -162,14 +192,14 @@ func ApproveRejectLeave(c *fiber.Ctx) error {
 			}
 		}
 
-		if err := tx.Save(&req).Error; err != nil {
+		if err := tx.Omit("Employee").Save(&req).Error; err != nil {
 			return err
 		}
 		return nil
 	})
 
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Transaction failed"})
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("transaction failed: %w", err)))
 	}
 
 	return c.JSON(req)
@@ -177,12 +207,14 @@ func ApproveRejectLeave(c *fiber.Ctx) error {
 
 // GetLeaveQuota get quota for Employee (My Quota)
 func GetMyLeaveQuota(c *fiber.Ctx) error {
-	userID := c.Locals("userID")
-	uid := uint(userID.(float64))
+	uid, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrUnauthorized)
+	}
 
 	var employee models.Employee
 	if err := database.DB.Where("user_id = ?", uid).First(&employee).Error; err != nil {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Employee profile not found"})
+		return utils.SendAppError(c, utils.ErrEmployeeProfileNotFound)
 	}
 
 	year := time.Now().Year()
@@ -191,7 +223,7 @@ func GetMyLeaveQuota(c *fiber.Ctx) error {
 		EmployeeID: employee.ID,
 		Year:       year,
 	}).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Quota error"})
+		return utils.SendAppError(c, utils.ErrLeaveQuota)
 	}
 
 	return c.JSON(quota)
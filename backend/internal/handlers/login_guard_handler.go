@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/internal/services"
+	"backend/pkg/utils"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// sendLoginBlocked ตอบ 429 พร้อม Retry-After เมื่อ user/IP ถูกล็อกหรืออยู่ในช่วง backoff
+func sendLoginBlocked(c *fiber.Ctx, block *services.LoginBlock) error {
+	seconds := int(math.Ceil(block.RetryAfter.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+
+	if block.Locked {
+		return utils.SendAppError(c, utils.ErrAccountLocked.WithDetails(fmt.Sprintf("retry after %d seconds", seconds)))
+	}
+	return utils.SendAppError(c, utils.ErrLoginBackoff.WithDetails(fmt.Sprintf("retry after %d seconds", seconds)))
+}
+
+// recordLoginFailure บันทึกการ login ผิดและเขียน audit log เมื่อมีการล็อก / ปิด PIN
+// user = nil เมื่อไม่พบ username (นับเฉพาะ IP)
+func recordLoginFailure(c *fiber.Ctx, user *models.User, method string) {
+	var userID uint
+	if user != nil {
+		userID = user.ID
+	}
+
+	result := services.RecordLoginFailure(userID, c.IP(), method)
+
+	if result.IPLocked {
+		services.CreateAuditLog(c, "IP_LOCKED", 0, "ip", map[string]interface{}{
+			"ip_address": c.IP(),
+			"duration":   services.LockoutDuration.String(),
+		})
+	}
+	if user == nil {
+		return
+	}
+
+	if result.UserLocked {
+		services.CreateAuditLog(c, "ACCOUNT_LOCKED", user.ID, "user", map[string]interface{}{
+			"username": user.Username,
+			"method":   method,
+			"failures": result.Failures,
+			"duration": services.LockoutDuration.String(),
+		}, user.ID)
+	}
+	if result.PINDisabled {
+		services.CreateAuditLog(c, "PIN_AUTO_DISABLED", user.ID, "user", map[string]interface{}{
+			"username": user.Username,
+			"reason":   "too many failed PIN attempts",
+		}, user.ID)
+	}
+}
+
+// UnlockUser godoc
+// @Summary Unlock a user account
+// @Description Clear failed login attempts and lockout of a user (Admin only)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/users/{id}/unlock [post]
+func UnlockUser(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var user models.User
+	if err := database.DB.First(&user, id).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrUserNotFound)
+	}
+
+	throttle := services.GetLoginThrottle(user.ID)
+	if !services.UnlockUser(user.ID) {
+		return utils.SendSuccess(c, nil, "User is not locked")
+	}
+
+	// Audit Log
+	details := map[string]interface{}{"username": user.Username}
+	if throttle != nil {
+		details["failures"] = throttle.Failures
+		details["locked_until"] = throttle.LockedUntil
+	}
+	services.CreateAuditLog(c, "ACCOUNT_UNLOCKED", user.ID, "user", details)
+
+	return utils.SendSuccess(c, nil, "User unlocked successfully")
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/internal/services"
+	"backend/pkg/email"
+	"backend/pkg/utils"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MagicLinkRequestInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type MagicLinkLoginInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// RequestMagicLink godoc
+// @Summary Request a login link
+// @Description Email a single-use, short-lived passwordless login link (response is the same whether or not the email exists)
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param input body MagicLinkRequestInput true "Email"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /auth/magic-link [post]
+func RequestMagicLink(c *fiber.Ctx) error {
+	var input MagicLinkRequestInput
+	if err := c.BodyParser(&input); err != nil {
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	}
+
+	const message = "If your email is registered, you will receive a login link"
+
+	// Don't reveal if email exists or not (security)
+	var user models.User
+	if err := database.DB.Where("LOWER(email) = ?", strings.ToLower(strings.TrimSpace(input.Email))).First(&user).Error; err != nil {
+		return utils.SendSuccess(c, nil, message)
+	}
+	if !user.Active || user.ApprovalStatus != models.ApprovalApproved {
+		return utils.SendSuccess(c, nil, message)
+	}
+
+	token, link, err := services.CreateMagicLink(&user, c.IP())
+	if err != nil {
+		if errors.Is(err, services.ErrMagicLinkRateLimited) {
+			services.CreateAuditLog(c, "MAGIC_LINK_RATE_LIMITED", user.ID, "user", map[string]string{"email": user.Email}, user.ID)
+			return utils.SendSuccess(c, nil, message)
+		}
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not create login link: %w", err)))
+	}
+
+	loginLink := fmt.Sprintf("%s/magic-login?token=%s", frontendURL(c), token)
+	go func() {
+		if err := email.SendMagicLinkEmail(user.Email, user.Username, loginLink); err != nil {
+			fmt.Printf("Failed to send magic link email: %v\n", err)
+		}
+	}()
+
+	// Audit Log
+	services.CreateAuditLog(c, "MAGIC_LINK_ISSUED", user.ID, "user", map[string]interface{}{
+		"magic_link_id": link.ID,
+		"email":         user.Email,
+		"expires_at":    link.ExpiresAt,
+	}, user.ID)
+
+	return utils.SendSuccess(c, nil, message)
+}
+
+// LoginWithMagicLink godoc
+// @Summary Login with a magic link
+// @Description Exchange a login link token for the same token pair as /auth/login (2FA still applies)
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param input body MagicLinkLoginInput true "Login link token"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /auth/magic-link/verify [post]
+func LoginWithMagicLink(c *fiber.Ctx) error {
+	var input MagicLinkLoginInput
+	if err := c.BodyParser(&input); err != nil {
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	}
+
+	link, err := services.ConsumeMagicLink(input.Token)
+	if err != nil {
+		if link != nil {
+			services.CreateAuditLog(c, "MAGIC_LINK_REJECTED", link.UserID, "user", map[string]interface{}{"magic_link_id": link.ID}, link.UserID)
+		}
+		return utils.SendError(c, fiber.StatusUnauthorized, services.ErrMagicLinkInvalid)
+	}
+
+	var user models.User
+	if err := database.DB.Preload("Role.Permissions").First(&user, link.UserID).Error; err != nil {
+		return utils.SendError(c, fiber.StatusUnauthorized, services.ErrMagicLinkInvalid)
+	}
+	if !user.Active {
+		return utils.SendAppError(c, utils.ErrUserInactive)
+	}
+
+	// link ถูกส่งไปที่ email ของ user - เปิด link ได้ = ยืนยันอีเมลแล้ว
+	if !user.EmailVerified {
+		now := time.Now()
+		user.EmailVerified = true
+		user.EmailVerifiedAt = &now
+		database.DB.Model(&user).Updates(map[string]interface{}{"email_verified": true, "email_verified_at": now})
+	}
+
+	// Audit Log
+	services.CreateAuditLog(c, "MAGIC_LINK_CONSUMED", user.ID, "user", map[string]interface{}{
+		"magic_link_id": link.ID,
+		"requested_ip":  link.IPAddress,
+	}, user.ID)
+
+	return beginLogin(c, &user, "magic_link")
+}
@@ -3,6 +3,8 @@ package handlers
 import (
 	"backend/internal/database"
 	"backend/internal/models"
+	"backend/pkg/utils"
+	"fmt"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -22,7 +24,7 @@ func GetDepartments(c *fiber.Ctx) error {
 	}
 
 	if err := query.Order("name ASC").Find(&departments).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not fetch departments"})
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch departments: %w", err)))
 	}
 
 	return c.JSON(fiber.Map{
@@ -38,9 +40,9 @@ func GetDepartment(c *fiber.Ctx) error {
 
 	if err := database.DB.First(&department, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Department not found"})
+			return utils.SendAppError(c, utils.ErrDepartmentNotFound)
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Database error"})
+		return utils.SendAppError(c, utils.ErrDatabase)
 	}
 
 	return c.JSON(fiber.Map{
@@ -58,11 +60,11 @@ func CreateDepartment(c *fiber.Ctx) error {
 	}
 
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 
 	if input.Name == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Name is required"})
+		return utils.SendAppError(c, utils.ErrNameRequired)
 	}
 
 	isActive := true
@@ -77,7 +79,7 @@ func CreateDepartment(c *fiber.Ctx) error {
 	}
 
 	if err := database.DB.Create(&department).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not create department"})
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not create department: %w", err)))
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -92,7 +94,7 @@ func UpdateDepartment(c *fiber.Ctx) error {
 	var department models.Department
 
 	if err := database.DB.First(&department, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Department not found"})
+		return utils.SendAppError(c, utils.ErrDepartmentNotFound)
 	}
 
 	var input struct {
@@ -102,7 +104,7 @@ func UpdateDepartment(c *fiber.Ctx) error {
 	}
 
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 
 	if input.Name != "" {
@@ -129,14 +131,14 @@ func DeleteDepartment(c *fiber.Ctx) error {
 	var department models.Department
 
 	if err := database.DB.First(&department, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Department not found"})
+		return utils.SendAppError(c, utils.ErrDepartmentNotFound)
 	}
 
 	// Check if any positions are using this department
 	var count int64
 	database.DB.Model(&models.Position{}).Where("department_id = ?", id).Count(&count)
 	if count > 0 {
-		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Cannot delete department with existing positions"})
+		return utils.SendAppError(c, utils.ErrDepartmentHasPositions)
 	}
 
 	database.DB.Delete(&department)
@@ -163,7 +165,7 @@ func GetPositions(c *fiber.Ctx) error {
 	}
 
 	if err := query.Order("name ASC").Find(&positions).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not fetch positions"})
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch positions: %w", err)))
 	}
 
 	return c.JSON(fiber.Map{
@@ -179,9 +181,9 @@ func GetPosition(c *fiber.Ctx) error {
 
 	if err := database.DB.Preload("Department").First(&position, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Position not found"})
+			return utils.SendAppError(c, utils.ErrPositionNotFound)
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Database error"})
+		return utils.SendAppError(c, utils.ErrDatabase)
 	}
 
 	return c.JSON(fiber.Map{
@@ -200,11 +202,11 @@ func CreatePosition(c *fiber.Ctx) error {
 	}
 
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 
 	if input.Name == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Name is required"})
+		return utils.SendAppError(c, utils.ErrNameRequired)
 	}
 
 	isActive := true
@@ -220,7 +222,7 @@ func CreatePosition(c *fiber.Ctx) error {
 	}
 
 	if err := database.DB.Create(&position).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not create position"})
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not create position: %w", err)))
 	}
 
 	// Preload department for response
@@ -238,7 +240,7 @@ func UpdatePosition(c *fiber.Ctx) error {
 	var position models.Position
 
 	if err := database.DB.First(&position, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Position not found"})
+		return utils.SendAppError(c, utils.ErrPositionNotFound)
 	}
 
 	var input struct {
@@ -249,7 +251,7 @@ func UpdatePosition(c *fiber.Ctx) error {
 	}
 
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 
 	if input.Name != "" {
@@ -282,7 +284,7 @@ func DeletePosition(c *fiber.Ctx) error {
 	var position models.Position
 
 	if err := database.DB.First(&position, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Position not found"})
+		return utils.SendAppError(c, utils.ErrPositionNotFound)
 	}
 
 	database.DB.Delete(&position)
@@ -303,7 +305,7 @@ func GetUsersWithoutEmployee(c *fiber.Ctx) error {
 	subQuery := database.DB.Model(&models.Employee{}).Select("user_id")
 
 	if err := database.DB.Where("id NOT IN (?)", subQuery).Find(&users).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not fetch users"})
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch users: %w", err)))
 	}
 
 	return c.JSON(fiber.Map{
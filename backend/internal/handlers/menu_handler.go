@@ -3,11 +3,13 @@ package handlers
 import (
 	"backend/internal/database"
 	"backend/internal/models"
+	"backend/internal/services"
 	"backend/pkg/utils"
 	"errors"
-	"log"
+	"fmt"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
 type CreateMenuInput struct {
@@ -17,6 +19,8 @@ type CreateMenuInput struct {
 	PermissionSlug string `json:"permission_slug"`
 	ParentID       *uint  `json:"parent_id"`
 	Order          int    `json:"order"`
+
+	Translations map[string]string `json:"translations"` // locale -> title เช่น {"th": "ผู้ใช้งาน"}
 }
 
 type UpdateMenuInput struct {
@@ -27,11 +31,13 @@ type UpdateMenuInput struct {
 	ParentID       *uint  `json:"parent_id"`
 	Order          int    `json:"order"`
 	IsActive       *bool  `json:"is_active"`
+
+	Translations map[string]string `json:"translations"` // locale -> title (title ว่าง = ลบคำแปล)
 }
 
 // GetMenus godoc
 // @Summary Get user menus
-// @Description Get menus permitted for current user
+// @Description Get the nested menu tree permitted for current user (filtered by effective permissions at every level)
 // @Tags Auth
 // @Security ApiKeyAuth
 // @Produce json
@@ -51,82 +57,100 @@ func GetMenus(c *fiber.Ctx) error {
 
 	// Map permissions to map for O(1) lookup
 	userPerms := make(map[string]bool)
-	if user.RoleID != nil {
-		for _, p := range user.Role.Permissions {
-			userPerms[p.Slug] = true
-		}
+	for _, slug := range services.PermissionSlugs(&user) {
+		userPerms[slug] = true
 	}
 
-	// DEBUG: Log permissions
-	log.Printf("[DEBUG] User: %s, Role: %s", user.Username, user.Role.Name)
-	log.Printf("[DEBUG] Permissions: %v", userPerms)
-
 	// Fetch all menus ordered
 	var allMenus []models.Menu
-	if err := database.DB.Order("\"order\" asc").Find(&allMenus).Error; err != nil {
+	if err := database.DB.Preload("Translations").Order("\"order\" asc").Find(&allMenus).Error; err != nil {
 		return utils.SendError(c, fiber.StatusInternalServerError, err)
 	}
 
-	// สร้าง map ของ child menus ตาม parent_id
-	childrenByParent := make(map[uint][]models.Menu)
-	for _, m := range allMenus {
-		if m.ParentID != nil {
-			childrenByParent[*m.ParentID] = append(childrenByParent[*m.ParentID], m)
-		}
-	}
-
-	// Filter เมนูตาม permission
-	var visibleMenus []models.Menu
-	for _, m := range allMenus {
-		// ถ้าเป็น group menu (path="#") ต้องเช็คว่ามี child ที่ user มี permission หรือไม่
-		if m.Path == "#" {
-			children := childrenByParent[m.ID]
-			hasVisibleChild := false
-			for _, child := range children {
-				if child.PermissionSlug == "" || userPerms[child.PermissionSlug] {
-					hasVisibleChild = true
-					break
-				}
-			}
-			if hasVisibleChild {
-				visibleMenus = append(visibleMenus, m)
-			} else {
-				log.Printf("[DEBUG] Hidden Group (no visible children): %s", m.Title)
-			}
-			continue
-		}
-
-		// เมนูปกติ: ถ้าไม่มี permission หรือ user มี permission ถึงแสดง
-		if m.PermissionSlug == "" || userPerms[m.PermissionSlug] {
-			visibleMenus = append(visibleMenus, m)
-		} else {
-			log.Printf("[DEBUG] Hidden Menu: %s (Req: %s)", m.Title, m.PermissionSlug)
-		}
-	}
-	log.Printf("[DEBUG] Visible Menus: %d", len(visibleMenus))
+	// ตัดเมนูที่ไม่มีสิทธิ์ทุกชั้น - group menu (path="#") ที่ไม่เหลือ child จะถูกซ่อน
+	visibleMenus := services.FilterMenuTree(services.BuildMenuTree(allMenus), userPerms)
+	visibleMenus = services.LocalizeMenuTree(visibleMenus, utils.GetLocale(c))
 
 	return utils.SendSuccess(c, fiber.Map{
 		"menus": visibleMenus,
-	}, "Menus retrieved successfully")
+	}, utils.MsgMenusRetrieved)
 }
 
 // GetAllMenus returns all menus (Admin)
 func GetAllMenus(c *fiber.Ctx) error {
 	var menus []models.Menu
-	if err := database.DB.Order("\"order\" asc").Find(&menus).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not fetch menus"))
+	if err := database.DB.Preload("Translations").Order("\"order\" asc").Find(&menus).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch menus: %w", err)))
 	}
-	return utils.SendSuccess(c, fiber.Map{"menus": menus}, "Menus retrieved successfully")
+	return utils.SendSuccess(c, fiber.Map{"menus": menus}, utils.MsgMenusRetrieved)
+}
+
+// GetMenuTree godoc
+// @Summary Get menu tree
+// @Description Get every menu as a nested tree (Admin)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/menus/tree [get]
+func GetMenuTree(c *fiber.Ctx) error {
+	var menus []models.Menu
+	if err := database.DB.Preload("Translations").Order("\"order\" asc").Find(&menus).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch menus: %w", err)))
+	}
+	return utils.SendSuccess(c, fiber.Map{"menus": services.BuildMenuTree(menus)}, "Menu tree retrieved successfully")
+}
+
+// UpdateMenuTree godoc
+// @Summary Save menu tree
+// @Description Rewrite parents and orders of every menu from the nested structure (drag-and-drop). The tree must contain every menu exactly once.
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param input body []services.MenuTreeNode true "Nested menu tree"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/menus/tree [put]
+func UpdateMenuTree(c *fiber.Ctx) error {
+	var input []services.MenuTreeNode
+	if err := c.BodyParser(&input); err != nil {
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+
+	if err := services.SaveMenuTree(input); err != nil {
+		if isMenuTreeError(err) {
+			return sendMenuTreeError(c, err)
+		}
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not update menu tree: %w", err)))
+	}
+
+	var menus []models.Menu
+	database.DB.Preload("Translations").Order("\"order\" asc").Find(&menus)
+	return utils.SendSuccess(c, fiber.Map{"menus": services.BuildMenuTree(menus)}, "Menu tree updated successfully")
+}
+
+// isMenuTreeError error จากการตรวจโครงสร้างเมนู (ส่ง 400 กลับไป)
+func isMenuTreeError(err error) bool {
+	return errors.Is(err, services.ErrMenuCycle) ||
+		errors.Is(err, services.ErrMenuTooDeep) ||
+		errors.Is(err, services.ErrMenuNotFound) ||
+		errors.Is(err, services.ErrMenuTreeIncomplete)
+}
+
+// sendMenuTreeError ตอบ 400 ทุกกรณี - MENU_NOT_FOUND ที่นี่หมายถึง parent / node ที่ส่งมาไม่มีอยู่
+func sendMenuTreeError(c *fiber.Ctx, err error) error {
+	return utils.SendAppError(c, utils.AsAppError(err, fiber.StatusBadRequest).WithStatus(fiber.StatusBadRequest))
 }
 
 func GetMenu(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid menu ID"))
+		return utils.SendAppError(c, utils.ErrMenuIDInvalid)
 	}
 	var menu models.Menu
-	if err := database.DB.First(&menu, id).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("menu not found"))
+	if err := database.DB.Preload("Translations").First(&menu, id).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrMenuNotFound)
 	}
 	return utils.SendSuccess(c, fiber.Map{"menu": menu}, "Menu retrieved successfully")
 }
@@ -134,7 +158,16 @@ func GetMenu(c *fiber.Ctx) error {
 func CreateMenu(c *fiber.Ctx) error {
 	var input CreateMenuInput
 	if err := c.BodyParser(&input); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	}
+	if err := services.ValidateMenuParent(0, input.ParentID); err != nil {
+		if isMenuTreeError(err) {
+			return sendMenuTreeError(c, err)
+		}
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not create menu: %w", err)))
 	}
 	menu := models.Menu{
 		Title:          input.Title,
@@ -145,24 +178,34 @@ func CreateMenu(c *fiber.Ctx) error {
 		Order:          input.Order,
 		IsActive:       true,
 	}
-	if err := database.DB.Create(&menu).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not create menu"))
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&menu).Error; err != nil {
+			return err
+		}
+		return services.SaveMenuTranslations(tx, menu.ID, input.Translations)
+	})
+	if errors.Is(err, services.ErrMenuLocale) {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	}
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not create menu: %w", err)))
 	}
+	database.DB.Model(&menu).Association("Translations").Find(&menu.Translations)
 	return utils.SendCreated(c, fiber.Map{"menu": menu}, "Menu created successfully")
 }
 
 func UpdateMenu(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid menu ID"))
+		return utils.SendAppError(c, utils.ErrMenuIDInvalid)
 	}
 	var input UpdateMenuInput
 	if err := c.BodyParser(&input); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 	var menu models.Menu
 	if err := database.DB.First(&menu, id).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("menu not found"))
+		return utils.SendAppError(c, utils.ErrMenuNotFound)
 	}
 
 	if input.Title != "" {
@@ -174,6 +217,13 @@ func UpdateMenu(c *fiber.Ctx) error {
 	if input.Icon != "" {
 		menu.Icon = input.Icon
 	}
+	if err := services.ValidateMenuParent(menu.ID, input.ParentID); err != nil {
+		if isMenuTreeError(err) {
+			return sendMenuTreeError(c, err)
+		}
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not update menu: %w", err)))
+	}
+
 	menu.PermissionSlug = input.PermissionSlug
 	menu.ParentID = input.ParentID
 	menu.Order = input.Order
@@ -181,23 +231,51 @@ func UpdateMenu(c *fiber.Ctx) error {
 		menu.IsActive = *input.IsActive
 	}
 
-	if err := database.DB.Save(&menu).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not update menu"))
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&menu).Error; err != nil {
+			return err
+		}
+		return services.SaveMenuTranslations(tx, menu.ID, input.Translations)
+	})
+	if errors.Is(err, services.ErrMenuLocale) {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	}
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not update menu: %w", err)))
 	}
+	database.DB.Model(&menu).Association("Translations").Find(&menu.Translations)
 	return utils.SendSuccess(c, fiber.Map{"menu": menu}, "Menu updated successfully")
 }
 
+// DeleteMenu godoc
+// @Summary Delete menu
+// @Description Delete a menu. Children are moved up to the deleted menu's parent (children=reparent, default) or deleted with it (children=cascade)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "Menu ID"
+// @Param children query string false "reparent | cascade"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/menus/{id} [delete]
 func DeleteMenu(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid menu ID"))
+		return utils.SendAppError(c, utils.ErrMenuIDInvalid)
 	}
+	mode := c.Query("children", services.MenuDeleteReparent)
+	if mode != services.MenuDeleteReparent && mode != services.MenuDeleteCascade {
+		return utils.SendAppError(c, utils.ErrMenuDeleteMode)
+	}
+
 	var menu models.Menu
 	if err := database.DB.First(&menu, id).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("menu not found"))
+		return utils.SendAppError(c, utils.ErrMenuNotFound)
 	}
-	if err := database.DB.Delete(&menu).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not delete menu"))
+	deleted, err := services.DeleteMenu(&menu, mode)
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not delete menu: %w", err)))
 	}
-	return utils.SendSuccess(c, nil, "Menu deleted successfully")
+	return utils.SendSuccess(c, fiber.Map{"deleted": deleted}, utils.MsgMenuDeleted)
 }
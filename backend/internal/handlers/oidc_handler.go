@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"backend/internal/services"
+	"backend/pkg/utils"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetOIDCProviders godoc
+// @Summary List external login providers
+// @Description List configured OpenID Connect providers for rendering login buttons
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /auth/oidc/providers [get]
+func GetOIDCProviders(c *fiber.Ctx) error {
+	providers := []fiber.Map{}
+	for _, p := range services.OIDCProviders() {
+		providers = append(providers, fiber.Map{
+			"name":         p.Name,
+			"display_name": p.DisplayName,
+			"login_url":    "/api/auth/oidc/" + p.Name + "/login",
+		})
+	}
+	return utils.SendSuccess(c, fiber.Map{"providers": providers}, "Providers retrieved successfully")
+}
+
+// StartOIDCLogin godoc
+// @Summary Start external login
+// @Description Returns the provider authorization URL (authorization code flow with PKCE)
+// @Tags Auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /auth/oidc/{provider}/login [get]
+func StartOIDCLogin(c *fiber.Ctx) error {
+	authURL, err := services.StartOIDCLogin(c.UserContext(), c.Params("provider"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrOIDCProviderNotFound):
+			return utils.SendError(c, fiber.StatusNotFound, err)
+		case errors.Is(err, services.ErrOIDCProviderUnavailable):
+			return utils.SendError(c, fiber.StatusBadGateway, err)
+		}
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not start login: %w", err)))
+	}
+
+	return utils.SendSuccess(c, fiber.Map{
+		"authorization_url": authURL,
+	}, "Redirect to provider")
+}
+
+type OIDCCallbackInput struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
+
+// OIDCCallback godoc
+// @Summary Complete external login
+// @Description Exchange the authorization code returned to the frontend callback page for the same token pair as /auth/login
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param input body OIDCCallbackInput true "Authorization code and state"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /auth/oidc/{provider}/callback [post]
+func OIDCCallback(c *fiber.Ctx) error {
+	var input OIDCCallbackInput
+	if err := c.BodyParser(&input); err != nil {
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	}
+
+	provider := c.Params("provider")
+	user, result, err := services.CompleteOIDCLogin(c.UserContext(), provider, input.Code, input.State)
+	if err != nil {
+		services.CreateAuditLog(c, "OIDC_LOGIN_FAILED", 0, "user", map[string]string{
+			"provider": provider,
+			"email":    result.Email,
+			"error":    err.Error(),
+		})
+
+		switch {
+		case errors.Is(err, services.ErrOIDCProviderNotFound):
+			return utils.SendError(c, fiber.StatusNotFound, err)
+		case errors.Is(err, services.ErrOIDCStateInvalid):
+			return utils.SendError(c, fiber.StatusBadRequest, err)
+		case errors.Is(err, services.ErrOIDCNoAccount):
+			return utils.SendError(c, fiber.StatusForbidden, err)
+		case errors.Is(err, services.ErrOIDCDomainNotAllowed), errors.Is(err, services.ErrOIDCEmailNotVerified),
+			errors.Is(err, services.ErrOIDCAccountUnverified):
+			return utils.SendError(c, fiber.StatusForbidden, err)
+		case errors.Is(err, utils.ErrRegistrationClosed), errors.Is(err, utils.ErrInvitationRequired),
+			errors.Is(err, utils.ErrEmailDomainNotAllowed):
+			// สร้าง user ใหม่ไม่ได้ตามนโยบายการสมัคร
+			return utils.SendError(c, fiber.StatusForbidden, err)
+		case errors.Is(err, services.ErrOIDCProviderUnavailable):
+			return utils.SendError(c, fiber.StatusBadGateway, err)
+		}
+		return utils.SendAppError(c, utils.ErrOIDCLoginFailed)
+	}
+
+	if result.Provisioned {
+		services.CreateAuditLog(c, "USER_PROVISIONED", user.ID, "user", map[string]string{"username": user.Username, "email": user.Email, "provider": provider}, user.ID)
+	} else if result.Linked {
+		services.CreateAuditLog(c, "OIDC_ACCOUNT_LINKED", user.ID, "user", map[string]string{"email": user.Email, "provider": provider}, user.ID)
+	}
+
+	if !user.Active {
+		return utils.SendAppError(c, utils.ErrUserInactive)
+	}
+
+	return beginLogin(c, user, "oidc")
+}
+
+// GetMyIdentities godoc
+// @Summary List linked accounts
+// @Description List external login accounts linked to the current user
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /auth/identities [get]
+func GetMyIdentities(c *fiber.Ctx) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusUnauthorized, err)
+	}
+
+	identities, err := services.ListUserIdentities(userID)
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch linked accounts: %w", err)))
+	}
+	return utils.SendSuccess(c, fiber.Map{"identities": identities}, "Linked accounts retrieved successfully")
+}
+
+// UnlinkMyIdentity godoc
+// @Summary Unlink an external account
+// @Description Remove an external login account from the current user
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "Identity ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /auth/identities/{id} [delete]
+func UnlinkMyIdentity(c *fiber.Ctx) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusUnauthorized, err)
+	}
+	identityID, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrIdentityIDInvalid)
+	}
+
+	identity, err := services.UnlinkUserIdentity(userID, uint(identityID))
+	if err != nil {
+		if errors.Is(err, services.ErrOIDCIdentityNotFound) {
+			return utils.SendError(c, fiber.StatusNotFound, err)
+		}
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not unlink account: %w", err)))
+	}
+
+	services.CreateAuditLog(c, "OIDC_IDENTITY_UNLINKED", userID, "user", map[string]string{"provider": identity.Provider, "email": identity.Email}, userID)
+
+	return utils.SendSuccess(c, nil, "Account unlinked successfully")
+}
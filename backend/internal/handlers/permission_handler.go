@@ -3,8 +3,9 @@ package handlers
 import (
 	"backend/internal/database"
 	"backend/internal/models"
+	"backend/internal/rbac"
 	"backend/pkg/utils"
-	"errors"
+	"fmt"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -20,10 +21,29 @@ import (
 func GetAllPermissions(c *fiber.Ctx) error {
 	var permissions []models.Permission
 	if err := database.DB.Find(&permissions).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not fetch permissions"))
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch permissions: %w", err)))
 	}
 
 	return utils.SendSuccess(c, fiber.Map{
 		"permissions": permissions,
 	}, "Permissions retrieved successfully")
 }
+
+// GetPermissionMatrix godoc
+// @Summary Get permission matrix
+// @Description List every permission-protected route with its permissions, plus unused and orphaned permissions
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/permissions/matrix [get]
+func GetPermissionMatrix(c *fiber.Ctx) error {
+	matrix, err := rbac.Default.CurrentMatrix()
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch permissions: %w", err)))
+	}
+
+	return utils.SendSuccess(c, fiber.Map{
+		"matrix": matrix,
+	}, "Permission matrix retrieved successfully")
+}
@@ -6,37 +6,100 @@ import (
 	"backend/internal/services"
 	"backend/pkg/utils"
 	"errors"
+	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// GetProjects retrieves all projects
+// GetProjects retrieves published projects
 // GetProjects godoc
 // @Summary Get all projects
-// @Description Get a list of all projects
+// @Description Get a list of published projects (publish_at reached) with filters, full-text search and sorting
 // @Tags Projects
 // @Produce json
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Page limit" default(10)
+// @Param category query string false "Category slug"
+// @Param status query string false "Project status"
+// @Param owner query string false "Owner (partial match)"
+// @Param location query string false "Location (partial match)"
+// @Param year query int false "Year the project was added"
+// @Param q query string false "Search title, description and location"
+// @Param sort query string false "Sort: order, newest, oldest, title, -title, relevance (default relevance when q is set)"
 // @Router /api/projects [get]
 func GetProjects(c *fiber.Ctx) error {
+	// หน้าเว็บสาธารณะเห็นเฉพาะ project ที่เผยแพร่แล้ว
+	return listProjects(c, services.ProjectFilter{Public: true})
+}
+
+// GetAllProjects retrieves projects in every state (admin)
+// GetAllProjects godoc
+// @Summary Get all projects (admin)
+// @Description Get a list of projects including drafts, scheduled and inactive ones, with the same filters as the public list
+// @Tags Projects
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page limit" default(10)
+// @Param is_active query bool false "Filter by active state"
+// @Param publish_status query string false "Filter by publish status: draft, in_review, published, archived"
+// @Param category query string false "Category slug"
+// @Param status query string false "Project status"
+// @Param owner query string false "Owner (partial match)"
+// @Param location query string false "Location (partial match)"
+// @Param year query int false "Year the project was added"
+// @Param q query string false "Search title, description and location"
+// @Param sort query string false "Sort: order, newest, oldest, title, -title, relevance (default relevance when q is set)"
+// @Security BearerAuth
+// @Router /api/projects/all [get]
+func GetAllProjects(c *fiber.Ctx) error {
+	filter := services.ProjectFilter{PublishStatus: c.Query("publish_status")}
+	if c.Query("is_active") != "" {
+		active := c.QueryBool("is_active")
+		filter.Active = &active
+	}
+	return listProjects(c, filter)
+}
+
+// listProjects รายการ projects ตาม query parameters (ใช้ร่วมกันระหว่าง public และ admin)
+func listProjects(c *fiber.Ctx, filter services.ProjectFilter) error {
 	page := c.QueryInt("page", 1)
 	limit := c.QueryInt("limit", 10)
 	offset := (page - 1) * limit
 
+	filter.Category = c.Query("category")
+	filter.Status = c.Query("status")
+	filter.Owner = c.Query("owner")
+	filter.Location = c.Query("location")
+	filter.Year = c.QueryInt("year", 0)
+	filter.Search = c.Query("q")
+	filter.Sort = c.Query("sort")
+	order, err := services.ProjectOrder(filter.Sort, filter.Search)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	}
+
 	var projects []models.Project
 	var total int64
 
-	if err := database.DB.Model(&models.Project{}).Count(&total).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not count projects"))
+	query := services.FilterProjects(database.DB.Model(&models.Project{}), filter)
+
+	if err := query.Count(&total).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not count projects: %w", err)))
 	}
 
-	if err := database.DB.Order("sort_order asc").Offset(offset).Limit(limit).Find(&projects).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not fetch projects"))
+	if err := query.Order(order).Offset(offset).Limit(limit).Find(&projects).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch projects: %w", err)))
 	}
 
 	totalPages := int((total + int64(limit) - 1) / int64(limit))
@@ -49,7 +112,28 @@ func GetProjects(c *fiber.Ctx) error {
 		HasNext:     page < totalPages,
 	}
 
-	return utils.SendSuccessWithPagination(c, projects, pagination, nil, "Projects retrieved successfully")
+	filters := fiber.Map{}
+	for key, value := range map[string]string{
+		"category":       filter.Category,
+		"status":         filter.Status,
+		"owner":          filter.Owner,
+		"location":       filter.Location,
+		"q":              filter.Search,
+		"sort":           filter.Sort,
+		"publish_status": filter.PublishStatus,
+	} {
+		if value != "" {
+			filters[key] = value
+		}
+	}
+	if filter.Year > 0 {
+		filters["year"] = filter.Year
+	}
+	if filter.Active != nil {
+		filters["is_active"] = *filter.Active
+	}
+
+	return utils.SendSuccessWithPagination(c, projects, pagination, filters, "Projects retrieved successfully")
 }
 
 // GetProject retrieves a project by ID
@@ -63,10 +147,63 @@ func GetProjects(c *fiber.Ctx) error {
 // @Failure 404 {object} map[string]interface{}
 // @Router /api/projects/{id} [get]
 func GetProject(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var project models.Project
+	// draft / ตั้งเวลาไว้ / เก็บถาวร ตอบ 404 เหมือนไม่มีอยู่ (ดูได้ทาง /preview)
+	if err := services.PublishedProjects(database.DB, time.Now()).First(&project, id).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrProjectNotFound)
+	}
+	return utils.SendSuccess(c, project, "Project retrieved successfully")
+}
+
+// PreviewProject retrieves a project in any publish state (admin)
+// PreviewProject godoc
+// @Summary Preview project
+// @Description Get a project by ID regardless of its publish status (drafts, scheduled, archived)
+// @Tags Projects
+// @Produce json
+// @Param id path string true "Project ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /api/projects/{id}/preview [get]
+func PreviewProject(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var project models.Project
 	if err := database.DB.First(&project, id).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("project not found"))
+		return utils.SendAppError(c, utils.ErrProjectNotFound)
+	}
+	return utils.SendSuccess(c, project, "Project retrieved successfully")
+}
+
+// GetProjectBySlug retrieves a project by its URL slug
+// GetProjectBySlug godoc
+// @Summary Get project by slug
+// @Description Get a project by its slug. A previous slug of a renamed project responds 301 with the current slug (Location header and data.slug)
+// @Tags Projects
+// @Produce json
+// @Param slug path string true "Project slug"
+// @Success 200 {object} map[string]interface{}
+// @Success 301 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/projects/by-slug/{slug} [get]
+func GetProjectBySlug(c *fiber.Ctx) error {
+	project, moved, err := services.FindProjectBySlug(database.DB, c.Params("slug"), true)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.SendAppError(c, utils.ErrProjectNotFound)
+		}
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch project: %w", err)))
+	}
+
+	if moved {
+		// slug เดิมของ project ที่เปลี่ยนชื่อ - บอก client ให้ไปที่ slug ปัจจุบัน (301 ให้ search engine ย้าย index ตาม)
+		c.Set(fiber.HeaderLocation, "/api/projects/by-slug/"+project.Slug)
+		return c.Status(fiber.StatusMovedPermanently).JSON(utils.SuccessResponse{
+			Success: true,
+			Data:    fiber.Map{"id": project.ID, "slug": project.Slug, "redirect": true},
+			Message: utils.Translate(c, utils.MsgProjectMoved),
+		})
 	}
 	return utils.SendSuccess(c, project, "Project retrieved successfully")
 }
@@ -86,23 +223,52 @@ func GetProject(c *fiber.Ctx) error {
 func CreateProject(c *fiber.Ctx) error {
 	project := new(models.Project)
 	if err := c.BodyParser(project); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 
-	if err := database.DB.Create(&project).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not create project"))
+	// project ใหม่เป็น draft เสมอ - เผยแพร่ผ่าน PUT /projects/:id/status (ต้องมีสิทธิ์ projects.publish)
+	project.PublishStatus = models.ProjectDraft
+	project.PublishAt = nil
+	project.PublishedAt = nil
+
+	var revision *models.ProjectRevision
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// category_id หรือชื่อหมวดหมู่ (client เดิม) ต้องมีอยู่ในตาราง categories
+		category, err := services.ResolveProjectCategory(tx, project.CategoryID, project.Category)
+		if err != nil {
+			return err
+		}
+		project.SetCategory(category)
+
+		// slug ที่กรอกมาเองต้องไม่ซ้ำ ถ้าไม่กรอกสร้างจากชื่อ (ซ้ำเติม -2, -3, ...)
+		if project.Slug != "" {
+			project.Slug, err = services.NormalizeProjectSlug(tx, project.Slug, 0)
+		} else {
+			project.Slug, err = services.UniqueProjectSlug(tx, project.Title, 0)
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(project).Error; err != nil {
+			return err
+		}
+		revision, err = services.RecordProjectRevision(tx, project, models.ProjectRevisionCreate, revisionAuthor(c), nil)
+		return err
+	})
+	if err != nil {
+		return sendProjectError(c, err, "could not create project")
 	}
 
 	// Audit Log
-	services.CreateAuditLog(c, "PROJECT_CREATE", project.ID, "project", map[string]string{"title": project.Title})
+	services.CreateAuditLog(c, "PROJECT_CREATE", project.ID, "project", map[string]interface{}{"title": project.Title, "slug": project.Slug, "revision": revision.Revision})
 
-	return utils.SendCreated(c, project, "Project created successfully")
+	return utils.SendCreated(c, project, utils.MsgProjectCreated)
 }
 
 // UpdateProject modifies an existing project
 // UpdateProject godoc
 // @Summary Update a project
-// @Description Update a project
+// @Description Update a project. Editing a published or archived project requires projects.publish
 // @Tags Projects
 // @Accept json
 // @Produce json
@@ -110,29 +276,150 @@ func CreateProject(c *fiber.Ctx) error {
 // @Param input body models.Project true "Project info"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
 // @Router /api/projects/{id} [put]
 func UpdateProject(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var project models.Project
 	if err := database.DB.First(&project, id).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("project not found"))
+		return utils.SendAppError(c, utils.ErrProjectNotFound)
 	}
 
 	updateData := new(models.Project)
 	if err := c.BodyParser(updateData); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 
-	// Manually update fields to avoid zero-value issues with structs if needed,
-	// or use Model(&project).Updates(updateData)
-	// For simplicity using Model updates
-	database.DB.Model(&project).Updates(updateData)
+	// slug แยกอัปเดตเพื่อเก็บ slug เดิมลง history (เปลี่ยนชื่อ project ไม่เปลี่ยน slug อัตโนมัติ - ลิงก์เดิมไม่เสีย)
+	newSlug := updateData.Slug
+	updateData.Slug = ""
+	oldSlug := project.Slug
+
+	// สถานะการเผยแพร่เปลี่ยนผ่าน PUT /projects/:id/status เท่านั้น
+	updateData.PublishStatus = ""
+	updateData.PublishAt = nil
+	updateData.PublishedAt = nil
+
+	// หมวดหมู่แยกอัปเดต - category_id = 0 คือเอาหมวดหมู่ออก
+	changeCategory := updateData.CategoryID != nil || updateData.Category != ""
+	categoryID, categoryName := updateData.CategoryID, updateData.Category
+	updateData.CategoryID, updateData.Category = nil, ""
+
+	var revision *models.ProjectRevision
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockEditableProject(c, tx, &project); err != nil {
+			return err
+		}
+		// Manually update fields to avoid zero-value issues with structs if needed,
+		// or use Model(&project).Updates(updateData)
+		// For simplicity using Model updates
+		if err := tx.Model(&project).Updates(updateData).Error; err != nil {
+			return err
+		}
+		if changeCategory {
+			category, err := services.ResolveProjectCategory(tx, categoryID, categoryName)
+			if err != nil {
+				return err
+			}
+			project.SetCategory(category)
+			if err := tx.Model(&project).Updates(map[string]interface{}{"category_id": project.CategoryID, "category": project.Category}).Error; err != nil {
+				return err
+			}
+		}
+		if newSlug != "" {
+			slug, err := services.NormalizeProjectSlug(tx, newSlug, project.ID)
+			if err != nil {
+				return err
+			}
+			if err := services.ChangeProjectSlug(tx, &project, slug); err != nil {
+				return err
+			}
+		}
+		var err error
+		revision, err = services.RecordProjectRevision(tx, &project, models.ProjectRevisionUpdate, revisionAuthor(c), nil)
+		return err
+	})
+	if err != nil {
+		return sendProjectError(c, err, "could not update project")
+	}
 
 	// Audit Log
-	services.CreateAuditLog(c, "PROJECT_UPDATE", project.ID, "project", map[string]string{"title": project.Title})
+	details := map[string]interface{}{"title": project.Title, "revision": revision.Revision}
+	if project.Slug != oldSlug {
+		details["slug"] = project.Slug
+		details["old_slug"] = oldSlug
+	}
+	services.CreateAuditLog(c, "PROJECT_UPDATE", project.ID, "project", details)
 
-	return utils.SendSuccess(c, project, "Project updated successfully")
+	return utils.SendSuccess(c, project, utils.MsgProjectUpdated)
+}
+
+// UpdateProjectStatus moves a project through the draft/review/publish workflow
+// UpdateProjectStatus godoc
+// @Summary Update project publish status
+// @Description Change the publish status (draft, in_review, published, archived). Publishing, archiving or pulling a project back from those states requires projects.publish. publish_at in the future schedules publishing
+// @Tags Projects
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param input body map[string]interface{} true "status and optional publish_at (RFC3339)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /api/projects/{id}/status [put]
+func UpdateProjectStatus(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var project models.Project
+	if err := database.DB.First(&project, id).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrProjectNotFound)
+	}
+
+	var input struct {
+		Status    string     `json:"status"`
+		PublishAt *time.Time `json:"publish_at"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusUnauthorized, err)
+	}
+	from := project.PublishStatus
+	if models.ProjectStatusNeedsPublish(from, input.Status) && !claims.HasPermission("projects.publish") {
+		return utils.SendError(c, fiber.StatusForbidden, services.ErrProjectPublishDenied)
+	}
+
+	var revision *models.ProjectRevision
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := services.ChangeProjectStatus(tx, &project, input.Status, input.PublishAt, time.Now()); err != nil {
+			return err
+		}
+		var err error
+		revision, err = services.RecordProjectRevision(tx, &project, models.ProjectRevisionStatus, revisionAuthor(c), nil)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrProjectStatus) || errors.Is(err, services.ErrProjectPublishAt) {
+			return utils.SendError(c, fiber.StatusBadRequest, err)
+		}
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not update project: %w", err)))
+	}
+
+	// Audit Log
+	services.CreateAuditLog(c, "PROJECT_STATUS_CHANGE", project.ID, "project", map[string]interface{}{
+		"title":      project.Title,
+		"from":       from,
+		"to":         project.PublishStatus,
+		"publish_at": project.PublishAt,
+		"revision":   revision.Revision,
+	})
+
+	return utils.SendSuccess(c, project, utils.MsgProjectUpdated)
 }
 
 // DeleteProject removes a project
@@ -149,7 +436,7 @@ func DeleteProject(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var project models.Project
 	if err := database.DB.First(&project, id).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("project not found"))
+		return utils.SendAppError(c, utils.ErrProjectNotFound)
 	}
 
 	// Delete images from R2 if any
@@ -169,11 +456,13 @@ func DeleteProject(c *fiber.Ctx) error {
 		}
 	}
 
+	database.DB.Where("project_id = ?", project.ID).Delete(&models.ProjectSlugHistory{})
+	database.DB.Where("project_id = ?", project.ID).Delete(&models.ProjectRevision{})
 	database.DB.Delete(&project)
 	// Audit Log
 	services.CreateAuditLog(c, "PROJECT_DELETE", project.ID, "project", map[string]string{"title": project.Title})
 
-	return utils.SendSuccess(c, nil, "Project deleted successfully")
+	return utils.SendSuccess(c, nil, utils.MsgProjectDeleted)
 }
 
 // UpdateProjectOrder updates the sort order of projects
@@ -195,17 +484,48 @@ func UpdateProjectOrder(c *fiber.Ctx) error {
 	}
 
 	if err := c.BodyParser(&orderData); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 
 	tx := database.DB.Begin()
 	for _, item := range orderData {
 		if err := tx.Model(&models.Project{}).Where("id = ?", item.ID).Update("sort_order", item.SortOrder).Error; err != nil {
 			tx.Rollback()
-			return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not update project order"))
+			return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not update project order: %w", err)))
 		}
 	}
 	tx.Commit()
 
 	return utils.SendSuccess(c, nil, "Project order updated successfully")
 }
+
+// lockEditableProject lock project ใน transaction (อ่านสถานะล่าสุด) แล้วตรวจสิทธิ์แก้ไขเนื้อหา
+// project ที่เผยแพร่ / เก็บถาวรแล้วต้องมีสิทธิ์ projects.publish - ผู้ที่มีแค่ projects.manage ต้องให้ผู้เผยแพร่ดึงกลับเป็น draft ก่อน
+func lockEditableProject(c *fiber.Ctx, tx *gorm.DB, project *models.Project) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(project, project.ID).Error; err != nil {
+		return err
+	}
+	if !models.ProjectEditNeedsPublish(project.PublishStatus) {
+		return nil
+	}
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil || !claims.HasPermission("projects.publish") {
+		return utils.ErrProjectEditDenied
+	}
+	return nil
+}
+
+// sendProjectError ตอบ error ของ slug / หมวดหมู่ตาม code เฉพาะ ที่เหลือตอบ 500 ด้วยข้อความ fallback
+// slug ที่ชน unique index (สร้าง / เปลี่ยน slug พร้อมกัน) ตอบ 409 เหมือนกรณีตรวจพบก่อนบันทึก
+func sendProjectError(c *fiber.Ctx, err error, fallback string) error {
+	err = services.ProjectSlugConflict(err)
+	switch {
+	case errors.Is(err, services.ErrProjectSlugInvalid), errors.Is(err, services.ErrProjectCategory):
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	case errors.Is(err, services.ErrProjectSlugTaken):
+		return utils.SendError(c, fiber.StatusConflict, err)
+	case errors.Is(err, utils.ErrProjectEditDenied):
+		return utils.SendAppError(c, utils.ErrProjectEditDenied)
+	}
+	return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("%s: %w", fallback, err)))
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/internal/services"
+	"backend/pkg/utils"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// revisionAuthor ผู้แก้ไขของ request ปัจจุบัน (บันทึกลง ProjectRevision)
+func revisionAuthor(c *fiber.Ctx) services.RevisionAuthor {
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		return services.RevisionAuthor{}
+	}
+	return services.RevisionAuthor{ID: claims.UserID, Username: claims.Username}
+}
+
+// sendRevisionError ตอบ 404 เมื่อไม่พบ revision ที่เหลือตอบ 500
+func sendRevisionError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, services.ErrProjectRevisionNotFound) {
+		return utils.SendError(c, fiber.StatusNotFound, err)
+	}
+	return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch revision: %w", err)))
+}
+
+// GetProjectRevisions lists the revisions of a project
+// GetProjectRevisions godoc
+// @Summary List project revisions
+// @Description List every revision of a project, newest first, with author and timestamp
+// @Tags Projects
+// @Produce json
+// @Param id path int true "Project ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /api/projects/{id}/revisions [get]
+func GetProjectRevisions(c *fiber.Ctx) error {
+	var project models.Project
+	if err := database.DB.First(&project, c.Params("id")).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrProjectNotFound)
+	}
+
+	var revisions []models.ProjectRevision
+	if err := database.DB.Where("project_id = ?", project.ID).Order("revision desc").Find(&revisions).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch revisions: %w", err)))
+	}
+	return utils.SendSuccess(c, revisions, "Revisions retrieved successfully")
+}
+
+// GetProjectRevision retrieves one revision of a project
+// GetProjectRevision godoc
+// @Summary Get project revision
+// @Description Get the full snapshot stored in one revision
+// @Tags Projects
+// @Produce json
+// @Param id path int true "Project ID"
+// @Param revision path int true "Revision number"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /api/projects/{id}/revisions/{revision} [get]
+func GetProjectRevision(c *fiber.Ctx) error {
+	projectID, _ := c.ParamsInt("id")
+	number, err := c.ParamsInt("revision")
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrProjectRevisionInvalid)
+	}
+
+	revision, err := services.FindProjectRevision(database.DB, uint(projectID), number)
+	if err != nil {
+		return sendRevisionError(c, err)
+	}
+	return utils.SendSuccess(c, revision, "Revision retrieved successfully")
+}
+
+// DiffProjectRevisions compares two revisions field by field
+// DiffProjectRevisions godoc
+// @Summary Diff project revisions
+// @Description Compare two revisions of a project field by field. Without "to" the revision is compared with the latest one
+// @Tags Projects
+// @Produce json
+// @Param id path int true "Project ID"
+// @Param from query int true "Older revision number"
+// @Param to query int false "Newer revision number (default: latest)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /api/projects/{id}/revisions/diff [get]
+func DiffProjectRevisions(c *fiber.Ctx) error {
+	projectID, _ := c.ParamsInt("id")
+	fromNumber := c.QueryInt("from", 0)
+	toNumber := c.QueryInt("to", 0)
+	if fromNumber <= 0 || toNumber < 0 {
+		return utils.SendAppError(c, utils.ErrProjectRevisionInvalid)
+	}
+
+	// ไม่ระบุ to - เทียบกับ revision ล่าสุด
+	if toNumber == 0 {
+		if err := database.DB.Model(&models.ProjectRevision{}).Where("project_id = ?", projectID).
+			Select("COALESCE(MAX(revision), 0)").Scan(&toNumber).Error; err != nil {
+			return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch revision: %w", err)))
+		}
+	}
+
+	from, err := services.FindProjectRevision(database.DB, uint(projectID), fromNumber)
+	if err != nil {
+		return sendRevisionError(c, err)
+	}
+	to, err := services.FindProjectRevision(database.DB, uint(projectID), toNumber)
+	if err != nil {
+		return sendRevisionError(c, err)
+	}
+
+	return utils.SendSuccess(c, fiber.Map{
+		"from":    from.Revision,
+		"to":      to.Revision,
+		"changes": from.Snapshot.Diff(to.Snapshot),
+	}, "Revisions compared successfully")
+}
+
+// RestoreProjectRevision restores a revision as a new revision
+// RestoreProjectRevision godoc
+// @Summary Restore project revision
+// @Description Copy the content of an old revision back onto the project and record it as a new revision. Publish status is not restored. Restoring onto a published or archived project requires projects.publish
+// @Tags Projects
+// @Produce json
+// @Param id path int true "Project ID"
+// @Param revision path int true "Revision number"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Security BearerAuth
+// @Router /api/projects/{id}/revisions/{revision}/restore [post]
+func RestoreProjectRevision(c *fiber.Ctx) error {
+	var project models.Project
+	if err := database.DB.First(&project, c.Params("id")).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrProjectNotFound)
+	}
+	number, err := c.ParamsInt("revision")
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrProjectRevisionInvalid)
+	}
+
+	var restored *models.ProjectRevision
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockEditableProject(c, tx, &project); err != nil {
+			return err
+		}
+		source, err := services.FindProjectRevision(tx, project.ID, number)
+		if err != nil {
+			return err
+		}
+		restored, err = services.RestoreProjectRevision(tx, &project, source, revisionAuthor(c))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrProjectRevisionNotFound) {
+			return utils.SendError(c, fiber.StatusNotFound, err)
+		}
+		return sendProjectError(c, err, "could not restore revision")
+	}
+
+	// Audit Log
+	services.CreateAuditLog(c, "PROJECT_REVISION_RESTORE", project.ID, "project", map[string]interface{}{
+		"title":         project.Title,
+		"restored_from": number,
+		"revision":      restored.Revision,
+	})
+
+	return utils.SendSuccess(c, fiber.Map{"project": project, "revision": restored}, "Revision restored successfully")
+}
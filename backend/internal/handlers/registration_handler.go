@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/internal/services"
+	"backend/pkg/email"
+	"backend/pkg/utils"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CreateInvitationInput struct {
+	Email          string `json:"email"`            // optional - ถ้าระบุจะส่ง invite ทาง email และบังคับให้สมัครด้วย email นี้
+	Role           string `json:"role"`             // role name ที่จะได้รับหลังสมัคร (ว่าง = user)
+	ExpiresInHours int    `json:"expires_in_hours"` // ว่าง = 7 วัน
+}
+
+// CreateInvitation godoc
+// @Summary Create an invitation
+// @Description Generate a single-use registration link with a pre-assigned role (Admin only)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param input body CreateInvitationInput true "Invitation info"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/invitations [post]
+func CreateInvitation(c *fiber.Ctx) error {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusUnauthorized, err)
+	}
+
+	var input CreateInvitationInput
+	if err := c.BodyParser(&input); err != nil {
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	input.Email = strings.TrimSpace(input.Email)
+	if input.Email != "" && !strings.Contains(input.Email, "@") {
+		return utils.SendAppError(c, utils.ErrEmailInvalid)
+	}
+
+	var roleID *uint
+	if input.Role != "" {
+		id := getRoleIDByName(input.Role)
+		if id == 0 {
+			return utils.SendAppError(c, utils.ErrRoleNotFound.WithStatus(fiber.StatusBadRequest))
+		}
+		roleID = &id
+	}
+
+	ttl := services.DefaultInvitationTTL
+	if input.ExpiresInHours > 0 {
+		ttl = time.Duration(input.ExpiresInHours) * time.Hour
+	}
+
+	token, invitation, err := services.CreateInvitation(input.Email, roleID, adminID, ttl)
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not create invitation: %w", err)))
+	}
+
+	inviteLink := fmt.Sprintf("%s/register?invite=%s", frontendURL(c), token)
+	if invitation.Email != "" {
+		go func() {
+			if err := email.SendInvitationEmail(invitation.Email, inviteLink, invitation.ExpiresAt); err != nil {
+				fmt.Printf("Failed to send invitation email: %v\n", err)
+			}
+		}()
+	}
+
+	// Audit Log
+	services.CreateAuditLog(c, "INVITATION_CREATED", invitation.ID, "invitation", map[string]interface{}{
+		"email":      invitation.Email,
+		"role":       input.Role,
+		"expires_at": invitation.ExpiresAt,
+	})
+
+	return utils.SendCreated(c, fiber.Map{
+		"invitation":  invitation,
+		"invite_link": inviteLink,
+		"token":       token,
+	}, "Invitation created successfully")
+}
+
+// GetInvitations godoc
+// @Summary List invitations
+// @Description List invitations, newest first (Admin only)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param status query string false "Filter: active, used, revoked, expired"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/invitations [get]
+func GetInvitations(c *fiber.Ctx) error {
+	query := database.DB.Preload("Role").Order("created_at desc")
+
+	now := time.Now()
+	switch c.Query("status") {
+	case "active":
+		query = query.Where("used_at IS NULL AND revoked_at IS NULL AND expires_at > ?", now)
+	case "used":
+		query = query.Where("used_at IS NOT NULL")
+	case "revoked":
+		query = query.Where("revoked_at IS NOT NULL")
+	case "expired":
+		query = query.Where("used_at IS NULL AND revoked_at IS NULL AND expires_at <= ?", now)
+	}
+
+	var invitations []models.Invitation
+	if err := query.Find(&invitations).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch invitations: %w", err)))
+	}
+
+	return utils.SendSuccess(c, fiber.Map{
+		"invitations": invitations,
+	}, "Invitations retrieved successfully")
+}
+
+// RevokeInvitation godoc
+// @Summary Revoke an invitation
+// @Description Revoke an unused invitation link (Admin only)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "Invitation ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/invitations/{id} [delete]
+func RevokeInvitation(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var invitation models.Invitation
+	if err := database.DB.Where("used_at IS NULL AND revoked_at IS NULL").First(&invitation, id).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrInvitationNotFound)
+	}
+
+	if err := database.DB.Model(&invitation).Update("revoked_at", time.Now()).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not revoke invitation: %w", err)))
+	}
+
+	// Audit Log
+	services.CreateAuditLog(c, "INVITATION_REVOKED", invitation.ID, "invitation", map[string]string{"email": invitation.Email})
+
+	return utils.SendSuccess(c, nil, "Invitation revoked successfully")
+}
+
+// CheckInvitation godoc
+// @Summary Check an invitation link
+// @Description Validate an invitation token before showing the registration form
+// @Tags Auth
+// @Produce json
+// @Param token path string true "Invitation token"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /auth/invitations/{token} [get]
+func CheckInvitation(c *fiber.Ctx) error {
+	invitation, err := services.FindValidInvitation(c.Params("token"))
+	if err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	}
+
+	return utils.SendSuccess(c, fiber.Map{
+		"valid":      true,
+		"email":      invitation.Email,
+		"role":       invitation.Role.Name,
+		"expires_at": invitation.ExpiresAt,
+	}, "Invitation is valid")
+}
+
+// GetPendingRegistrations godoc
+// @Summary Registration approval queue
+// @Description List self-registered accounts waiting for approval (Admin only)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/users/pending [get]
+func GetPendingRegistrations(c *fiber.Ctx) error {
+	var users []models.User
+	if err := database.DB.Preload("Role").
+		Where("approval_status = ?", models.ApprovalPending).
+		Order("created_at asc").
+		Find(&users).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch pending registrations: %w", err)))
+	}
+
+	return utils.SendSuccess(c, fiber.Map{
+		"users": users,
+	}, "Pending registrations retrieved successfully")
+}
+
+type RegistrationDecisionInput struct {
+	Reason string `json:"reason"`
+}
+
+// ApproveRegistration godoc
+// @Summary Approve a registration
+// @Description Approve a pending self-registered account (Admin only)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/users/{id}/approve [post]
+func ApproveRegistration(c *fiber.Ctx) error {
+	return decideRegistration(c, true)
+}
+
+// RejectRegistration godoc
+// @Summary Reject a registration
+// @Description Reject a pending self-registered account and deactivate it (Admin only)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param input body RegistrationDecisionInput false "Reason"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/users/{id}/reject [post]
+func RejectRegistration(c *fiber.Ctx) error {
+	return decideRegistration(c, false)
+}
+
+func decideRegistration(c *fiber.Ctx, approve bool) error {
+	var input RegistrationDecisionInput
+	_ = c.BodyParser(&input) // body เป็น optional
+
+	id := c.Params("id")
+	var user models.User
+	if err := database.DB.Where("approval_status = ?", models.ApprovalPending).First(&user, id).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrRegistrationNotFound)
+	}
+
+	updates := map[string]interface{}{"approval_status": models.ApprovalApproved}
+	action := "REGISTRATION_APPROVED"
+	if !approve {
+		updates = map[string]interface{}{"approval_status": models.ApprovalRejected, "active": false}
+		action = "REGISTRATION_REJECTED"
+	}
+
+	// conditional update กัน admin 2 คนตัดสินพร้อมกัน
+	result := database.DB.Model(&models.User{}).
+		Where("id = ? AND approval_status = ?", user.ID, models.ApprovalPending).
+		Updates(updates)
+	if result.Error != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not update registration: %w", result.Error)))
+	}
+	if result.RowsAffected == 0 {
+		return utils.SendAppError(c, utils.ErrRegistrationDecided)
+	}
+
+	go func() {
+		if err := email.SendRegistrationDecisionEmail(user.Email, user.Username, approve, input.Reason); err != nil {
+			fmt.Printf("Failed to send registration decision email: %v\n", err)
+		}
+	}()
+
+	// Audit Log
+	services.CreateAuditLog(c, action, user.ID, "user", map[string]string{
+		"username": user.Username,
+		"email":    user.Email,
+		"reason":   input.Reason,
+	})
+
+	if approve {
+		return utils.SendSuccess(c, nil, "Registration approved successfully")
+	}
+	return utils.SendSuccess(c, nil, "Registration rejected successfully")
+}
@@ -3,8 +3,11 @@ package handlers
 import (
 	"backend/internal/database"
 	"backend/internal/models"
+	"backend/internal/services"
 	"backend/pkg/utils"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -20,8 +23,8 @@ import (
 // @Router /api/roles [get]
 func GetAllRoles(c *fiber.Ctx) error {
 	var roles []models.Role
-	if err := database.DB.Preload("Permissions").Find(&roles).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not fetch roles"))
+	if err := database.DB.Preload("Permissions").Preload("Scopes").Find(&roles).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch roles: %w", err)))
 	}
 
 	return utils.SendSuccess(c, fiber.Map{
@@ -42,19 +45,88 @@ func GetAllRoles(c *fiber.Ctx) error {
 func GetRole(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var role models.Role
-	if err := database.DB.Preload("Permissions").First(&role, id).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("role not found"))
+	if err := database.DB.Preload("Permissions").Preload("Scopes").Preload("Parent").First(&role, id).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrRoleNotFound)
+	}
+
+	effective, err := services.EffectivePermissions(role.ID)
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not resolve role permissions: %w", err)))
+	}
+	inherited := []services.EffectivePermission{}
+	for _, p := range effective {
+		if p.InheritedFrom != nil {
+			inherited = append(inherited, p)
+		}
 	}
 
 	return utils.SendSuccess(c, fiber.Map{
-		"role": role,
+		"role":                  role,
+		"direct_permissions":    role.Permissions,
+		"inherited_permissions": inherited,
 	}, "Role retrieved successfully")
 }
 
+// RoleScopeInput จำกัด permission ของ role ให้ใช้ได้เฉพาะแผนก/ลูกทีม
+type RoleScopeInput struct {
+	PermissionID uint   `json:"permission_id"`
+	ScopeType    string `json:"scope_type"` // department, own_department, reports
+	DepartmentID *uint  `json:"department_id"`
+}
+
+var errInvalidRoleScope = utils.ErrRoleScopeInvalid
+
+// replaceRoleScopes แทนที่ scopes ทั้งหมดของ role - permission ต้องเป็นของ role นั้น
+func replaceRoleScopes(tx *gorm.DB, role *models.Role, inputs []RoleScopeInput) error {
+	var permissionIDs []uint
+	if err := tx.Table("role_permissions").Where("role_id = ?", role.ID).Pluck("permission_id", &permissionIDs).Error; err != nil {
+		return err
+	}
+	granted := map[uint]bool{}
+	for _, id := range permissionIDs {
+		granted[id] = true
+	}
+
+	scopes := make([]models.RolePermissionScope, 0, len(inputs))
+	for _, in := range inputs {
+		if !granted[in.PermissionID] {
+			return errInvalidRoleScope
+		}
+		scope := models.RolePermissionScope{RoleID: role.ID, PermissionID: in.PermissionID, ScopeType: in.ScopeType}
+		switch in.ScopeType {
+		case models.ScopeDepartment:
+			if in.DepartmentID == nil {
+				return errInvalidRoleScope
+			}
+			var count int64
+			tx.Model(&models.Department{}).Where("id = ?", *in.DepartmentID).Count(&count)
+			if count == 0 {
+				return errInvalidRoleScope
+			}
+			scope.DepartmentID = in.DepartmentID
+		case models.ScopeOwnDepartment, models.ScopeReports:
+		default:
+			return errInvalidRoleScope
+		}
+		scopes = append(scopes, scope)
+	}
+
+	if err := tx.Where("role_id = ?", role.ID).Delete(&models.RolePermissionScope{}).Error; err != nil {
+		return err
+	}
+	if len(scopes) == 0 {
+		return nil
+	}
+	return tx.Create(&scopes).Error
+}
+
 type CreateRoleInput struct {
-	Name          string `json:"name" validate:"required"`
-	Description   string `json:"description"`
-	PermissionIDs []uint `json:"permission_ids"` // รองรับการสร้าง role พร้อม permissions
+	Name             string           `json:"name" validate:"required"`
+	Description      string           `json:"description"`
+	PermissionIDs    []uint           `json:"permission_ids"` // รองรับการสร้าง role พร้อม permissions
+	RequireTwoFactor bool             `json:"require_two_factor"`
+	Scopes           []RoleScopeInput `json:"scopes"`
+	ParentID         *uint            `json:"parent_id"` // สืบทอด permissions จาก role นี้
 }
 
 // CreateRole godoc
@@ -70,12 +142,22 @@ type CreateRoleInput struct {
 func CreateRole(c *fiber.Ctx) error {
 	var input CreateRoleInput
 	if err := c.BodyParser(&input); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
 	}
 
 	role := models.Role{
-		Name:        input.Name,
-		Description: input.Description,
+		Name:             input.Name,
+		Description:      input.Description,
+		RequireTwoFactor: input.RequireTwoFactor,
+		ParentID:         input.ParentID,
+	}
+	if input.ParentID != nil {
+		if err := services.ValidateRoleParent(0, *input.ParentID); err != nil {
+			return sendRoleParentError(c, err)
+		}
 	}
 
 	// ใช้ transaction เพื่อสร้าง role และ assign permissions พร้อมกัน
@@ -96,15 +178,21 @@ func CreateRole(c *fiber.Ctx) error {
 			}
 		}
 
+		if len(input.Scopes) > 0 {
+			return replaceRoleScopes(tx, &role, input.Scopes)
+		}
 		return nil
 	})
 
 	if err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not create role"))
+		if errors.Is(err, errInvalidRoleScope) {
+			return utils.SendError(c, fiber.StatusBadRequest, err)
+		}
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not create role: %w", err)))
 	}
 
 	// Reload role with permissions
-	database.DB.Preload("Permissions").First(&role, role.ID)
+	database.DB.Preload("Permissions").Preload("Scopes").First(&role, role.ID)
 
 	return utils.SendCreated(c, fiber.Map{
 		"role": role,
@@ -112,9 +200,24 @@ func CreateRole(c *fiber.Ctx) error {
 }
 
 type UpdateRoleInput struct {
-	Name          string `json:"name"`
-	Description   string `json:"description"`
-	PermissionIDs []uint `json:"permission_ids"` // List of permission IDs to assign
+	Name             string            `json:"name"`
+	Description      string            `json:"description"`
+	PermissionIDs    []uint            `json:"permission_ids"` // List of permission IDs to assign
+	RequireTwoFactor *bool             `json:"require_two_factor"`
+	Scopes           *[]RoleScopeInput `json:"scopes"`    // nil = ไม่เปลี่ยน, [] = ล้าง scope ทั้งหมด
+	ParentID         *uint             `json:"parent_id"` // nil = ไม่เปลี่ยน, 0 = ไม่มี parent
+}
+
+// sendRoleParentError ตอบกลับเมื่อ parent role ไม่ถูกต้อง
+func sendRoleParentError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrRoleCycle), errors.Is(err, services.ErrRoleTooDeep):
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	case errors.Is(err, services.ErrRoleNotFound):
+		return utils.SendAppError(c, utils.ErrRoleParentNotFound)
+	default:
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not validate parent role: %w", err)))
+	}
 }
 
 // UpdateRole godoc
@@ -132,12 +235,12 @@ func UpdateRole(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var input UpdateRoleInput
 	if err := c.BodyParser(&input); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 
 	var role models.Role
 	if err := database.DB.First(&role, id).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("role not found"))
+		return utils.SendAppError(c, utils.ErrRoleNotFound)
 	}
 
 	// Update fields
@@ -145,6 +248,26 @@ func UpdateRole(c *fiber.Ctx) error {
 		role.Name = input.Name
 	}
 	role.Description = input.Description
+	// เปลี่ยน permissions หรือการบังคับ 2FA = claims ใน token ของ user ใน role นี้ไม่ตรงแล้ว
+	claimsChanged := input.PermissionIDs != nil
+	if input.RequireTwoFactor != nil {
+		claimsChanged = claimsChanged || role.RequireTwoFactor != *input.RequireTwoFactor
+		role.RequireTwoFactor = *input.RequireTwoFactor
+	}
+	// เปลี่ยน parent = permissions ที่สืบทอดเปลี่ยน
+	if input.ParentID != nil {
+		var newParent *uint
+		if *input.ParentID != 0 {
+			if err := services.ValidateRoleParent(role.ID, *input.ParentID); err != nil {
+				return sendRoleParentError(c, err)
+			}
+			newParent = input.ParentID
+		}
+		oldParent := role.ParentID
+		claimsChanged = claimsChanged || (oldParent == nil) != (newParent == nil) || (oldParent != nil && *oldParent != *newParent)
+		role.ParentID = newParent
+		role.Parent = nil
+	}
 
 	// Update Permissions using transaction
 	err := database.DB.Transaction(func(tx *gorm.DB) error {
@@ -161,16 +284,35 @@ func UpdateRole(c *fiber.Ctx) error {
 			if err := tx.Model(&role).Association("Permissions").Replace(permissions); err != nil {
 				return err
 			}
+			// scope ของ permission ที่ถูกถอดออกไม่มีผลแล้ว
+			if err := tx.Where("role_id = ? AND permission_id NOT IN (?)", role.ID,
+				tx.Table("role_permissions").Select("permission_id").Where("role_id = ?", role.ID)).
+				Delete(&models.RolePermissionScope{}).Error; err != nil {
+				return err
+			}
+		}
+
+		if input.Scopes != nil {
+			return replaceRoleScopes(tx, &role, *input.Scopes)
 		}
 		return nil
 	})
 
 	if err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not update role"))
+		if errors.Is(err, errInvalidRoleScope) {
+			return utils.SendError(c, fiber.StatusBadRequest, err)
+		}
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not update role: %w", err)))
+	}
+
+	if claimsChanged {
+		if err := services.BumpRoleTokenVersion(role.ID); err != nil {
+			return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("role updated but could not invalidate existing tokens: %w", err)))
+		}
 	}
 
 	// Reload to get permissions
-	database.DB.Preload("Permissions").First(&role, role.ID)
+	database.DB.Preload("Permissions").Preload("Scopes").First(&role, role.ID)
 
 	return utils.SendSuccess(c, fiber.Map{
 		"role": role,
@@ -190,21 +332,45 @@ func DeleteRole(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var role models.Role
 	if err := database.DB.First(&role, id).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("role not found"))
+		return utils.SendAppError(c, utils.ErrRoleNotFound)
 	}
 
 	// Check if role is assigned to users
 	var count int64
 	database.DB.Model(&models.User{}).Where("role_id = ?", role.ID).Count(&count)
 	if count > 0 {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("cannot delete role assigned to users"))
+		return utils.SendAppError(c, utils.ErrRoleInUse)
+	}
+
+	// role ที่ยังถูก grant เป็น role เพิ่มเติมอยู่ (รวมที่ยังไม่ถึงวันเริ่ม)
+	database.DB.Model(&models.UserRole{}).
+		Where("role_id = ? AND revoked_at IS NULL AND (valid_until IS NULL OR valid_until > ?)", role.ID, time.Now()).
+		Count(&count)
+	if count > 0 {
+		return utils.SendAppError(c, utils.ErrRoleGrantedToUsers)
+	}
+
+	// role ลูกสืบทอด permissions จาก role นี้อยู่
+	database.DB.Model(&models.Role{}).Where("parent_id = ?", role.ID).Count(&count)
+	if count > 0 {
+		return utils.SendAppError(c, utils.ErrRoleHasChildren)
 	}
 
-	if err := database.DB.Delete(&role).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not delete role"))
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ?", role.ID).Delete(&models.RolePermissionScope{}).Error; err != nil {
+			return err
+		}
+		// ประวัติ grant ที่ปิดไปแล้วของ role นี้
+		if err := tx.Where("role_id = ?", role.ID).Delete(&models.UserRole{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&role).Error
+	})
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not delete role: %w", err)))
 	}
 
-	return utils.SendSuccess(c, nil, "Role deleted successfully")
+	return utils.SendSuccess(c, nil, utils.MsgRoleDeleted)
 }
 
 // GetRoleUsers godoc
@@ -222,7 +388,7 @@ func GetRoleUsers(c *fiber.Ctx) error {
 	// Check if role exists
 	var role models.Role
 	if err := database.DB.First(&role, id).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("role not found"))
+		return utils.SendAppError(c, utils.ErrRoleNotFound)
 	}
 
 	// Get users with this role - ใช้ fields ที่ถูกต้องตาม User model
@@ -238,7 +404,7 @@ func GetRoleUsers(c *fiber.Ctx) error {
 		Select("id, email, first_name, last_name, username").
 		Where("role_id = ?", id).
 		Find(&users).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not fetch users"))
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch users: %w", err)))
 	}
 
 	// แปลงเป็น format ที่ frontend ใช้
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/internal/services"
+	"backend/pkg/utils"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// sessionResponse แปลง session เป็น response พร้อม flag ว่าเป็น session ปัจจุบันหรือไม่
+// currentSessionID = 0 เมื่อไม่ใช่ session ของผู้เรียกเอง (current เป็น false ทั้งหมด)
+func sessionResponse(sessions []models.Session, currentSessionID uint) []fiber.Map {
+	result := make([]fiber.Map, 0, len(sessions))
+	for _, s := range sessions {
+		result = append(result, fiber.Map{
+			"id":           s.ID,
+			"device":       s.Device,
+			"ip_address":   s.IPAddress,
+			"user_agent":   s.UserAgent,
+			"login_method": s.LoginMethod,
+			"created_at":   s.CreatedAt,
+			"last_used_at": s.LastUsedAt,
+			"current":      s.ID == currentSessionID,
+		})
+	}
+	return result
+}
+
+// GetMySessions godoc
+// @Summary List active sessions
+// @Description List devices where the current user is logged in
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /auth/sessions [get]
+func GetMySessions(c *fiber.Ctx) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusUnauthorized, err)
+	}
+
+	sessions, err := services.ListActiveSessions(userID)
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch sessions: %w", err)))
+	}
+
+	return utils.SendSuccess(c, fiber.Map{
+		"sessions": sessionResponse(sessions, utils.GetSessionIDFromContext(c)),
+	}, "Sessions retrieved successfully")
+}
+
+// RevokeMySession godoc
+// @Summary Sign out a device
+// @Description Revoke one of the current user's sessions
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /auth/sessions/{id} [delete]
+func RevokeMySession(c *fiber.Ctx) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusUnauthorized, err)
+	}
+
+	sessionID, err := c.ParamsInt("id")
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrSessionIDInvalid)
+	}
+
+	session, err := services.RevokeSession(userID, uint(sessionID))
+	if err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			return utils.SendError(c, fiber.StatusNotFound, err)
+		}
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not revoke session: %w", err)))
+	}
+
+	services.CreateAuditLog(c, "SESSION_REVOKED", session.ID, "session", map[string]string{"device": session.Device, "ip_address": session.IPAddress}, userID)
+
+	return utils.SendSuccess(c, nil, utils.MsgSessionRevoked)
+}
+
+// GetUserSessions godoc
+// @Summary List sessions of a user
+// @Description List active sessions of a user (Admin only)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/users/{id}/sessions [get]
+func GetUserSessions(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var user models.User
+	if err := database.DB.First(&user, id).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrUserNotFound)
+	}
+
+	sessions, err := services.ListActiveSessions(user.ID)
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch sessions: %w", err)))
+	}
+
+	// session ปัจจุบันมีความหมายเฉพาะเมื่อ admin ดู session ของตัวเอง
+	var currentSessionID uint
+	if callerID, err := utils.GetUserIDFromContext(c); err == nil && callerID == user.ID {
+		currentSessionID = utils.GetSessionIDFromContext(c)
+	}
+
+	return utils.SendSuccess(c, fiber.Map{
+		"sessions": sessionResponse(sessions, currentSessionID),
+	}, "Sessions retrieved successfully")
+}
+
+// RevokeUserSession godoc
+// @Summary Sign out a user's device
+// @Description Revoke one session of a user (Admin only)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Param sessionId path string true "Session ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/users/{id}/sessions/{sessionId} [delete]
+func RevokeUserSession(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var user models.User
+	if err := database.DB.First(&user, id).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrUserNotFound)
+	}
+
+	sessionID, err := c.ParamsInt("sessionId")
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrSessionIDInvalid)
+	}
+
+	session, err := services.RevokeSession(user.ID, uint(sessionID))
+	if err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			return utils.SendError(c, fiber.StatusNotFound, err)
+		}
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not revoke session: %w", err)))
+	}
+
+	// Audit Log
+	services.CreateAuditLog(c, "SESSION_REVOKED", session.ID, "session", map[string]interface{}{
+		"username":   user.Username,
+		"user_id":    user.ID,
+		"device":     session.Device,
+		"ip_address": session.IPAddress,
+	})
+
+	return utils.SendSuccess(c, nil, utils.MsgSessionRevoked)
+}
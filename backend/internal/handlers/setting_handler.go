@@ -3,6 +3,7 @@ package handlers
 import (
 	"backend/internal/database"
 	"backend/internal/models"
+	"backend/pkg/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -19,7 +20,7 @@ import (
 func GetSettings(c *fiber.Ctx) error {
 	var settings []models.Setting
 	if err := database.DB.Find(&settings).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch settings"})
+		return utils.SendAppError(c, utils.ErrSettingsFetch)
 	}
 	return c.JSON(settings)
 }
@@ -36,7 +37,7 @@ func GetSettings(c *fiber.Ctx) error {
 func UpdateSettings(c *fiber.Ctx) error {
 	var input []models.Setting
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 
 	return database.DB.Transaction(func(tx *gorm.DB) error {
@@ -63,7 +64,7 @@ func UpdateSettings(c *fiber.Ctx) error {
 func GetPublicSettings(c *fiber.Ctx) error {
 	var settings []models.Setting
 	if err := database.DB.Where("is_public = ?", true).Find(&settings).Error; err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch settings"})
+		return utils.SendAppError(c, utils.ErrSettingsFetch)
 	}
 
 	// Maybe return as key-value map for easier usage on frontend?
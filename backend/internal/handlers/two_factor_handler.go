@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/internal/services"
+	"backend/pkg/utils"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ============== Two-Factor Authentication (TOTP) ==============
+
+type TwoFactorSetupInput struct {
+	Password string `json:"password" validate:"required"` // ยืนยันตัวตนก่อนสร้าง secret
+}
+
+type TwoFactorCodeInput struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TwoFactorDisableInput struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type LoginTwoFactorInput struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"` // ใช้แทน code เมื่อไม่มี authenticator
+}
+
+// GetTwoFactorStatus godoc
+// @Summary Get 2FA status
+// @Description Check whether two-factor authentication is enabled or required for the current user
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /auth/2fa/status [get]
+func GetTwoFactorStatus(c *fiber.Ctx) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusUnauthorized, err)
+	}
+
+	var user models.User
+	if err := database.DB.Preload("Role").First(&user, userID).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrUserNotFound)
+	}
+
+	return utils.SendSuccess(c, fiber.Map{
+		"enabled":                  user.TwoFactorEnabled,
+		"required_by_role":         services.TwoFactorRequiredByRole(&user),
+		"recovery_codes_remaining": len(user.RecoveryCodes),
+	}, "Two-factor status retrieved")
+}
+
+// SetupTwoFactor godoc
+// @Summary Start 2FA enrollment
+// @Description Generate a TOTP secret and otpauth URI (for QR code). 2FA is enabled only after confirming a code.
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param input body TwoFactorSetupInput true "Current password"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /auth/2fa/setup [post]
+func SetupTwoFactor(c *fiber.Ctx) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusUnauthorized, err)
+	}
+
+	var input TwoFactorSetupInput
+	if err := c.BodyParser(&input); err != nil {
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrUserNotFound)
+	}
+
+	if user.TwoFactorEnabled {
+		return utils.SendAppError(c, utils.ErrTwoFactorEnabled)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+		return utils.SendAppError(c, utils.ErrIncorrectPassword)
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not generate secret: %w", err)))
+	}
+
+	// เก็บ secret ไว้ก่อน (ยังไม่ enable) จนกว่าผู้ใช้จะยืนยัน code แรก
+	user.TwoFactorSecret = secret
+	user.TwoFactorLastStep = 0
+	if err := database.DB.Model(&user).Select("TwoFactorSecret", "TwoFactorLastStep").Updates(&user).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not save secret: %w", err)))
+	}
+
+	uri := utils.TOTPProvisioningURI(services.TOTPIssuer(), user.Email, secret)
+
+	return utils.SendSuccess(c, fiber.Map{
+		"secret":      secret,
+		"otpauth_uri": uri,
+		"qr_payload":  uri, // frontend render เป็น QR code
+	}, "Scan the QR code with your authenticator app and confirm with a code")
+}
+
+// EnableTwoFactor godoc
+// @Summary Confirm 2FA enrollment
+// @Description Verify the first TOTP code, enable 2FA and return one-time recovery codes
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param input body TwoFactorCodeInput true "TOTP code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /auth/2fa/enable [post]
+func EnableTwoFactor(c *fiber.Ctx) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusUnauthorized, err)
+	}
+
+	var input TwoFactorCodeInput
+	if err := c.BodyParser(&input); err != nil {
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrUserNotFound)
+	}
+
+	if user.TwoFactorEnabled {
+		return utils.SendAppError(c, utils.ErrTwoFactorEnabled)
+	}
+	if user.TwoFactorSecret == "" {
+		return utils.SendAppError(c, utils.ErrTwoFactorSetupNotStarted)
+	}
+
+	if err := services.VerifyTOTPCode(&user, input.Code); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	}
+
+	codes, hashes, err := services.NewRecoveryCodes()
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not generate recovery codes: %w", err)))
+	}
+
+	user.TwoFactorEnabled = true
+	user.RecoveryCodes = hashes
+	if err := database.DB.Model(&user).Select("TwoFactorEnabled", "RecoveryCodes").Updates(&user).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not enable two-factor authentication: %w", err)))
+	}
+
+	services.CreateAuditLog(c, "TWO_FACTOR_ENABLED", user.ID, "user", nil, user.ID)
+
+	return utils.SendSuccess(c, fiber.Map{
+		"recovery_codes": codes,
+	}, "Two-factor authentication enabled. Store your recovery codes in a safe place.")
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable 2FA
+// @Description Disable two-factor authentication (requires password and a current code)
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param input body TwoFactorDisableInput true "Password and TOTP code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /auth/2fa/disable [post]
+func DisableTwoFactor(c *fiber.Ctx) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusUnauthorized, err)
+	}
+
+	var input TwoFactorDisableInput
+	if err := c.BodyParser(&input); err != nil {
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	}
+
+	var user models.User
+	if err := database.DB.Preload("Role").First(&user, userID).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrUserNotFound)
+	}
+
+	if !user.TwoFactorEnabled {
+		return utils.SendAppError(c, utils.ErrTwoFactorNotEnabled)
+	}
+	if services.TwoFactorRequiredByRole(&user) {
+		return utils.SendAppError(c, utils.ErrTwoFactorRequiredByRole)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+		return utils.SendAppError(c, utils.ErrIncorrectPassword)
+	}
+	if err := services.VerifyTOTPCode(&user, input.Code); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	}
+
+	if err := clearTwoFactor(&user); err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not disable two-factor authentication: %w", err)))
+	}
+
+	services.CreateAuditLog(c, "TWO_FACTOR_DISABLED", user.ID, "user", nil, user.ID)
+
+	return utils.SendSuccess(c, nil, utils.MsgTwoFactorDisabled)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate 2FA recovery codes
+// @Description Replace all recovery codes (requires a current TOTP code)
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param input body TwoFactorCodeInput true "TOTP code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /auth/2fa/recovery-codes [post]
+func RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		return utils.SendError(c, fiber.StatusUnauthorized, err)
+	}
+
+	var input TwoFactorCodeInput
+	if err := c.BodyParser(&input); err != nil {
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrUserNotFound)
+	}
+
+	if !user.TwoFactorEnabled {
+		return utils.SendAppError(c, utils.ErrTwoFactorNotEnabled)
+	}
+	if err := services.VerifyTOTPCode(&user, input.Code); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	}
+
+	codes, hashes, err := services.NewRecoveryCodes()
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not generate recovery codes: %w", err)))
+	}
+
+	user.RecoveryCodes = hashes
+	if err := database.DB.Model(&user).Select("RecoveryCodes").Updates(&user).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not save recovery codes: %w", err)))
+	}
+
+	services.CreateAuditLog(c, "TWO_FACTOR_RECOVERY_CODES_REGENERATED", user.ID, "user", nil, user.ID)
+
+	return utils.SendSuccess(c, fiber.Map{
+		"recovery_codes": codes,
+	}, "Recovery codes regenerated")
+}
+
+// LoginTwoFactor godoc
+// @Summary Complete login with 2FA
+// @Description Exchange the challenge token from /auth/login and a TOTP (or recovery) code for access and refresh tokens
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param input body LoginTwoFactorInput true "Challenge token and code"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /auth/login/2fa [post]
+func LoginTwoFactor(c *fiber.Ctx) error {
+	var input LoginTwoFactorInput
+	if err := c.BodyParser(&input); err != nil {
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	}
+	if input.Code == "" && input.RecoveryCode == "" {
+		return utils.SendAppError(c, utils.ErrTwoFactorCodeRequired)
+	}
+
+	claims, err := utils.ParseChallengeToken(input.ChallengeToken, utils.PurposeTwoFactor)
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrChallengeInvalid)
+	}
+
+	var user models.User
+	if err := database.DB.Preload("Role.Permissions").First(&user, claims.UserID).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrChallengeInvalid)
+	}
+	if !user.Active {
+		return utils.SendAppError(c, utils.ErrUserInactive)
+	}
+	if !user.TwoFactorEnabled {
+		return utils.SendAppError(c, utils.ErrTwoFactorNotEnabled)
+	}
+	if block := services.CheckLoginAllowed(user.ID, c.IP()); block != nil {
+		return sendLoginBlocked(c, block)
+	}
+
+	usedRecovery, err := services.VerifySecondFactor(&user, input.Code, input.RecoveryCode)
+	if err != nil {
+		recordLoginFailure(c, &user, "2fa")
+		services.CreateAuditLog(c, "TWO_FACTOR_FAILED", user.ID, "user", map[string]string{"username": user.Username}, user.ID)
+		if errors.Is(err, services.ErrInvalidTwoFactorCode) {
+			return utils.SendAppError(c, utils.ErrTwoFactorCodeInvalid.WithStatus(fiber.StatusUnauthorized))
+		}
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(err))
+	}
+
+	// challenge token ใช้ login ได้ครั้งเดียว
+	if err := services.ConsumeTwoFactorChallenge(claims); err != nil {
+		if errors.Is(err, services.ErrTwoFactorChallengeUsed) {
+			return utils.SendError(c, fiber.StatusUnauthorized, err)
+		}
+		return utils.SendAppError(c, utils.ErrTokenGeneration)
+	}
+
+	if usedRecovery {
+		services.CreateAuditLog(c, "TWO_FACTOR_RECOVERY_USED", user.ID, "user", map[string]int{"recovery_codes_remaining": len(user.RecoveryCodes)}, user.ID)
+	}
+
+	return completeLogin(c, &user, claims.LoginMethod)
+}
+
+// AdminResetTwoFactor godoc
+// @Summary Reset a user's 2FA
+// @Description Disable two-factor authentication for a user who lost their device (Admin only)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/users/{id}/2fa [delete]
+func AdminResetTwoFactor(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var user models.User
+	if err := database.DB.First(&user, id).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrUserNotFound)
+	}
+
+	if err := clearTwoFactor(&user); err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not reset two-factor authentication: %w", err)))
+	}
+	// session เดิมต้อง login ใหม่ (และได้ flag ตั้งค่า 2FA ใหม่ถ้า role บังคับ)
+	if err := services.BumpTokenVersion(user.ID); err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("2FA reset but could not invalidate existing tokens: %w", err)))
+	}
+
+	// Audit Log
+	services.CreateAuditLog(c, "TWO_FACTOR_RESET", user.ID, "user", map[string]string{"username": user.Username})
+
+	return utils.SendSuccess(c, nil, "Two-factor authentication reset successfully")
+}
+
+// clearTwoFactor ล้างข้อมูล 2FA ทั้งหมดของ user
+func clearTwoFactor(user *models.User) error {
+	user.TwoFactorEnabled = false
+	user.TwoFactorSecret = ""
+	user.TwoFactorLastStep = 0
+	user.RecoveryCodes = nil
+	return database.DB.Model(user).
+		Select("TwoFactorEnabled", "TwoFactorSecret", "TwoFactorLastStep", "RecoveryCodes").
+		Updates(user).Error
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"backend/internal/services"
+	"backend/pkg/utils"
 	"errors"
 	"path/filepath"
 	"strings"
@@ -51,36 +52,24 @@ const maxFileSize = 10 * 1024 * 1024
 // @Router /api/upload/image [post]
 func UploadImage(c *fiber.Ctx) error {
 	if r2Service == nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Upload service not configured",
-		})
+		return utils.SendAppError(c, utils.ErrUploadUnavailable)
 	}
 
 	// Get file from form
 	file, err := c.FormFile("image")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "No image file provided",
-		})
+		return utils.SendAppError(c, utils.ErrUploadFileRequired)
 	}
 
 	// Check file size
 	if file.Size > maxFileSize {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "File size exceeds 10MB limit",
-		})
+		return utils.SendAppError(c, utils.ErrUploadTooLarge)
 	}
 
 	// Check file extension
 	ext := strings.ToLower(filepath.Ext(file.Filename))
 	if !allowedExtensions[ext] {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "Invalid file type. Allowed: jpg, jpeg, png, gif, webp",
-		})
+		return utils.SendAppError(c, utils.ErrUploadTypeInvalid)
 	}
 
 	// Get folder from form (default: projects)
@@ -89,27 +78,17 @@ func UploadImage(c *fiber.Ctx) error {
 	// Open file
 	f, err := file.Open()
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to open file",
-		})
+		return utils.SendAppError(c, utils.ErrUploadFailed.Wrap(err))
 	}
 	defer f.Close()
 
 	// Upload to R2
 	result, err := r2Service.UploadImage(f, file.Filename, folder)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to upload image: " + err.Error(),
-		})
+		return utils.SendAppError(c, utils.ErrUploadFailed.Wrap(err))
 	}
 
-	return c.JSON(fiber.Map{
-		"success": true,
-		"message": "Image uploaded successfully",
-		"data":    result,
-	})
+	return utils.SendSuccess(c, result, "Image uploaded successfully")
 }
 
 // DeleteImage handles image deletion from R2
@@ -126,31 +105,19 @@ func UploadImage(c *fiber.Ctx) error {
 // @Router /api/upload/image/{key} [delete]
 func DeleteImage(c *fiber.Ctx) error {
 	if r2Service == nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Upload service not configured",
-		})
+		return utils.SendAppError(c, utils.ErrUploadUnavailable)
 	}
 
 	key := c.Params("*")
 	if key == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "Image key is required",
-		})
+		return utils.SendAppError(c, utils.ErrImageKeyRequired)
 	}
 
 	if err := r2Service.DeleteImage(key); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to delete image: " + err.Error(),
-		})
+		return utils.SendAppError(c, utils.ErrImageDeleteFailed.Wrap(err))
 	}
 
-	return c.JSON(fiber.Map{
-		"success": true,
-		"message": "Image deleted successfully",
-	})
+	return utils.SendSuccess(c, nil, "Image deleted successfully")
 }
 
 // DeleteImages handles multiple image deletion from R2
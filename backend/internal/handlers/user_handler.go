@@ -4,11 +4,12 @@ import (
 	"backend/internal/database"
 	"backend/internal/models"
 	"backend/internal/services"
+	"backend/pkg/i18n"
 	"backend/pkg/utils"
-	"errors"
+	"fmt"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // Helper function to get role ID by name (temporary until frontend sends ID)
@@ -27,6 +28,9 @@ type UpdateProfileInput struct {
 	Address   string `json:"address"`
 	LineID    string `json:"line_id"`
 	Info      string `json:"info"`
+
+	// Locale ภาษาที่ต้องการ (th / en, "" = ตาม browser) - ไม่ส่งมา = ไม่เปลี่ยน
+	Locale *string `json:"locale"`
 }
 
 // GetProfile godoc
@@ -42,21 +46,25 @@ type UpdateProfileInput struct {
 func GetProfile(c *fiber.Ctx) error {
 	userID, err := utils.GetUserIDFromContext(c)
 	if err != nil {
-		return utils.SendError(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+		return utils.SendAppError(c, utils.ErrUnauthorized)
 	}
 
 	var user models.User
 	if err := database.DB.Preload("Role.Permissions").First(&user, userID).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("user not found"))
+		return utils.SendAppError(c, utils.ErrUserNotFound)
 	}
 
-	// Flatten permissions
-	permissions := []string{}
-	for _, p := range user.Role.Permissions {
-		permissions = append(permissions, p.Slug)
+	// Flatten permissions (รวมที่สืบทอดจาก parent role)
+	permissions := services.PermissionSlugs(&user)
+
+	// แสดงแถบ "กำลังดูในมุมมองของ user" ที่ frontend
+	var impersonatorID *uint
+	if id := utils.GetImpersonatorIDFromContext(c); id != 0 {
+		impersonatorID = &id
 	}
 
 	return utils.SendSuccess(c, fiber.Map{
+		"impersonator_id": impersonatorID,
 		"user": fiber.Map{
 			"id":          user.ID,
 			"username":    user.Username,
@@ -68,9 +76,10 @@ func GetProfile(c *fiber.Ctx) error {
 			"address":     user.Address,
 			"line_id":     user.LineID,
 			"info":        user.Info,
+			"locale":      user.Locale,
 			"permissions": permissions,
 		},
-	}, "Profile retrieved successfully")
+	}, utils.MsgProfileRetrieved)
 }
 
 // UpdateProfile godoc
@@ -89,17 +98,17 @@ func GetProfile(c *fiber.Ctx) error {
 func UpdateProfile(c *fiber.Ctx) error {
 	userID, err := utils.GetUserIDFromContext(c)
 	if err != nil {
-		return utils.SendError(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+		return utils.SendAppError(c, utils.ErrUnauthorized)
 	}
 
 	var input UpdateProfileInput
 	if err := c.BodyParser(&input); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 
 	var user models.User
 	if err := database.DB.First(&user, userID).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("user not found"))
+		return utils.SendAppError(c, utils.ErrUserNotFound)
 	}
 
 	// Update fields
@@ -109,9 +118,20 @@ func UpdateProfile(c *fiber.Ctx) error {
 	user.Address = input.Address
 	user.LineID = input.LineID
 	user.Info = input.Info
+	if input.Locale != nil {
+		if *input.Locale != "" && !i18n.IsSupported(*input.Locale) {
+			return utils.SendAppError(c, utils.ErrLocaleUnsupported.WithDetails(i18n.Supported()))
+		}
+		user.Locale = *input.Locale
+	}
 
 	if err := database.DB.Save(&user).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not update profile"))
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not update profile: %w", err)))
+	}
+
+	// access token เดิมยังถือภาษาเก่าจนกว่าจะ refresh - response นี้ใช้ภาษาใหม่เลย
+	if input.Locale != nil {
+		c.Locals("locale", user.Locale)
 	}
 
 	return utils.SendSuccess(c, fiber.Map{
@@ -126,8 +146,9 @@ func UpdateProfile(c *fiber.Ctx) error {
 			"address":    user.Address,
 			"line_id":    user.LineID,
 			"info":       user.Info,
+			"locale":     user.Locale,
 		},
-	}, "Profile updated successfully")
+	}, utils.MsgProfileUpdated)
 }
 
 // GetAllUsers godoc
@@ -151,11 +172,11 @@ func GetAllUsers(c *fiber.Ctx) error {
 	var total int64
 
 	if err := database.DB.Model(&models.User{}).Count(&total).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not count users"))
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not count users: %w", err)))
 	}
 
 	if err := database.DB.Preload("Role").Offset(offset).Limit(limit).Find(&users).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not fetch users"))
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch users: %w", err)))
 	}
 
 	totalPages := int((total + int64(limit) - 1) / int64(limit))
@@ -186,7 +207,7 @@ func GetUserByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var user models.User
 	if err := database.DB.Preload("Role").First(&user, id).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("user not found"))
+		return utils.SendAppError(c, utils.ErrUserNotFound)
 	}
 
 	return utils.SendSuccess(c, fiber.Map{
@@ -224,18 +245,20 @@ type CreateUserInput struct {
 func CreateUser(c *fiber.Ctx) error {
 	var input CreateUserInput
 	if err := c.BodyParser(&input); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
 	}
 
 	// Check if user exists (username or email)
 	var existingUser models.User
 	if err := database.DB.Where("username = ? OR email = ?", input.Username, input.Email).First(&existingUser).Error; err == nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("username or email already exists"))
+		return utils.SendAppError(c, utils.ErrUsernameOrEmailTaken)
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
-	if err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not hash password"))
+	if err := services.ValidateNewPassword(nil, input.Password); err != nil {
+		return sendPasswordPolicyError(c, err)
 	}
 
 	// Get Role ID
@@ -250,7 +273,6 @@ func CreateUser(c *fiber.Ctx) error {
 
 	user := models.User{
 		Username:  input.Username,
-		Password:  string(hashedPassword),
 		Email:     input.Email,
 		FirstName: input.FirstName,
 		LastName:  input.LastName,
@@ -262,8 +284,18 @@ func CreateUser(c *fiber.Ctx) error {
 		Info:      input.Info,
 	}
 
+	// user ที่ admin สร้างให้ถือว่ายืนยันอีเมลแล้ว
+	now := time.Now()
+	user.EmailVerified = true
+	user.EmailVerifiedAt = &now
+
+	// รหัสผ่านที่ admin ตั้งให้ - ผู้ใช้ต้องเปลี่ยนเองเมื่อ login ครั้งแรก
+	if err := services.SetUserPassword(database.DB, &user, input.Password, true); err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not hash password: %w", err)))
+	}
+
 	if err := database.DB.Create(&user).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not create user"))
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not create user: %w", err)))
 	}
 
 	// Audit Log
@@ -304,14 +336,17 @@ func UpdateUserAdmin(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var input UpdateUserAdminInput
 	if err := c.BodyParser(&input); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
 	}
 
 	var user models.User
 	if err := database.DB.First(&user, id).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("user not found"))
+		return utils.SendAppError(c, utils.ErrUserNotFound)
 	}
 
+	previousRoleID := user.RoleID
+	previousActive := user.Active
+
 	user.FirstName = input.FirstName
 	user.LastName = input.LastName
 
@@ -329,7 +364,16 @@ func UpdateUserAdmin(c *fiber.Ctx) error {
 	user.Info = input.Info
 
 	if err := database.DB.Save(&user).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("could not update user"))
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not update user: %w", err)))
+	}
+
+	// role หรือสถานะเปลี่ยน - token เดิมต้อง refresh ไม่ได้
+	roleChanged := (previousRoleID == nil) != (user.RoleID == nil) ||
+		(previousRoleID != nil && user.RoleID != nil && *previousRoleID != *user.RoleID)
+	if roleChanged || previousActive != user.Active {
+		if err := services.BumpTokenVersion(user.ID); err != nil {
+			return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("user updated but could not invalidate existing tokens: %w", err)))
+		}
 	}
 
 	// Audit Log
@@ -356,18 +400,18 @@ func DeleteUser(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var user models.User // Fetch the user first to ensure it exists
 	if err := database.DB.First(&user, id).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("user not found"))
+		return utils.SendAppError(c, utils.ErrUserNotFound)
 	}
 
 	// Delete user
 	if err := database.DB.Delete(&user).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("failed to delete user"))
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("failed to delete user: %w", err)))
 	}
 
 	// Audit Log
 	services.CreateAuditLog(c, "USER_DELETE", user.ID, "user", map[string]string{"username": user.Username})
 
-	return utils.SendSuccess(c, nil, "User deleted successfully")
+	return utils.SendSuccess(c, nil, utils.MsgUserDeleted)
 }
 
 // AdminResetPassword allows admins to reset a user's password
@@ -395,27 +439,55 @@ func AdminResetPassword(c *fiber.Ctx) error {
 	var input ResetPasswordInput
 
 	if err := c.BodyParser(&input); err != nil {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("invalid input"))
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, id).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrUserNotFound)
+	}
+
+	if err := services.ValidateNewPassword(&user, input.NewPassword); err != nil {
+		return sendPasswordPolicyError(c, err)
 	}
 
-	if len(input.NewPassword) < 6 {
-		return utils.SendError(c, fiber.StatusBadRequest, errors.New("password must be at least 6 characters"))
+	// ผู้ใช้ต้องเปลี่ยนรหัสผ่านที่ admin ตั้งให้เมื่อ login ครั้งถัดไป
+	if err := services.SetUserPassword(database.DB, &user, input.NewPassword, true); err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("failed to update password: %w", err)))
 	}
 
+	services.CreateAuditLog(c, "PASSWORD_RESET_BY_ADMIN", user.ID, "user", map[string]string{"username": user.Username})
+
+	return utils.SendSuccess(c, nil, utils.MsgPasswordReset)
+}
+
+// AdminLogoutAll godoc
+// @Summary Revoke all sessions of a user
+// @Description Revoke every refresh token of a user, forcing them to login again (Admin only)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/users/{id}/logout-all [post]
+func AdminLogoutAll(c *fiber.Ctx) error {
+	id := c.Params("id")
 	var user models.User
 	if err := database.DB.First(&user, id).Error; err != nil {
-		return utils.SendError(c, fiber.StatusNotFound, errors.New("user not found"))
+		return utils.SendAppError(c, utils.ErrUserNotFound)
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	revoked, err := services.RevokeAllSessions(user.ID)
 	if err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("failed to hash password"))
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not revoke sessions: %w", err)))
 	}
 
-	user.Password = string(hashedPassword)
-	if err := database.DB.Save(&user).Error; err != nil {
-		return utils.SendError(c, fiber.StatusInternalServerError, errors.New("failed to update password"))
-	}
+	// Audit Log
+	services.CreateAuditLog(c, "USER_SESSIONS_REVOKED", user.ID, "user", map[string]interface{}{"username": user.Username, "revoked_sessions": revoked})
 
-	return utils.SendSuccess(c, nil, "Password reset successfully")
+	return utils.SendSuccess(c, fiber.Map{
+		"revoked_sessions": revoked,
+	}, "User sessions revoked successfully")
 }
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/internal/services"
+	"backend/pkg/utils"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GrantRoleInput role เพิ่มเติมที่จะให้ user (valid_from / valid_until ไม่ระบุ = มีผลทันที / ถาวร)
+type GrantRoleInput struct {
+	RoleID     uint       `json:"role_id" validate:"required"`
+	ValidFrom  *time.Time `json:"valid_from"`
+	ValidUntil *time.Time `json:"valid_until"`
+	Reason     string     `json:"reason"`
+}
+
+// GetUserRoles godoc
+// @Summary Get roles of a user
+// @Description Get the primary role and all additional role grants (including expired and revoked) of a user
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/users/{id}/roles [get]
+func GetUserRoles(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var user models.User
+	if err := database.DB.Preload("Role").First(&user, id).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrUserNotFound)
+	}
+
+	grants, err := services.ListRoleGrants(user.ID)
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not fetch role grants: %w", err)))
+	}
+
+	now := time.Now()
+	result := make([]fiber.Map, 0, len(grants))
+	for _, grant := range grants {
+		result = append(result, fiber.Map{
+			"id":            grant.ID,
+			"role_id":       grant.RoleID,
+			"role":          grant.Role.Name,
+			"valid_from":    grant.ValidFrom,
+			"valid_until":   grant.ValidUntil,
+			"reason":        grant.Reason,
+			"granted_by_id": grant.GrantedByID,
+			"revoked_at":    grant.RevokedAt,
+			"revoke_reason": grant.RevokeReason,
+			"active":        grant.ActiveAt(now),
+			"created_at":    grant.CreatedAt,
+		})
+	}
+
+	var primary interface{}
+	if user.RoleID != nil {
+		primary = fiber.Map{"id": user.Role.ID, "name": user.Role.Name}
+	}
+
+	return utils.SendSuccess(c, fiber.Map{
+		"primary_role": primary,
+		"grants":       result,
+	}, "User roles retrieved successfully")
+}
+
+// GrantUserRole godoc
+// @Summary Grant an additional role to a user
+// @Description Grant a role on top of the user's primary role, optionally limited to a time window
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param input body GrantRoleInput true "Role grant"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/users/{id}/roles [post]
+func GrantUserRole(c *fiber.Ctx) error {
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrUnauthorized)
+	}
+
+	id := c.Params("id")
+	var user models.User
+	if err := database.DB.First(&user, id).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrUserNotFound)
+	}
+
+	input := new(GrantRoleInput)
+	if err := c.BodyParser(input); err != nil {
+		return utils.SendAppError(c, utils.ErrInvalidInput)
+	}
+	if err := utils.ValidateStruct(input); err != nil {
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	}
+
+	grant, err := services.GrantRole(claims, user.ID, input.RoleID, input.ValidFrom, input.ValidUntil, input.Reason)
+	var appErr *utils.AppError
+	if errors.Is(err, services.ErrRoleGrantEscalation) && errors.As(err, &appErr) {
+		// Audit Log - บันทึกความพยายามให้ role ที่สิทธิ์สูงกว่าตัวเอง
+		services.CreateAuditLog(c, "ROLE_GRANT_DENIED", user.ID, "user", map[string]interface{}{
+			"username":            user.Username,
+			"role_id":             input.RoleID,
+			"missing_permissions": appErr.Details,
+		})
+		return utils.SendAppError(c, appErr)
+	}
+	switch {
+	case errors.Is(err, services.ErrRoleGrantInvalidWindow):
+		return utils.SendError(c, fiber.StatusBadRequest, err)
+	case errors.Is(err, services.ErrRoleNotFound):
+		return utils.SendAppError(c, utils.ErrRoleNotFound.WithStatus(fiber.StatusBadRequest))
+	case errors.Is(err, services.ErrRoleGrantDuplicate):
+		return utils.SendError(c, fiber.StatusConflict, err)
+	case err != nil:
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not grant role: %w", err)))
+	}
+
+	// Audit Log
+	services.CreateAuditLog(c, "ROLE_GRANTED", user.ID, "user", map[string]interface{}{
+		"username":    user.Username,
+		"grant_id":    grant.ID,
+		"role_id":     grant.RoleID,
+		"role":        grant.Role.Name,
+		"valid_from":  grant.ValidFrom,
+		"valid_until": grant.ValidUntil,
+		"reason":      grant.Reason,
+	})
+
+	return utils.SendCreated(c, grant, "Role granted successfully")
+}
+
+// RevokeUserRole godoc
+// @Summary Revoke an additional role grant
+// @Description Revoke a role grant before it expires; the user has to login again
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Param grantId path string true "Role grant ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/users/{id}/roles/{grantId} [delete]
+func RevokeUserRole(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var user models.User
+	if err := database.DB.First(&user, id).Error; err != nil {
+		return utils.SendAppError(c, utils.ErrUserNotFound)
+	}
+
+	grantID, err := c.ParamsInt("grantId")
+	if err != nil {
+		return utils.SendAppError(c, utils.ErrGrantIDInvalid)
+	}
+
+	grant, err := services.RevokeRoleGrant(user.ID, uint(grantID))
+	if err != nil {
+		if errors.Is(err, services.ErrRoleGrantNotFound) {
+			return utils.SendError(c, fiber.StatusNotFound, err)
+		}
+		return utils.SendAppError(c, utils.ErrInternalServer.Wrap(fmt.Errorf("could not revoke role grant: %w", err)))
+	}
+
+	// Audit Log
+	services.CreateAuditLog(c, "ROLE_GRANT_REVOKED", grant.UserID, "user", map[string]interface{}{
+		"username": user.Username,
+		"grant_id": grant.ID,
+		"role_id":  grant.RoleID,
+		"role":     grant.Role.Name,
+	})
+
+	return utils.SendSuccess(c, nil, "Role grant revoked successfully")
+}
@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"backend/internal/services"
 	"backend/pkg/utils"
+	"strconv"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
@@ -11,29 +13,46 @@ func Protected() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Missing authorization header",
-			})
+			return utils.SendAppError(c, utils.ErrAuthHeaderMissing)
 		}
 
 		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenStr == authHeader {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Invalid token format",
-			})
+			return utils.SendAppError(c, utils.ErrTokenFormatInvalid)
 		}
 
 		claims, err := utils.ParseToken(tokenStr)
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Invalid or expired token",
-			})
+			return utils.SendAppError(c, utils.ErrAccessTokenInvalid)
+		}
+
+		// ภาษาที่ user ตั้งไว้มาก่อน Accept-Language
+		if claims.Locale != "" {
+			c.Locals("locale", claims.Locale)
+		}
+
+		// access token ต้องผูกกับ session ที่ยังไม่ถูก revoke (logout / sign-out remote)
+		// ตอน impersonate session เป็นของ admin ที่ impersonate
+		sessionOwner := claims.UserID
+		if claims.IsImpersonation() {
+			sessionOwner = claims.ImpersonatorID
+		}
+		if !services.IsSessionActive(claims.SessionID, sessionOwner) {
+			return utils.SendAppError(c, utils.ErrSessionRevoked)
+		}
+
+		// รหัสผ่านหมดอายุ - ใช้ได้เฉพาะ endpoint สำหรับเปลี่ยนรหัสผ่าน
+		if claims.PasswordChangeRequired && !passwordChangeAllowed(c) {
+			return sendPasswordChangeRequired(c)
 		}
 
 		c.Locals("userID", claims.UserID)
+		c.Locals("sessionID", claims.SessionID)
+		c.Locals("claims", claims)
+		if claims.IsImpersonation() {
+			c.Locals("impersonatorID", claims.ImpersonatorID)
+			c.Set("X-Impersonated-By", strconv.FormatUint(uint64(claims.ImpersonatorID), 10))
+		}
 		return c.Next()
 	}
 }
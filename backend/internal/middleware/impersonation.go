@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"backend/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NoImpersonation ปิด endpoint ที่อ่อนไหว (รหัสผ่าน, PIN, 2FA, session) ระหว่าง admin impersonate user
+// ⚠️ ต้องวางหลัง Protected()
+func NoImpersonation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if utils.GetImpersonatorIDFromContext(c) != 0 {
+			return utils.SendAppError(c, utils.ErrImpersonationForbidden)
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"backend/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// passwordChangeAllowedRoutes endpoints ที่ใช้ได้ระหว่างรหัสผ่านหมดอายุ/ถูกบังคับเปลี่ยน
+var passwordChangeAllowedRoutes = map[string]bool{
+	fiber.MethodPut + " /api/auth/change-password": true,
+	fiber.MethodGet + " /api/auth/profile":         true,
+	fiber.MethodPost + " /api/auth/logout-all":     true,
+}
+
+func passwordChangeAllowed(c *fiber.Ctx) bool {
+	return passwordChangeAllowedRoutes[c.Method()+" "+c.Path()]
+}
+
+func sendPasswordChangeRequired(c *fiber.Ctx) error {
+	return utils.SendAppError(c, utils.ErrPasswordChangeRequired)
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/pkg/utils"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// slug ทั้งหมดที่ route อ้างถึง - ใช้ตรวจตอน start ว่ามีอยู่จริงในตาราง permissions
+var (
+	routePermissionsMu sync.Mutex
+	routePermissions   = map[string]bool{}
+)
+
+func registerRoutePermissions(slugs []string) {
+	routePermissionsMu.Lock()
+	defer routePermissionsMu.Unlock()
+	for _, slug := range slugs {
+		routePermissions[slug] = true
+	}
+}
+
+// RequirePermission อนุญาตเมื่อ user มี permission อย่างน้อยหนึ่งตัวจาก slugs (any)
+// ต้องใช้หลัง Protected
+func RequirePermission(slugs ...string) fiber.Handler {
+	return permissionGate(slugs, false)
+}
+
+// RequireAllPermissions อนุญาตเมื่อ user มีครบทุก permission ใน slugs (all)
+// ต้องใช้หลัง Protected
+func RequireAllPermissions(slugs ...string) fiber.Handler {
+	return permissionGate(slugs, true)
+}
+
+func permissionGate(slugs []string, requireAll bool) fiber.Handler {
+	if len(slugs) == 0 {
+		panic("middleware: permission gate requires at least one slug")
+	}
+	registerRoutePermissions(slugs)
+
+	return func(c *fiber.Ctx) error {
+		claims, err := utils.GetClaimsFromContext(c)
+		if err != nil {
+			return utils.SendError(c, fiber.StatusUnauthorized, err)
+		}
+
+		if !hasPermissions(claims, slugs, requireAll) {
+			return utils.SendAppError(c, utils.ErrForbidden)
+		}
+
+		if claims.EmailUnverified {
+			return sendEmailNotVerified(c)
+		}
+
+		// role ที่บังคับ 2FA - ต้องเปิด 2FA ก่อนจึงจะใช้งานส่วนที่ต้องมีสิทธิ์ได้
+		// ตรวจ DB ซ้ำเฉพาะกรณีนี้ เพราะ user อาจเพิ่งเปิด 2FA หลังได้ token มา
+		if claims.TwoFactorSetupRequired && !twoFactorEnabled(claims.UserID) {
+			return utils.SendAppError(c, utils.ErrTwoFactorSetupRequired)
+		}
+
+		return c.Next()
+	}
+}
+
+func hasPermissions(claims *utils.Claims, slugs []string, requireAll bool) bool {
+	for _, slug := range slugs {
+		has := claims.HasPermission(slug)
+		if requireAll && !has {
+			return false
+		}
+		if !requireAll && has {
+			return true
+		}
+	}
+	return requireAll
+}
+
+// VerifyRoutePermissions ตรวจว่าทุก slug ที่ route อ้างถึงมีอยู่ในตาราง permissions
+// เรียกหลัง SetupRoutes - slug ที่พิมพ์ผิดจะทำให้ route นั้นไม่มีใครเข้าได้เลย
+func VerifyRoutePermissions() error {
+	routePermissionsMu.Lock()
+	slugs := make([]string, 0, len(routePermissions))
+	for slug := range routePermissions {
+		slugs = append(slugs, slug)
+	}
+	routePermissionsMu.Unlock()
+	if len(slugs) == 0 {
+		return nil
+	}
+
+	var existing []string
+	if err := database.DB.Model(&models.Permission{}).Where("slug IN ?", slugs).Pluck("slug", &existing).Error; err != nil {
+		return fmt.Errorf("could not load permissions: %w", err)
+	}
+	found := make(map[string]bool, len(existing))
+	for _, slug := range existing {
+		found[slug] = true
+	}
+
+	var missing []string
+	for _, slug := range slugs {
+		if !found[slug] {
+			missing = append(missing, slug)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("routes reference unknown permission slugs: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
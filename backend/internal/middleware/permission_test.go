@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"backend/pkg/utils"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func permissionTestApp(claims *utils.Claims, gate fiber.Handler) *fiber.App {
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Locals("claims", claims)
+		return c.Next()
+	}, gate, func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+func TestRequirePermission(t *testing.T) {
+	claims := &utils.Claims{Permissions: []string{"users.view", "leaves.view"}}
+
+	tests := []struct {
+		name string
+		gate fiber.Handler
+		want int
+	}{
+		{"any - one match", RequirePermission("users.manage", "users.view"), fiber.StatusOK},
+		{"any - no match", RequirePermission("users.manage"), fiber.StatusForbidden},
+		{"all - every match", RequireAllPermissions("users.view", "leaves.view"), fiber.StatusOK},
+		{"all - one missing", RequireAllPermissions("users.view", "leaves.manage"), fiber.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := permissionTestApp(claims, tt.gate).Test(httptest.NewRequest("GET", "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+
+	if !routePermissions["leaves.manage"] || !routePermissions["users.manage"] {
+		t.Error("expected gate slugs to be registered for the startup check")
+	}
+}
+
+func TestRequirePermissionUnverifiedEmail(t *testing.T) {
+	claims := &utils.Claims{Permissions: []string{"users.view"}, EmailUnverified: true}
+	resp, err := permissionTestApp(claims, RequirePermission("users.view")).Test(httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusForbidden {
+		t.Errorf("status = %d, want %d", resp.StatusCode, fiber.StatusForbidden)
+	}
+}
+
+func TestNoImpersonation(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		impersonatorID uint
+		want           int
+	}{
+		{"own token", 0, fiber.StatusOK},
+		{"impersonating", 1, fiber.StatusForbidden},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				if tt.impersonatorID != 0 {
+					c.Locals("impersonatorID", tt.impersonatorID)
+				}
+				return c.Next()
+			}, NoImpersonation(), func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
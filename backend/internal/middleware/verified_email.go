@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"backend/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireVerifiedEmail ปฏิเสธ user ที่ยังไม่ยืนยันอีเมล (email_verification_mode = restrict)
+// ต้องใช้หลัง Protected
+func RequireVerifiedEmail() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, err := utils.GetClaimsFromContext(c)
+		if err != nil {
+			return utils.SendError(c, fiber.StatusUnauthorized, err)
+		}
+		if claims.EmailUnverified {
+			return sendEmailNotVerified(c)
+		}
+		return c.Next()
+	}
+}
+
+func sendEmailNotVerified(c *fiber.Ctx) error {
+	return utils.SendAppError(c, utils.ErrEmailNotVerified)
+}
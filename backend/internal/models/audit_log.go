@@ -17,4 +17,7 @@ type AuditLog struct {
 	UserAgent  string         `json:"user_agent"`
 	CreatedAt  time.Time      `json:"created_at"`
 	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// ImpersonatorID admin ที่ทำรายการนี้ในมุมมองของ UserID (ว่าง = ทำเอง)
+	ImpersonatorID *uint `gorm:"index" json:"impersonator_id,omitempty"`
 }
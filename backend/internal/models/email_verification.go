@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// EmailVerification เก็บ token สำหรับยืนยันอีเมลหลังสมัครสมาชิก
+// เก็บเป็น SHA-256 hash เหมือน refresh token (ไม่เก็บ token จริง)
+type EmailVerification struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	TokenHash string    `json:"-" gorm:"size:64;unique;not null"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Used      bool      `json:"used" gorm:"default:false"`
+	CreatedAt time.Time `json:"created_at"`
+}
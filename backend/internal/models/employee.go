@@ -27,4 +27,9 @@ type Employee struct {
 	CreatedAt  time.Time      `json:"created_at"`
 	UpdatedAt  time.Time      `json:"updated_at"`
 	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	// โครงสร้างองค์กร - ใช้กับ permission scope (ดู RolePermissionScope)
+	// Department (ชื่อ) ยังเก็บไว้เพื่อแสดงผล และ sync จาก DepartmentID
+	DepartmentID *uint `json:"department_id" gorm:"index"`
+	ManagerID    *uint `json:"manager_id" gorm:"index"` // Employee.ID ของหัวหน้าโดยตรง
 }
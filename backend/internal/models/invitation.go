@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Invitation link เชิญสมัครสมาชิกที่ admin สร้าง (ใช้ได้ครั้งเดียว)
+// token เก็บเป็น SHA-256 hash - ถ้าระบุ Email ไว้ ต้องสมัครด้วย email นั้นเท่านั้น
+type Invitation struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	TokenHash   string     `json:"-" gorm:"size:64;unique;not null"`
+	Email       string     `json:"email"`
+	RoleID      *uint      `json:"role_id"`
+	Role        Role       `json:"role" gorm:"foreignKey:RoleID"`
+	CreatedByID uint       `json:"created_by_id"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	UsedAt      *time.Time `json:"used_at"`
+	UsedByID    *uint      `json:"used_by_id"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// LoginThrottle นับจำนวน login ที่ล้มเหลวต่อ key (user หรือ IP)
+// ใช้คำนวณ backoff / lockout ของ password, PIN และ 2FA login
+type LoginThrottle struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	Key           string     `json:"key" gorm:"size:150;unique;not null"` // e.g. "user:12", "ip:10.0.0.1"
+	Failures      int        `json:"failures" gorm:"default:0"`
+	PINFailures   int        `json:"pin_failures" gorm:"default:0"` // นับเฉพาะ PIN ที่ผิดติดกัน
+	LastFailureAt *time.Time `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// MagicLink link login แบบไม่ใช้รหัสผ่าน (ใช้ได้ครั้งเดียว อายุสั้น)
+// token เก็บเป็น SHA-256 hash
+type MagicLink struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	Email     string     `json:"email" gorm:"index"` // ใช้นับ rate limit ต่อ email
+	TokenHash string     `json:"-" gorm:"size:64;unique;not null"`
+	IPAddress string     `json:"ip_address"` // IP ที่ขอ link
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
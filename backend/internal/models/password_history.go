@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// PasswordHistory เก็บ bcrypt hash ของรหัสผ่านเก่า เพื่อกันการใช้รหัสผ่านซ้ำ (password_history_depth)
+type PasswordHistory struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"not null;index"`
+	PasswordHash string    `json:"-" gorm:"not null"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Permission scope types
+const (
+	ScopeDepartment    = "department"     // แผนกที่ระบุ (DepartmentID)
+	ScopeOwnDepartment = "own_department" // แผนกเดียวกับผู้ใช้
+	ScopeReports       = "reports"        // ลูกทีมตามสายบังคับบัญชา (ทั้งทางตรงและทางอ้อม)
+)
+
+// RolePermissionScope จำกัด permission ของ role ให้ใช้ได้เฉพาะกลุ่มพนักงาน
+// role ที่มี permission แต่ไม่มี scope ของ permission นั้น = ใช้ได้กับพนักงานทุกคน
+// มีหลาย scope = ใช้ได้กับพนักงานที่อยู่ใน scope ใดก็ได้ (union)
+type RolePermissionScope struct {
+	ID           uint        `json:"id" gorm:"primaryKey"`
+	RoleID       uint        `json:"role_id" gorm:"not null;index"`
+	PermissionID uint        `json:"permission_id" gorm:"not null;index"`
+	Permission   Permission  `json:"permission" gorm:"foreignKey:PermissionID"`
+	ScopeType    string      `json:"scope_type" gorm:"size:20;not null"`
+	DepartmentID *uint       `json:"department_id"`
+	Department   *Department `json:"department,omitempty" gorm:"foreignKey:DepartmentID"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
@@ -4,9 +4,14 @@ import (
 	"time"
 )
 
+// ProjectSearchDocument ข้อความที่ใช้ค้นหา project (full-text และ trigram)
+// ต้องตรงกับ expression ของ index ค้นหาใน database.ConnectDB ไม่งั้น PostgreSQL ไม่ใช้ index
+const ProjectSearchDocument = `coalesce(title, '') || ' ' || coalesce(description, '') || ' ' || coalesce(location, '')`
+
 type Project struct {
 	ID              uint      `json:"id" gorm:"primaryKey"`
 	Title           string    `json:"title"`
+	Slug            string    `json:"slug" gorm:"size:100;uniqueIndex"` // URL สาธารณะ - แก้ได้ แต่ slug เดิมยังใช้ได้ผ่าน ProjectSlugHistory
 	Location        string    `json:"location"`
 	LocationMapLink string    `json:"location_map_link"`
 	Owner           string    `json:"owner"`
@@ -20,4 +25,68 @@ type Project struct {
 	UpdatedBy       string    `json:"updated_by"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
+
+	// หมวดหมู่ - Category (ชื่อ) ยังเก็บไว้เพื่อแสดงผล และ sync จาก CategoryID
+	// CategoryRef มีไว้ให้ migrate สร้าง foreign key (ลบหมวดหมู่ที่ยังมี project ไม่ได้) - ไม่ preload / ไม่ส่งออกใน JSON
+	CategoryID  *uint     `json:"category_id" gorm:"index"`
+	CategoryRef *Category `json:"-" gorm:"foreignKey:CategoryID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+
+	// การเผยแพร่ - หน้าเว็บสาธารณะเห็นเฉพาะ published ที่ถึง PublishAt แล้ว (ดู IsPublic)
+	PublishStatus string     `json:"publish_status" gorm:"size:20;default:'draft';index"`
+	PublishAt     *time.Time `json:"publish_at" gorm:"index"` // เวลาเริ่มแสดง (อนาคต = ตั้งเวลาเผยแพร่)
+	PublishedAt   *time.Time `json:"published_at"`            // เวลาที่ขึ้นหน้าเว็บจริง (scheduler ตั้งให้เมื่อถึงเวลา)
+}
+
+// สถานะการเผยแพร่ของ project
+const (
+	ProjectDraft     = "draft"
+	ProjectInReview  = "in_review"
+	ProjectPublished = "published"
+	ProjectArchived  = "archived"
+)
+
+// IsValidProjectStatus ตรวจว่าเป็นสถานะการเผยแพร่ที่รองรับ
+func IsValidProjectStatus(status string) bool {
+	switch status {
+	case ProjectDraft, ProjectInReview, ProjectPublished, ProjectArchived:
+		return true
+	}
+	return false
+}
+
+// ProjectEditNeedsPublish การแก้ไขเนื้อหาที่ต้องมีสิทธิ์ projects.publish
+// project ที่เผยแพร่ / เก็บถาวรแล้ว การแก้ไขมีผลกับหน้าเว็บทันทีโดยไม่ผ่านการตรวจ - เหมือนการเผยแพร่ใหม่
+func ProjectEditNeedsPublish(status string) bool {
+	return status == ProjectPublished || status == ProjectArchived
+}
+
+// ProjectStatusNeedsPublish การเปลี่ยนสถานะที่ต้องมีสิทธิ์ projects.publish
+// คือเผยแพร่ / เก็บถาวร หรือดึง project ออกจากสองสถานะนั้น - ส่วน draft <-> in_review ผู้แก้ไขทำเองได้
+func ProjectStatusNeedsPublish(from, to string) bool {
+	return to == ProjectPublished || to == ProjectArchived || from == ProjectPublished || from == ProjectArchived
+}
+
+// IsPublic project แสดงบนหน้าเว็บสาธารณะได้ ณ เวลา now
+func (p *Project) IsPublic(now time.Time) bool {
+	return p.IsActive && p.PublishStatus == ProjectPublished && (p.PublishAt == nil || !p.PublishAt.After(now))
+}
+
+// SetCategory ผูก project กับหมวดหมู่ (nil = ไม่มีหมวดหมู่) พร้อม sync ชื่อ
+func (p *Project) SetCategory(category *Category) {
+	if category == nil {
+		p.CategoryID = nil
+		p.Category = ""
+		return
+	}
+	p.CategoryID = &category.ID
+	p.Category = category.Name
+}
+
+// ProjectSlugHistory slug เดิมของ project ที่ถูกเปลี่ยนไปแล้ว
+// ลิงก์เก่ายังค้นเจอได้ - GET /projects/by-slug/:slug ตอบกลับพร้อม slug ปัจจุบันแทน 404
+type ProjectSlugHistory struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ProjectID uint      `json:"project_id" gorm:"index;not null"`
+	Slug      string    `json:"slug" gorm:"size:100;uniqueIndex;not null"`
+	CreatedAt time.Time `json:"created_at"`
 }
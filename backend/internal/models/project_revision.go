@@ -0,0 +1,109 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Action ของ ProjectRevision
+const (
+	ProjectRevisionBaseline = "baseline" // สถานะ ณ ตอนเริ่มเก็บ revision (projects ที่มีอยู่ก่อน)
+	ProjectRevisionCreate   = "create"
+	ProjectRevisionUpdate   = "update"
+	ProjectRevisionStatus   = "status"
+	ProjectRevisionRestore  = "restore"
+)
+
+// ProjectRevision snapshot ทั้งหมดของ project หลังการแก้ไขแต่ละครั้ง
+// Revision นับต่อ project เริ่มที่ 1 - restore สร้าง revision ใหม่ ไม่ย้อนเลข
+type ProjectRevision struct {
+	ID           uint            `json:"id" gorm:"primaryKey"`
+	ProjectID    uint            `json:"project_id" gorm:"not null;uniqueIndex:idx_project_revision"`
+	Revision     int             `json:"revision" gorm:"not null;uniqueIndex:idx_project_revision"`
+	Action       string          `json:"action" gorm:"size:20"`
+	Snapshot     ProjectSnapshot `json:"snapshot" gorm:"type:jsonb;serializer:json"`
+	RestoredFrom *int            `json:"restored_from,omitempty"` // revision ที่ถูก restore (action = restore)
+	AuthorID     *uint           `json:"author_id"`               // nil = ระบบ (เช่น scheduler)
+	Author       string          `json:"author"`                  // username ตอนบันทึก (user อาจถูกลบภายหลัง)
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// ProjectSnapshot ข้อมูลของ project ที่เก็บในแต่ละ revision
+type ProjectSnapshot struct {
+	Title           string     `json:"title"`
+	Slug            string     `json:"slug"`
+	Location        string     `json:"location"`
+	LocationMapLink string     `json:"location_map_link"`
+	Owner           string     `json:"owner"`
+	Category        string     `json:"category"`
+	CategoryID      *uint      `json:"category_id"`
+	Images          []string   `json:"images"`
+	Description     string     `json:"description"`
+	Status          string     `json:"status"`
+	SortOrder       int        `json:"sort_order"`
+	IsActive        bool       `json:"is_active"`
+	PublishStatus   string     `json:"publish_status"`
+	PublishAt       *time.Time `json:"publish_at"`
+}
+
+// Snapshot คืนข้อมูลปัจจุบันของ project สำหรับเก็บเป็น revision
+func (p *Project) Snapshot() ProjectSnapshot {
+	return ProjectSnapshot{
+		Title:           p.Title,
+		Slug:            p.Slug,
+		Location:        p.Location,
+		LocationMapLink: p.LocationMapLink,
+		Owner:           p.Owner,
+		Category:        p.Category,
+		CategoryID:      p.CategoryID,
+		Images:          append([]string(nil), p.Images...),
+		Description:     p.Description,
+		Status:          p.Status,
+		SortOrder:       p.SortOrder,
+		IsActive:        p.IsActive,
+		PublishStatus:   p.PublishStatus,
+		PublishAt:       p.PublishAt,
+	}
+}
+
+// ProjectFieldChange ค่าของ field หนึ่งที่ต่างกันระหว่างสอง revision
+type ProjectFieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// Diff เทียบ snapshot ทีละ field (ชื่อ field ตาม json) - คืนเฉพาะ field ที่เปลี่ยน เรียงตามลำดับใน struct
+func (s ProjectSnapshot) Diff(to ProjectSnapshot) []ProjectFieldChange {
+	changes := []ProjectFieldChange{}
+	from, target := reflect.ValueOf(s), reflect.ValueOf(to)
+	for i := 0; i < from.NumField(); i++ {
+		a, b := snapshotValue(from.Field(i)), snapshotValue(target.Field(i))
+		if reflect.DeepEqual(a, b) {
+			continue
+		}
+		field := strings.Split(from.Type().Field(i).Tag.Get("json"), ",")[0]
+		changes = append(changes, ProjectFieldChange{Field: field, From: a, To: b})
+	}
+	return changes
+}
+
+// snapshotValue ค่าของ field สำหรับเทียบ/แสดงผล (pointer ใช้ค่าที่ชี้, slice ว่างกับ nil ถือว่าเท่ากัน)
+func snapshotValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return snapshotValue(v.Elem())
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return []string{}
+		}
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.UTC()
+	}
+	return v.Interface()
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProjectSnapshotDiff(t *testing.T) {
+	category := uint(3)
+	publishAt := time.Date(2026, 10, 1, 9, 0, 0, 0, time.FixedZone("ICT", 7*3600))
+
+	before := (&Project{Title: "บ้านสวน", Owner: "Khun A", Images: nil, IsActive: true}).Snapshot()
+	after := (&Project{Title: "บ้านสวน 2", Owner: "Khun A", Images: []string{}, CategoryID: &category, PublishAt: &publishAt}).Snapshot()
+
+	got := before.Diff(after)
+	want := []ProjectFieldChange{
+		{Field: "title", From: "บ้านสวน", To: "บ้านสวน 2"},
+		{Field: "category_id", From: nil, To: uint(3)},
+		{Field: "is_active", From: true, To: false},
+		{Field: "publish_at", From: nil, To: publishAt.UTC()},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Diff() = %+v, want %+v", got, want)
+	}
+
+	if changes := after.Diff(after); len(changes) != 0 {
+		t.Errorf("Diff() of identical snapshots = %+v, want none", changes)
+	}
+}
+
+func TestProjectSnapshotCopiesImages(t *testing.T) {
+	project := Project{Images: []string{"a.jpg"}}
+	snap := project.Snapshot()
+	project.Images[0] = "b.jpg"
+	if snap.Images[0] != "a.jpg" {
+		t.Errorf("snapshot shares images with project: %v", snap.Images)
+	}
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+func TestProjectSetCategory(t *testing.T) {
+	project := Project{Category: "interior"}
+
+	project.SetCategory(&Category{ID: 2, Name: "Interior"})
+	if project.CategoryID == nil || *project.CategoryID != 2 || project.Category != "Interior" {
+		t.Fatalf("SetCategory() = (%v, %q), want (2, Interior)", project.CategoryID, project.Category)
+	}
+
+	project.SetCategory(nil)
+	if project.CategoryID != nil || project.Category != "" {
+		t.Fatalf("SetCategory(nil) = (%v, %q), want no category", project.CategoryID, project.Category)
+	}
+}
+
+func TestProjectIsPublic(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	cases := []struct {
+		name    string
+		project Project
+		want    bool
+	}{
+		{"published", Project{IsActive: true, PublishStatus: ProjectPublished, PublishAt: &past}, true},
+		{"published without publish_at", Project{IsActive: true, PublishStatus: ProjectPublished}, true},
+		{"scheduled", Project{IsActive: true, PublishStatus: ProjectPublished, PublishAt: &future}, false},
+		{"draft", Project{IsActive: true, PublishStatus: ProjectDraft}, false},
+		{"in review", Project{IsActive: true, PublishStatus: ProjectInReview}, false},
+		{"archived", Project{IsActive: true, PublishStatus: ProjectArchived, PublishAt: &past}, false},
+		{"inactive", Project{PublishStatus: ProjectPublished, PublishAt: &past}, false},
+	}
+	for _, tc := range cases {
+		if got := tc.project.IsPublic(now); got != tc.want {
+			t.Errorf("%s: IsPublic() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestProjectStatusNeedsPublish(t *testing.T) {
+	cases := []struct {
+		from, to string
+		want     bool
+	}{
+		{ProjectDraft, ProjectInReview, false},
+		{ProjectInReview, ProjectDraft, false},
+		{ProjectInReview, ProjectPublished, true},
+		{ProjectDraft, ProjectArchived, true},
+		{ProjectPublished, ProjectDraft, true},
+		{ProjectArchived, ProjectInReview, true},
+	}
+	for _, tc := range cases {
+		if got := ProjectStatusNeedsPublish(tc.from, tc.to); got != tc.want {
+			t.Errorf("ProjectStatusNeedsPublish(%s, %s) = %v, want %v", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
+
+func TestProjectEditNeedsPublish(t *testing.T) {
+	for status, want := range map[string]bool{
+		ProjectDraft:     false,
+		ProjectInReview:  false,
+		ProjectPublished: true,
+		ProjectArchived:  true,
+	} {
+		if got := ProjectEditNeedsPublish(status); got != want {
+			t.Errorf("ProjectEditNeedsPublish(%s) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestProjectCategoryForeignKey(t *testing.T) {
+	s, err := schema.Parse(&Project{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel, ok := s.Relationships.Relations["CategoryRef"]
+	if !ok || rel.Type != schema.BelongsTo {
+		t.Fatalf("CategoryRef should be a belongs-to relation, got %+v", rel)
+	}
+	constraint := rel.ParseConstraint()
+	if constraint == nil || constraint.OnDelete != "RESTRICT" || constraint.ForeignKeys[0].DBName != "category_id" {
+		t.Errorf("unexpected category constraint %+v", constraint)
+	}
+}
@@ -9,6 +9,16 @@ type Role struct {
 	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions;"`
 	CreatedAt   time.Time    `json:"created_at"`
 	UpdatedAt   time.Time    `json:"updated_at"`
+
+	// RequireTwoFactor บังคับให้ผู้ใช้ใน role นี้เปิด 2FA ก่อนเข้าส่วน admin
+	RequireTwoFactor bool `json:"require_two_factor" gorm:"default:false"`
+
+	// Scopes จำกัด permission บางตัวให้ใช้ได้เฉพาะแผนก/ลูกทีม
+	Scopes []RolePermissionScope `json:"scopes" gorm:"foreignKey:RoleID"`
+
+	// ParentID role แม่ - สืบทอด permission ทั้งหมดของ parent (ดู services.EffectivePermissions)
+	ParentID *uint `json:"parent_id" gorm:"index"`
+	Parent   *Role `json:"parent,omitempty" gorm:"foreignKey:ParentID"`
 }
 
 type Permission struct {
@@ -17,6 +27,8 @@ type Permission struct {
 	Description string    `json:"description"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	Group string `json:"group"` // กลุ่มในหน้าแก้ไข role (ประกาศใน rbac registry)
 }
 
 type Menu struct {
@@ -31,4 +43,24 @@ type Menu struct {
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 	Children       []Menu    `json:"children" gorm:"foreignKey:ParentID"`
+
+	Translations []MenuTranslation `json:"translations,omitempty" gorm:"foreignKey:MenuID"`
+}
+
+// MenuTranslation ชื่อเมนูตามภาษา - ภาษาที่ไม่มีแปลใช้ Menu.Title
+type MenuTranslation struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	MenuID uint   `json:"menu_id" gorm:"not null;uniqueIndex:idx_menu_translation_locale"`
+	Locale string `json:"locale" gorm:"size:10;not null;uniqueIndex:idx_menu_translation_locale"`
+	Title  string `json:"title" gorm:"not null"`
+}
+
+// LocalizedTitle คืนชื่อเมนูตามภาษา (ต้อง preload Translations)
+func (m *Menu) LocalizedTitle(locale string) string {
+	for _, t := range m.Translations {
+		if t.Locale == locale && t.Title != "" {
+			return t.Title
+		}
+	}
+	return m.Title
 }
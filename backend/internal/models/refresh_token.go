@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Refresh token revocation reasons
+const (
+	RefreshTokenRevokedRotated   = "rotated"
+	RefreshTokenRevokedReused    = "reuse_detected"
+	RefreshTokenRevokedLogout    = "logout"
+	RefreshTokenRevokedLogoutAll = "logout_all"
+	RefreshTokenRevokedStale     = "stale_version" // สิทธิ์ของ user เปลี่ยน (token_version)
+)
+
+// RefreshToken เก็บ refresh token แบบ hash (ไม่เก็บ token จริง)
+// token ที่ออกต่อเนื่องจากการ login ครั้งเดียวกันจะอยู่ใน family เดียวกัน
+// เพื่อใช้ตรวจจับการนำ token ที่ถูก rotate แล้วกลับมาใช้ซ้ำ
+type RefreshToken struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	UserID        uint       `json:"user_id" gorm:"not null;index"`
+	FamilyID      string     `json:"family_id" gorm:"size:36;not null;index"`
+	TokenHash     string     `json:"-" gorm:"size:64;unique;not null"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at"`
+	RevokedReason string     `json:"revoked_reason" gorm:"size:30"`
+	ReplacedByID  *uint      `json:"replaced_by_id"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
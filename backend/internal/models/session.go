@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Session แทนการ login หนึ่งครั้งบนอุปกรณ์หนึ่ง
+// ผูกกับ refresh token family (FamilyID) - revoke session = revoke refresh token ทั้ง family
+type Session struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	FamilyID    string     `json:"-" gorm:"size:36;unique;not null"`
+	Device      string     `json:"device"` // e.g. "Chrome on Windows"
+	IPAddress   string     `json:"ip_address"`
+	UserAgent   string     `json:"user_agent"`
+	LoginMethod string     `json:"login_method" gorm:"size:30"` // password, pin, magic_link
+	LastUsedAt  time.Time  `json:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+
+	// TokenVersion ของ user ตอนออก session - ไม่ตรงกับ users.token_version = สิทธิ์เปลี่ยนแล้ว
+	TokenVersion int `json:"-" gorm:"default:0;not null"`
+}
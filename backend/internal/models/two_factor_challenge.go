@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// UsedTwoFactorChallenge challenge token (jti) ที่ใช้ login สำเร็จไปแล้ว - กันการนำ token เดิมกลับมาใช้ซ้ำ
+// เก็บไว้จนกว่า token จะหมดอายุ
+type UsedTwoFactorChallenge struct {
+	TokenID   string    `json:"token_id" gorm:"primaryKey;size:36"`
+	UserID    uint      `json:"user_id" gorm:"index"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -2,6 +2,13 @@ package models
 
 import "time"
 
+// User approval statuses
+const (
+	ApprovalApproved = "approved"
+	ApprovalPending  = "pending"
+	ApprovalRejected = "rejected"
+)
+
 type User struct {
 	ID         uint       `json:"id" gorm:"primaryKey"`
 	Username   string     `json:"username" gorm:"unique;not null"`
@@ -21,4 +28,28 @@ type User struct {
 	Role       Role       `json:"role" gorm:"foreignKey:RoleID"`
 	CreatedAt  time.Time  `json:"created_at"`
 	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// Two-factor authentication (TOTP)
+	TwoFactorEnabled  bool     `json:"two_factor_enabled" gorm:"default:false"`
+	TwoFactorSecret   string   `json:"-" gorm:"size:64"`         // base32 secret (ตั้งไว้ตอน setup ก่อน enable)
+	TwoFactorLastStep int64    `json:"-"`                        // time step ล่าสุดที่ใช้ - กันใช้ code ซ้ำ
+	RecoveryCodes     []string `json:"-" gorm:"serializer:json"` // SHA-256 hash ของ recovery codes ที่ยังไม่ถูกใช้
+
+	// TokenVersion เพิ่มขึ้นเมื่อสิทธิ์ของ user เปลี่ยน (role, active, permissions ของ role)
+	// refresh token ที่ออกด้วย version เก่าจะถูกปฏิเสธ
+	TokenVersion int `json:"-" gorm:"default:0;not null"`
+
+	// Email verification (self-registration)
+	EmailVerified   bool       `json:"email_verified" gorm:"default:false"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+
+	// Registration approval (registration_mode = approval_required)
+	ApprovalStatus string `json:"approval_status" gorm:"size:20;default:'approved'"` // approved, pending, rejected
+
+	// Password policy - หมดอายุตาม password_max_age_days หรือถูกบังคับเปลี่ยน (admin ตั้งรหัสผ่านให้)
+	PasswordChangedAt  *time.Time `json:"password_changed_at"`
+	MustChangePassword bool       `json:"must_change_password" gorm:"default:false"`
+
+	// Locale ภาษาที่ user เลือก (th / en) - ว่าง = ตาม Accept-Language ของ browser
+	Locale string `json:"locale" gorm:"size:10"`
 }
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// UserIdentity บัญชีภายนอก (OIDC provider) ที่ผูกกับ user
+// หนึ่ง user ผูกได้หลาย provider แต่ (provider, subject) หนึ่งคู่ผูกได้กับ user เดียว
+type UserIdentity struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	Provider    string     `json:"provider" gorm:"size:50;not null;uniqueIndex:idx_identity_provider_subject"`
+	Subject     string     `json:"-" gorm:"size:255;not null;uniqueIndex:idx_identity_provider_subject"` // "sub" claim
+	Email       string     `json:"email"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// OAuthState state ของ OIDC login ที่ยังไม่เสร็จ (ใช้ครั้งเดียว อายุสั้น)
+// เก็บ nonce และ PKCE verifier ไว้ฝั่ง server เพื่อให้ทำงานได้หลาย instance
+type OAuthState struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	StateHash    string    `json:"-" gorm:"size:64;unique;not null"`
+	Provider     string    `json:"provider" gorm:"size:50;not null"`
+	Nonce        string    `json:"-" gorm:"size:100;not null"`
+	CodeVerifier string    `json:"-" gorm:"size:100;not null"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Role grant revoke reasons
+const (
+	RoleGrantRevoked = "revoked" // admin ถอนสิทธิ์
+	RoleGrantExpired = "expired" // หมดช่วงเวลา valid_until (scheduled job)
+)
+
+// UserRole role เพิ่มเติมของ user นอกจาก User.RoleID (role หลัก)
+// ValidFrom / ValidUntil ว่าง = มีผลทันที / ไม่มีวันหมดอายุ
+// ใช้กับสิทธิ์ชั่วคราว เช่น รักษาการแทนหัวหน้าที่ลา
+type UserRole struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"user_id" gorm:"not null;index"`
+	RoleID       uint       `json:"role_id" gorm:"not null;index"`
+	Role         Role       `json:"role" gorm:"foreignKey:RoleID"`
+	ValidFrom    *time.Time `json:"valid_from"`
+	ValidUntil   *time.Time `json:"valid_until" gorm:"index"`
+	Reason       string     `json:"reason"`
+	GrantedByID  *uint      `json:"granted_by_id"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	RevokeReason string     `json:"revoke_reason" gorm:"size:20"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ActiveAt grant มีผลอยู่ ณ เวลาที่ระบุหรือไม่
+func (g *UserRole) ActiveAt(t time.Time) bool {
+	if g.RevokedAt != nil {
+		return false
+	}
+	if g.ValidFrom != nil && t.Before(*g.ValidFrom) {
+		return false
+	}
+	return g.ValidUntil == nil || t.Before(*g.ValidUntil)
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUserRoleActiveAt(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	cases := []struct {
+		name  string
+		grant UserRole
+		want  bool
+	}{
+		{"open ended", UserRole{}, true},
+		{"within window", UserRole{ValidFrom: &past, ValidUntil: &future}, true},
+		{"not started", UserRole{ValidFrom: &future}, false},
+		{"expired", UserRole{ValidUntil: &past}, false},
+		{"ends now", UserRole{ValidUntil: &now}, false},
+		{"revoked", UserRole{RevokedAt: &past}, false},
+	}
+	for _, tc := range cases {
+		if got := tc.grant.ActiveAt(now); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Package rbac เก็บรายการ permission ทั้งหมดของระบบไว้ที่เดียว
+// permission ถูกประกาศด้วย Define และผูกกับ route ผ่าน Router - registry ใช้ sync ตาราง permissions,
+// สร้าง matrix route × permission และหา permission ที่ไม่มี route ใช้
+package rbac
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Permission นิยามของ permission หนึ่งตัว
+// Group ใช้จัดกลุ่มในหน้าแก้ไข role (ตรงกับกลุ่มเมนูใน admin panel)
+type Permission struct {
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+	Group       string `json:"group"`
+}
+
+// Route route ที่ผูกกับ permission (any = มีตัวใดตัวหนึ่ง, all = ต้องมีครบ)
+type Route struct {
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	Permissions []string `json:"permissions"`
+	RequireAll  bool     `json:"require_all"`
+}
+
+// Registry รายการ permission และ route ที่ประกาศไว้
+type Registry struct {
+	mu          sync.RWMutex
+	permissions map[string]Permission
+	order       []string
+	routes      []Route
+}
+
+func NewRegistry() *Registry {
+	return &Registry{permissions: map[string]Permission{}}
+}
+
+// Default registry ที่ routes ของแอปใช้
+var Default = NewRegistry()
+
+// Define ประกาศ permission ใน Default registry
+func Define(slug, description, group string) Permission {
+	return Default.Define(slug, description, group)
+}
+
+// Define ประกาศ permission - slug เดียวกันประกาศซ้ำด้วยค่าต่างกันถือเป็น bug (panic)
+func (r *Registry) Define(slug, description, group string) Permission {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := Permission{Slug: slug, Description: description, Group: group}
+	if existing, ok := r.permissions[slug]; ok {
+		if existing != p {
+			panic(fmt.Sprintf("rbac: permission %q defined twice with different values", slug))
+		}
+		return existing
+	}
+	r.permissions[slug] = p
+	r.order = append(r.order, slug)
+	return p
+}
+
+func (r *Registry) addRoute(route Route) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, slug := range route.Permissions {
+		if _, ok := r.permissions[slug]; !ok {
+			panic(fmt.Sprintf("rbac: route %s %s uses undefined permission %q", route.Method, route.Path, slug))
+		}
+	}
+	r.routes = append(r.routes, route)
+}
+
+// Permissions คืน permission ทั้งหมดตามลำดับที่ประกาศ
+func (r *Registry) Permissions() []Permission {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]Permission, 0, len(r.order))
+	for _, slug := range r.order {
+		result = append(result, r.permissions[slug])
+	}
+	return result
+}
+
+// Routes คืน route ที่ผูกกับ permission ทั้งหมดตามลำดับที่ลงทะเบียน
+func (r *Registry) Routes() []Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Route(nil), r.routes...)
+}
+
+// PermissionUsage permission หนึ่งตัวพร้อม route ที่ใช้ (รูปแบบ "METHOD /path")
+type PermissionUsage struct {
+	Permission
+	Routes []string `json:"routes"`
+}
+
+// Matrix ภาพรวม route × permission สำหรับตรวจสอบสิทธิ์
+type Matrix struct {
+	Routes      []Route           `json:"routes"`
+	Permissions []PermissionUsage `json:"permissions"`
+	Unused      []string          `json:"unused"`   // ประกาศไว้แต่ไม่มี route ใช้
+	Orphaned    []string          `json:"orphaned"` // อยู่ในตาราง permissions แต่ไม่มีใน registry
+}
+
+// Matrix สร้าง matrix จาก registry เทียบกับ slug ที่มีอยู่ในฐานข้อมูล
+func (r *Registry) Matrix(dbSlugs []string) Matrix {
+	routes := r.Routes()
+	perms := r.Permissions()
+
+	used := map[string][]string{}
+	for _, route := range routes {
+		for _, slug := range route.Permissions {
+			used[slug] = append(used[slug], route.Method+" "+route.Path)
+		}
+	}
+
+	m := Matrix{
+		Routes:      routes,
+		Permissions: make([]PermissionUsage, 0, len(perms)),
+		Unused:      []string{},
+		Orphaned:    []string{},
+	}
+	defined := make(map[string]bool, len(perms))
+	for _, p := range perms {
+		defined[p.Slug] = true
+		usage := PermissionUsage{Permission: p, Routes: used[p.Slug]}
+		if usage.Routes == nil {
+			usage.Routes = []string{}
+			m.Unused = append(m.Unused, p.Slug)
+		}
+		m.Permissions = append(m.Permissions, usage)
+	}
+	for _, slug := range dbSlugs {
+		if !defined[slug] {
+			m.Orphaned = append(m.Orphaned, slug)
+		}
+	}
+	sort.Strings(m.Orphaned)
+	return m
+}
@@ -0,0 +1,72 @@
+package rbac
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRegistryMatrix(t *testing.T) {
+	reg := NewRegistry()
+	view := reg.Define("things.view", "View Things", "Things")
+	manage := reg.Define("things.manage", "Manage Things", "Things")
+	reg.Define("things.export", "Export Things", "Things")
+
+	app := fiber.New()
+	r := &Router{router: app.Group("/api"), prefix: "/api", registry: reg}
+	things := r.Group("/things")
+	things.Get("/", Any(view, manage), func(c *fiber.Ctx) error { return nil })
+	things.Delete("/:id", All(manage), func(c *fiber.Ctx) error { return nil })
+
+	m := reg.Matrix([]string{"things.view", "legacy.manage"})
+
+	wantRoutes := []Route{
+		{Method: "GET", Path: "/api/things/", Permissions: []string{"things.view", "things.manage"}},
+		{Method: "DELETE", Path: "/api/things/:id", Permissions: []string{"things.manage"}, RequireAll: true},
+	}
+	if !reflect.DeepEqual(m.Routes, wantRoutes) {
+		t.Errorf("routes = %+v, want %+v", m.Routes, wantRoutes)
+	}
+	if !reflect.DeepEqual(m.Permissions[1].Routes, []string{"GET /api/things/", "DELETE /api/things/:id"}) {
+		t.Errorf("things.manage routes = %v", m.Permissions[1].Routes)
+	}
+	if !reflect.DeepEqual(m.Unused, []string{"things.export"}) {
+		t.Errorf("unused = %v", m.Unused)
+	}
+	if !reflect.DeepEqual(m.Orphaned, []string{"legacy.manage"}) {
+		t.Errorf("orphaned = %v", m.Orphaned)
+	}
+}
+
+func TestRouterEnforcesGuard(t *testing.T) {
+	reg := NewRegistry()
+	view := reg.Define("things.view", "View Things", "Things")
+
+	app := fiber.New()
+	r := &Router{router: app, registry: reg}
+	r.Get("/things", Any(view), func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	// ไม่มี claims (ไม่ได้ผ่าน Protected) ต้องไม่ผ่าน guard
+	resp, err := app.Test(httptest.NewRequest("GET", "/things", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", resp.StatusCode, fiber.StatusUnauthorized)
+	}
+}
+
+func TestDefineConflictPanics(t *testing.T) {
+	reg := NewRegistry()
+	reg.Define("things.view", "View Things", "Things")
+	reg.Define("things.view", "View Things", "Things") // ซ้ำด้วยค่าเดิมได้
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for conflicting definition")
+		}
+	}()
+	reg.Define("things.view", "Something else", "Things")
+}
@@ -0,0 +1,82 @@
+package rbac
+
+import (
+	"backend/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Guard เงื่อนไข permission ของ route
+type Guard struct {
+	permissions []Permission
+	all         bool
+}
+
+// Any ผ่านเมื่อ user มี permission อย่างน้อยหนึ่งตัว
+func Any(permissions ...Permission) Guard {
+	return Guard{permissions: permissions}
+}
+
+// All ผ่านเมื่อ user มี permission ครบทุกตัว
+func All(permissions ...Permission) Guard {
+	return Guard{permissions: permissions, all: true}
+}
+
+func (g Guard) slugs() []string {
+	slugs := make([]string, len(g.permissions))
+	for i, p := range g.permissions {
+		slugs[i] = p.Slug
+	}
+	return slugs
+}
+
+func (g Guard) handler() fiber.Handler {
+	if g.all {
+		return middleware.RequireAllPermissions(g.slugs()...)
+	}
+	return middleware.RequirePermission(g.slugs()...)
+}
+
+// Router ครอบ fiber.Router - ทุก route ที่ลงทะเบียนผ่าน Router ต้องระบุ Guard
+// และถูกบันทึกใน registry (ใช้ทำ matrix / หา permission ที่ไม่มีใครใช้)
+type Router struct {
+	router   fiber.Router
+	prefix   string
+	registry *Registry
+}
+
+// NewRouter สร้าง Router บน Default registry - prefix คือ path ของ router ที่ส่งเข้ามา (เช่น "/api")
+func NewRouter(router fiber.Router, prefix string) *Router {
+	return &Router{router: router, prefix: prefix, registry: Default}
+}
+
+// Group สร้าง sub-router (handlers เช่น middleware.Protected ใช้กับทุก route ใน group)
+func (r *Router) Group(prefix string, handlers ...fiber.Handler) *Router {
+	return &Router{router: r.router.Group(prefix, handlers...), prefix: r.prefix + prefix, registry: r.registry}
+}
+
+func (r *Router) add(method, path string, guard Guard, handlers []fiber.Handler) {
+	r.registry.addRoute(Route{Method: method, Path: r.prefix + path, Permissions: guard.slugs(), RequireAll: guard.all})
+	r.router.Add(method, path, append([]fiber.Handler{guard.handler()}, handlers...)...)
+}
+
+func (r *Router) Get(path string, guard Guard, handlers ...fiber.Handler) {
+	r.add(fiber.MethodGet, path, guard, handlers)
+}
+
+func (r *Router) Post(path string, guard Guard, handlers ...fiber.Handler) {
+	r.add(fiber.MethodPost, path, guard, handlers)
+}
+
+func (r *Router) Put(path string, guard Guard, handlers ...fiber.Handler) {
+	r.add(fiber.MethodPut, path, guard, handlers)
+}
+
+func (r *Router) Delete(path string, guard Guard, handlers ...fiber.Handler) {
+	r.add(fiber.MethodDelete, path, guard, handlers)
+}
+
+// Fiber คืน fiber.Router ของ group นี้ - ใช้กับ route ที่ไม่ต้องมี permission (เช่น ข้อมูลของตัวเอง)
+func (r *Router) Fiber() fiber.Router {
+	return r.router
+}
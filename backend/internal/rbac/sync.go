@@ -0,0 +1,42 @@
+package rbac
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"log"
+)
+
+// Sync สร้าง/อัปเดตตาราง permissions ตาม Default registry
+func Sync() error {
+	return Default.Sync()
+}
+
+// Sync สร้าง/อัปเดตตาราง permissions ตาม registry และแจ้งเตือน permission ที่ไม่มี route ใช้
+func (r *Registry) Sync() error {
+	perms := r.Permissions()
+	records := make([]models.Permission, len(perms))
+	for i, p := range perms {
+		records[i] = models.Permission{Slug: p.Slug, Description: p.Description, Group: p.Group}
+	}
+	if err := database.SyncPermissions(records); err != nil {
+		return err
+	}
+
+	matrix, err := r.CurrentMatrix()
+	if err != nil {
+		return err
+	}
+	if len(matrix.Orphaned) > 0 {
+		log.Printf("Warning: orphaned permissions (not declared in the route registry): %v", matrix.Orphaned)
+	}
+	return nil
+}
+
+// CurrentMatrix สร้าง matrix เทียบกับตาราง permissions ปัจจุบัน
+func (r *Registry) CurrentMatrix() (Matrix, error) {
+	var slugs []string
+	if err := database.DB.Model(&models.Permission{}).Order("slug").Pluck("slug", &slugs).Error; err != nil {
+		return Matrix{}, err
+	}
+	return r.Matrix(slugs), nil
+}
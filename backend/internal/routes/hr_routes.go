@@ -3,58 +3,56 @@ package routes
 import (
 	"backend/internal/handlers"
 	"backend/internal/middleware"
-
-	"github.com/gofiber/fiber/v2"
+	"backend/internal/rbac"
 )
 
-func SetupHRRoutes(api fiber.Router) {
-	hr := api.Group("/hr", middleware.Protected())
+func SetupHRRoutes(secured *rbac.Router) {
+	hr := secured.Group("/hr", middleware.Protected(), middleware.RequireVerifiedEmail())
 
-	// Master Data - Departments (public read, admin write)
+	// Master Data - Departments (read ได้ทุกคน, แก้ไขต้องมีสิทธิ์)
 	departments := hr.Group("/departments")
-	departments.Get("/", handlers.GetDepartments)
-	departments.Get("/:id", handlers.GetDepartment)
-	departments.Post("/", middleware.Admin(), handlers.CreateDepartment)
-	departments.Put("/:id", middleware.Admin(), handlers.UpdateDepartment)
-	departments.Delete("/:id", middleware.Admin(), handlers.DeleteDepartment)
+	departments.Fiber().Get("/", handlers.GetDepartments)
+	departments.Fiber().Get("/:id", handlers.GetDepartment)
+	departments.Post("/", rbac.Any(permDepartmentsManage), handlers.CreateDepartment)
+	departments.Put("/:id", rbac.Any(permDepartmentsManage), handlers.UpdateDepartment)
+	departments.Delete("/:id", rbac.Any(permDepartmentsManage), handlers.DeleteDepartment)
 
-	// Master Data - Positions (public read, admin write)
+	// Master Data - Positions (read ได้ทุกคน, แก้ไขต้องมีสิทธิ์)
 	positions := hr.Group("/positions")
-	positions.Get("/", handlers.GetPositions)
-	positions.Get("/:id", handlers.GetPosition)
-	positions.Post("/", middleware.Admin(), handlers.CreatePosition)
-	positions.Put("/:id", middleware.Admin(), handlers.UpdatePosition)
-	positions.Delete("/:id", middleware.Admin(), handlers.DeletePosition)
+	positions.Fiber().Get("/", handlers.GetPositions)
+	positions.Fiber().Get("/:id", handlers.GetPosition)
+	positions.Post("/", rbac.Any(permPositionsManage), handlers.CreatePosition)
+	positions.Put("/:id", rbac.Any(permPositionsManage), handlers.UpdatePosition)
+	positions.Delete("/:id", rbac.Any(permPositionsManage), handlers.DeletePosition)
 
 	// Users without employee record (for dropdown in employee form)
-	hr.Get("/users-without-employee", middleware.Admin(), handlers.GetUsersWithoutEmployee)
+	hr.Get("/users-without-employee", rbac.Any(permEmployeesManage), handlers.GetUsersWithoutEmployee)
 
 	// Employees
 	employees := hr.Group("/employees")
-	// Only admin (or HR role) should manage employees. For now using Admin middleware
-	employees.Post("/", middleware.Admin(), handlers.CreateEmployee)
-	employees.Get("/", middleware.Admin(), handlers.GetEmployees)
-	employees.Get("/:id", middleware.Admin(), handlers.GetEmployee)
-	employees.Put("/:id", middleware.Admin(), handlers.UpdateEmployee)
+	employees.Post("/", rbac.Any(permEmployeesManage), handlers.CreateEmployee)
+	employees.Get("/", rbac.Any(permEmployeesView), handlers.GetEmployees)
+	employees.Get("/:id", rbac.Any(permEmployeesView), handlers.GetEmployee)
+	employees.Put("/:id", rbac.Any(permEmployeesManage), handlers.UpdateEmployee)
 
 	// Get own employee profile
-	hr.Get("/my-employee-profile/:userId", handlers.GetEmployeeByUserID)
+	hr.Fiber().Get("/my-employee-profile/:userId", handlers.GetEmployeeByUserID)
 
 	// Attendance
-	attendance := hr.Group("/attendance")
+	attendance := hr.Group("/attendance").Fiber()
 	attendance.Post("/check-in", handlers.CheckIn)
 	attendance.Post("/check-out", handlers.CheckOut)
 	attendance.Get("/history", handlers.GetAttendanceHistory)
 	attendance.Get("/today", handlers.GetTodayAttendanceStatus)
 
 	// Admin Attendance View
-	hr.Get("/employees/:id/attendance", middleware.Admin(), handlers.GetEmployeeAttendance)
+	hr.Get("/employees/:id/attendance", rbac.Any(permAttendanceView), handlers.GetEmployeeAttendance)
 
 	// Leaves
 	leaves := hr.Group("/leaves")
-	leaves.Post("/", handlers.RequestLeave)
-	leaves.Get("/my", handlers.GetMyLeaves)
-	leaves.Get("/quota", handlers.GetMyLeaveQuota)
-	leaves.Get("/pending", middleware.Admin(), handlers.GetPendingLeaves) // Admin/Manager only
-	leaves.Put("/:id/approval", middleware.Admin(), handlers.ApproveRejectLeave)
+	leaves.Fiber().Post("/", handlers.RequestLeave)
+	leaves.Fiber().Get("/my", handlers.GetMyLeaves)
+	leaves.Fiber().Get("/quota", handlers.GetMyLeaveQuota)
+	leaves.Get("/pending", rbac.Any(permLeavesView, permLeavesManage), handlers.GetPendingLeaves)
+	leaves.Put("/:id/approval", rbac.Any(permLeavesManage), handlers.ApproveRejectLeave)
 }
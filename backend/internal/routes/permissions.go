@@ -0,0 +1,38 @@
+package routes
+
+import "backend/internal/rbac"
+
+// Permissions ของระบบ - ประกาศที่นี่ที่เดียว แล้ว rbac.Sync สร้างในตาราง permissions ให้ตอน start
+// Group = กลุ่มเมนูใน admin panel (ใช้จัดกลุ่มในหน้าแก้ไข role)
+var (
+	permAdminAccess   = rbac.Define("admin.access", "Access Admin Panel", "General")
+	permDashboardView = rbac.Define("dashboard.view", "View Dashboard", "General")
+
+	permUsersView   = rbac.Define("users.view", "View Users", "Users")
+	permUsersManage = rbac.Define("users.manage", "Create, Edit, Delete Users", "Users")
+	permRolesView   = rbac.Define("roles.view", "View Roles", "Users")
+	permRolesManage = rbac.Define("roles.manage", "Create, Edit, Delete Roles", "Users")
+
+	permUsersImpersonate = rbac.Define("users.impersonate", "View the App as Another User", "Users")
+
+	permEmployeesView     = rbac.Define("employees.view", "View Employees", "HR")
+	permEmployeesManage   = rbac.Define("employees.manage", "Create and Edit Employees", "HR")
+	permDepartmentsManage = rbac.Define("departments.manage", "Create, Edit, Delete Departments", "HR")
+	permPositionsManage   = rbac.Define("positions.manage", "Create, Edit, Delete Positions", "HR")
+	permAttendanceView    = rbac.Define("attendance.view", "View Attendance", "HR")
+	permAttendanceManage  = rbac.Define("attendance.manage", "Manage Attendance", "HR")
+	permLeavesView        = rbac.Define("leaves.view", "View Leaves", "HR")
+	permLeavesManage      = rbac.Define("leaves.manage", "Manage Leave Requests", "HR")
+
+	permProjectsView     = rbac.Define("projects.view", "View Projects", "Content")
+	permProjectsManage   = rbac.Define("projects.manage", "Create, Edit, Delete Projects", "Content")
+	permProjectsPublish  = rbac.Define("projects.publish", "Publish, Schedule, Archive Projects", "Content")
+	permCategoriesView   = rbac.Define("categories.view", "View Categories", "Content")
+	permCategoriesManage = rbac.Define("categories.manage", "Create, Edit, Delete Categories", "Content")
+
+	permMenusView      = rbac.Define("menus.view", "View Menus", "System")
+	permMenusManage    = rbac.Define("menus.manage", "Create, Edit, Delete Menus", "System")
+	permSettingsView   = rbac.Define("settings.view", "View Settings", "System")
+	permSettingsManage = rbac.Define("settings.manage", "Manage Settings", "System")
+	permAuditLogsView  = rbac.Define("audit_logs.view", "View Audit Logs", "System")
+)
@@ -4,12 +4,16 @@ import (
 	_ "backend/docs" // Import generated docs
 	"backend/internal/handlers"
 	"backend/internal/middleware"
+	"backend/internal/rbac"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/swagger"
 )
 
 func SetupRoutes(app *fiber.App) {
+	// Public keys สำหรับ service อื่นใช้ verify access token
+	app.Get("/.well-known/jwks.json", handlers.GetJWKS)
+
 	api := app.Group("/api")
 
 	// Swagger Route
@@ -37,104 +41,167 @@ func SetupRoutes(app *fiber.App) {
 	auth := api.Group("/auth")
 	auth.Post("/login", handlers.Login)
 	auth.Post("/login-pin", handlers.LoginWithPin)
+	auth.Post("/login/2fa", handlers.LoginTwoFactor)
+	auth.Post("/magic-link", handlers.RequestMagicLink)
+	auth.Post("/magic-link/verify", handlers.LoginWithMagicLink)
 	auth.Post("/register", handlers.Register)
 	auth.Post("/refresh", handlers.RefreshToken)
+	auth.Post("/logout", handlers.Logout)
+
+	// External login (OpenID Connect)
+	auth.Get("/oidc/providers", handlers.GetOIDCProviders)
+	auth.Get("/oidc/:provider/login", handlers.StartOIDCLogin)
+	auth.Post("/oidc/:provider/callback", handlers.OIDCCallback)
+	auth.Get("/identities", middleware.Protected(), handlers.GetMyIdentities)
+	auth.Delete("/identities/:id", middleware.Protected(), middleware.NoImpersonation(), handlers.UnlinkMyIdentity)
+
+	// Email verification routes (public)
+	auth.Post("/verify-email/:token", handlers.VerifyEmail)
+	auth.Post("/resend-verification", handlers.ResendVerification)
+	auth.Get("/invitations/:token", handlers.CheckInvitation)
 
 	// Forgot password routes (public)
 	auth.Post("/forgot-password", handlers.ForgotPassword)
 	auth.Get("/verify-reset-token/:token", handlers.VerifyResetToken)
 	auth.Post("/reset-password", handlers.ResetPassword)
 
-	// Protected routes
+	// Protected routes (endpoint ที่อ่อนไหวปิดระหว่าง impersonate)
 	auth.Get("/profile", middleware.Protected(), handlers.GetProfile)
 	auth.Put("/profile", middleware.Protected(), handlers.UpdateProfile)
-	auth.Put("/change-password", middleware.Protected(), handlers.ChangePassword)
+	auth.Put("/change-password", middleware.Protected(), middleware.NoImpersonation(), handlers.ChangePassword)
+	auth.Post("/logout-all", middleware.Protected(), middleware.NoImpersonation(), handlers.LogoutAll)
+	auth.Get("/sessions", middleware.Protected(), handlers.GetMySessions)
+	auth.Delete("/sessions/:id", middleware.Protected(), middleware.NoImpersonation(), handlers.RevokeMySession)
 	auth.Get("/menus", middleware.Protected(), handlers.GetMenus)
 
+	// Impersonation ("view as user") - token ของ admin ที่กำลังดูในมุมมองของ user
+	auth.Post("/impersonation/stop", middleware.Protected(), handlers.StopImpersonation)
+
+	// Two-factor authentication routes (protected)
+	auth.Get("/2fa/status", middleware.Protected(), handlers.GetTwoFactorStatus)
+	auth.Post("/2fa/setup", middleware.Protected(), middleware.NoImpersonation(), handlers.SetupTwoFactor)
+	auth.Post("/2fa/enable", middleware.Protected(), middleware.NoImpersonation(), handlers.EnableTwoFactor)
+	auth.Post("/2fa/disable", middleware.Protected(), middleware.NoImpersonation(), handlers.DisableTwoFactor)
+	auth.Post("/2fa/recovery-codes", middleware.Protected(), middleware.NoImpersonation(), handlers.RegenerateRecoveryCodes)
+
 	// PIN routes (protected)
-	auth.Put("/pin", middleware.Protected(), handlers.SetPin)
-	auth.Delete("/pin", middleware.Protected(), handlers.DisablePin)
+	auth.Put("/pin", middleware.Protected(), middleware.NoImpersonation(), handlers.SetPin)
+	auth.Delete("/pin", middleware.Protected(), middleware.NoImpersonation(), handlers.DisablePin)
 	auth.Get("/pin-status", middleware.Protected(), handlers.GetPinStatus)
 
-	// Admin User Management
-	users := api.Group("/users", middleware.Protected(), middleware.Admin())
-	users.Post("", handlers.CreateUser)
-	users.Get("", handlers.GetAllUsers)
-	users.Get("/:id", handlers.GetUserByID)
-	users.Put("/:id", handlers.UpdateUserAdmin)
-	users.Put("/:id/reset-password", handlers.AdminResetPassword)
-	users.Delete("/:id", handlers.DeleteUser)
+	// Admin User Management - แต่ละ route ตรวจ permission ของงานนั้นโดยตรง
+	// route ที่ต้องมี permission ลงทะเบียนผ่าน rbac.Router (ใช้ทำ /permissions/matrix)
+	secured := rbac.NewRouter(api, "/api")
+
+	users := secured.Group("/users", middleware.Protected())
+	users.Post("", rbac.Any(permUsersManage), handlers.CreateUser)
+	users.Get("", rbac.Any(permUsersView), handlers.GetAllUsers)
+	users.Get("/pending", rbac.Any(permUsersView), handlers.GetPendingRegistrations) // ต้องอยู่ก่อน /:id
+	users.Get("/:id", rbac.Any(permUsersView), handlers.GetUserByID)
+	users.Put("/:id", rbac.Any(permUsersManage), handlers.UpdateUserAdmin)
+	users.Put("/:id/reset-password", rbac.Any(permUsersManage), handlers.AdminResetPassword)
+	users.Post("/:id/logout-all", rbac.Any(permUsersManage), handlers.AdminLogoutAll)
+	users.Get("/:id/sessions", rbac.Any(permUsersView), handlers.GetUserSessions)
+	users.Delete("/:id/sessions/:sessionId", rbac.Any(permUsersManage), handlers.RevokeUserSession)
+	users.Delete("/:id/2fa", rbac.Any(permUsersManage), handlers.AdminResetTwoFactor)
+	users.Post("/:id/unlock", rbac.Any(permUsersManage), handlers.UnlockUser)
+	users.Get("/:id/roles", rbac.Any(permUsersView), handlers.GetUserRoles)
+	users.Post("/:id/roles", rbac.Any(permUsersManage), handlers.GrantUserRole)
+	users.Delete("/:id/roles/:grantId", rbac.Any(permUsersManage), handlers.RevokeUserRole)
+	users.Post("/:id/impersonate", rbac.Any(permUsersImpersonate), middleware.NoImpersonation(), handlers.ImpersonateUser)
+	users.Delete("/:id", rbac.Any(permUsersManage), handlers.DeleteUser)
+	users.Post("/:id/approve", rbac.Any(permUsersManage), handlers.ApproveRegistration)
+	users.Post("/:id/reject", rbac.Any(permUsersManage), handlers.RejectRegistration)
+
+	// Invitations (registration links)
+	invitations := secured.Group("/invitations", middleware.Protected())
+	invitations.Post("", rbac.Any(permUsersManage), handlers.CreateInvitation)
+	invitations.Get("", rbac.Any(permUsersView), handlers.GetInvitations)
+	invitations.Delete("/:id", rbac.Any(permUsersManage), handlers.RevokeInvitation)
 
 	// Audit Logs
-	api.Get("/audit-logs", middleware.Protected(), middleware.Admin(), handlers.GetAuditLogs)
+	auditLogs := secured.Group("/audit-logs", middleware.Protected())
+	auditLogs.Get("", rbac.Any(permAuditLogsView), handlers.GetAuditLogs)
 
 	// Role & Permission Management
-	roles := api.Group("/roles", middleware.Protected(), middleware.Admin())
-	roles.Get("/", handlers.GetAllRoles)
-	roles.Post("/", handlers.CreateRole)
-	roles.Get("/:id/users", handlers.GetRoleUsers) // ต้องอยู่ก่อน /:id
-	roles.Get("/:id", handlers.GetRole)
-	roles.Put("/:id", handlers.UpdateRole)
-	roles.Delete("/:id", handlers.DeleteRole)
+	roles := secured.Group("/roles", middleware.Protected())
+	roles.Get("/", rbac.Any(permRolesView), handlers.GetAllRoles)
+	roles.Post("/", rbac.Any(permRolesManage), handlers.CreateRole)
+	roles.Get("/:id/users", rbac.All(permRolesView, permUsersView), handlers.GetRoleUsers) // ต้องอยู่ก่อน /:id
+	roles.Get("/:id", rbac.Any(permRolesView), handlers.GetRole)
+	roles.Put("/:id", rbac.Any(permRolesManage), handlers.UpdateRole)
+	roles.Delete("/:id", rbac.Any(permRolesManage), handlers.DeleteRole)
 
 	// Menu Management
-	menus := api.Group("/menus", middleware.Protected(), middleware.Admin())
-	menus.Get("/", handlers.GetAllMenus)
-	menus.Post("/", handlers.CreateMenu)
-	menus.Get("/:id", handlers.GetMenu)
-	menus.Put("/:id", handlers.UpdateMenu)
-	menus.Delete("/:id", handlers.DeleteMenu)
-
-	permissions := api.Group("/permissions", middleware.Protected(), middleware.Admin())
-	permissions.Get("/", handlers.GetAllPermissions)
+	menus := secured.Group("/menus", middleware.Protected())
+	menus.Get("/", rbac.Any(permMenusView), handlers.GetAllMenus)
+	menus.Post("/", rbac.Any(permMenusManage), handlers.CreateMenu)
+	menus.Get("/tree", rbac.Any(permMenusView), handlers.GetMenuTree) // ต้องอยู่ก่อน /:id
+	menus.Put("/tree", rbac.Any(permMenusManage), handlers.UpdateMenuTree)
+	menus.Get("/:id", rbac.Any(permMenusView), handlers.GetMenu)
+	menus.Put("/:id", rbac.Any(permMenusManage), handlers.UpdateMenu)
+	menus.Delete("/:id", rbac.Any(permMenusManage), handlers.DeleteMenu)
+
+	// ใช้ในหน้าแก้ไข role และเมนู
+	permissions := secured.Group("/permissions", middleware.Protected())
+	permissions.Get("/", rbac.Any(permRolesView, permMenusView), handlers.GetAllPermissions)
+	permissions.Get("/matrix", rbac.Any(permRolesView), handlers.GetPermissionMatrix)
 
 	// Project routes (public read)
 	projects := api.Group("/projects")
 	projects.Get("/", handlers.GetProjects)
-	projects.Get("/:id", handlers.GetProject)
+	projects.Get("/by-slug/:slug", handlers.GetProjectBySlug)
+	projects.Get("/:id<int>", handlers.GetProject) // ตัวเลขเท่านั้น - /projects/all เป็นของ admin
 
 	// Project routes (admin protected)
-	projectsAdmin := api.Group("/projects", middleware.Protected(), middleware.Admin())
-	projectsAdmin.Post("/", handlers.CreateProject)
-	projectsAdmin.Put("/:id", handlers.UpdateProject)
-	projectsAdmin.Delete("/:id", handlers.DeleteProject)
-	projectsAdmin.Put("/order", handlers.UpdateProjectOrder)
+	projectsAdmin := secured.Group("/projects", middleware.Protected())
+	projectsAdmin.Get("/all", rbac.Any(permProjectsView), handlers.GetAllProjects)
+	projectsAdmin.Post("/", rbac.Any(permProjectsManage), handlers.CreateProject)
+	projectsAdmin.Get("/:id<int>/preview", rbac.Any(permProjectsView), handlers.PreviewProject)
+	projectsAdmin.Get("/:id<int>/revisions", rbac.Any(permProjectsView), handlers.GetProjectRevisions)
+	projectsAdmin.Get("/:id<int>/revisions/diff", rbac.Any(permProjectsView), handlers.DiffProjectRevisions)
+	projectsAdmin.Get("/:id<int>/revisions/:revision<int>", rbac.Any(permProjectsView), handlers.GetProjectRevision)
+	projectsAdmin.Post("/:id<int>/revisions/:revision<int>/restore", rbac.Any(permProjectsManage), handlers.RestoreProjectRevision)
+	projectsAdmin.Put("/order", rbac.Any(permProjectsManage), handlers.UpdateProjectOrder) // ก่อน /:id
+	projectsAdmin.Put("/:id<int>", rbac.Any(permProjectsManage), handlers.UpdateProject)
+	projectsAdmin.Put("/:id<int>/status", rbac.Any(permProjectsManage, permProjectsPublish), handlers.UpdateProjectStatus)
+	projectsAdmin.Delete("/:id<int>", rbac.Any(permProjectsManage), handlers.DeleteProject)
 
 	// Category routes (public read)
 	api.Get("/categories", handlers.GetCategories)
 
 	// Category routes (admin protected)
-	categories := api.Group("/categories", middleware.Protected(), middleware.Admin())
-	categories.Get("/all", handlers.GetAllCategories)
-	categories.Get("/:id", handlers.GetCategory)
-	categories.Post("/", handlers.CreateCategory)
-	categories.Put("/order", handlers.UpdateCategoryOrder)
-	categories.Put("/:id", handlers.UpdateCategory)
-	categories.Delete("/:id", handlers.DeleteCategory)
-
-	// Upload routes (admin protected)
-	upload := api.Group("/upload", middleware.Protected(), middleware.Admin())
-	upload.Post("/image", handlers.UploadImage)
-	upload.Delete("/image/*", handlers.DeleteImage)
+	categories := secured.Group("/categories", middleware.Protected())
+	categories.Get("/all", rbac.Any(permCategoriesView), handlers.GetAllCategories)
+	categories.Get("/:id", rbac.Any(permCategoriesView), handlers.GetCategory)
+	categories.Post("/", rbac.Any(permCategoriesManage), handlers.CreateCategory)
+	categories.Put("/order", rbac.Any(permCategoriesManage), handlers.UpdateCategoryOrder)
+	categories.Put("/:id", rbac.Any(permCategoriesManage), handlers.UpdateCategory)
+	categories.Delete("/:id", rbac.Any(permCategoriesManage), handlers.DeleteCategory)
+
+	// Upload routes (ใช้ในฟอร์ม project เป็นหลัก)
+	upload := secured.Group("/upload", middleware.Protected())
+	upload.Post("/image", rbac.Any(permProjectsManage), handlers.UploadImage)
+	upload.Delete("/image/*", rbac.Any(permProjectsManage), handlers.DeleteImage)
 
 	// HR Routes
-	SetupHRRoutes(api)
+	SetupHRRoutes(secured)
 
 	// Dashboard Routes
-	dashboard := api.Group("/dashboard", middleware.Protected())
-	dashboard.Get("/stats", handlers.GetDashboardStats)
-	dashboard.Get("/activities", handlers.GetRecentActivities)
-	dashboard.Get("/recent-logins", handlers.GetRecentLogins)
+	dashboard := secured.Group("/dashboard", middleware.Protected())
+	dashboard.Get("/stats", rbac.Any(permDashboardView), handlers.GetDashboardStats)
+	dashboard.Get("/activities", rbac.Any(permDashboardView), handlers.GetRecentActivities)
+	dashboard.Get("/recent-logins", rbac.Any(permDashboardView), handlers.GetRecentLogins)
 
 	// Admin Cleanup Routes
-	cleanup := api.Group("/admin/cleanup", middleware.Protected(), middleware.Admin())
-	cleanup.Post("/images", handlers.CleanupOrphanedImages)
-	cleanup.Get("/status", handlers.GetCleanupStatus)
+	cleanup := secured.Group("/admin/cleanup", middleware.Protected())
+	cleanup.Post("/images", rbac.Any(permSettingsManage), handlers.CleanupOrphanedImages)
+	cleanup.Get("/status", rbac.Any(permSettingsManage), handlers.GetCleanupStatus)
 
 	// Settings Routes
 	api.Get("/public/settings", handlers.GetPublicSettings)
 
-	settings := api.Group("/settings", middleware.Protected(), middleware.Admin())
-	settings.Get("/", handlers.GetSettings)
-	settings.Put("/", handlers.UpdateSettings)
+	settings := secured.Group("/settings", middleware.Protected())
+	settings.Get("/", rbac.Any(permSettingsView), handlers.GetSettings)
+	settings.Put("/", rbac.Any(permSettingsManage), handlers.UpdateSettings)
 }
@@ -35,9 +35,30 @@ func CreateAuditLog(c *fiber.Ctx, action string, entityID uint, entityType strin
 		UserAgent:  c.Get("User-Agent"),
 		CreatedAt:  time.Now(),
 	}
+	// ระหว่าง impersonate บันทึกทั้ง user ที่ถูก impersonate และ admin ที่ทำจริง
+	if impersonatorID, ok := c.Locals("impersonatorID").(uint); ok && impersonatorID != 0 {
+		log.ImpersonatorID = &impersonatorID
+	}
 
 	// Run in background to not block response
 	go func() {
 		database.DB.Create(&log)
 	}()
 }
+
+// CreateSystemAuditLog บันทึก audit log จากงานเบื้องหลัง (scheduled job) ที่ไม่มี request context
+// actorID = 0 หมายถึงระบบ
+func CreateSystemAuditLog(action string, entityID uint, entityType string, details interface{}, actorID uint) {
+	detailsJSON, _ := json.Marshal(details)
+
+	log := models.AuditLog{
+		UserID:     actorID,
+		Action:     action,
+		EntityID:   entityID,
+		EntityType: entityType,
+		Details:    string(detailsJSON),
+		UserAgent:  "system",
+		CreatedAt:  time.Now(),
+	}
+	database.DB.Create(&log)
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/pkg/utils"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// EmailVerificationTTL อายุของ link ยืนยันอีเมล
+	EmailVerificationTTL = 24 * time.Hour
+	// EmailVerificationResendCooldown ระยะห่างขั้นต่ำระหว่างการส่ง email ยืนยันซ้ำ
+	EmailVerificationResendCooldown = 1 * time.Minute
+)
+
+// ค่าของ setting "email_verification_mode"
+const (
+	EmailVerificationOff      = "off"      // ไม่ตรวจสอบ
+	EmailVerificationRestrict = "restrict" // login ได้ แต่เข้า HR / admin ไม่ได้จนกว่าจะยืนยัน
+	EmailVerificationBlock    = "block"    // login ไม่ได้จนกว่าจะยืนยัน
+)
+
+var (
+	ErrEmailVerificationInvalid  = utils.ErrEmailVerificationInvalid
+	ErrEmailVerificationCooldown = errors.New("verification email was sent recently")
+)
+
+// EmailVerificationMode อ่านโหมดจาก settings (ค่าที่ไม่รู้จักถือเป็น block)
+func EmailVerificationMode() string {
+	switch mode := GetSetting("email_verification_mode", EmailVerificationBlock); mode {
+	case EmailVerificationOff, EmailVerificationRestrict:
+		return mode
+	default:
+		return EmailVerificationBlock
+	}
+}
+
+// EmailVerificationPending คืน true ถ้า user ยังไม่ยืนยันอีเมลและระบบเปิดการตรวจสอบอยู่
+func EmailVerificationPending(user *models.User) bool {
+	return !user.EmailVerified && EmailVerificationMode() != EmailVerificationOff
+}
+
+// CreateEmailVerification สร้าง token ยืนยันอีเมลใหม่ (token เดิมที่ยังไม่ใช้จะถูกลบ)
+// คืน ErrEmailVerificationCooldown ถ้าเพิ่งส่งไปไม่เกิน EmailVerificationResendCooldown
+func CreateEmailVerification(user *models.User) (string, error) {
+	var latest models.EmailVerification
+	if err := database.DB.Where("user_id = ? AND used = ?", user.ID, false).Order("created_at desc").First(&latest).Error; err == nil {
+		if time.Since(latest.CreatedAt) < EmailVerificationResendCooldown {
+			return "", ErrEmailVerificationCooldown
+		}
+	}
+
+	token, err := utils.GenerateResetToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND used = ?", user.ID, false).Delete(&models.EmailVerification{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.EmailVerification{
+			UserID:    user.ID,
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: time.Now().Add(EmailVerificationTTL),
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// VerifyEmailToken ใช้ token ยืนยันอีเมล (ใช้ได้ครั้งเดียว) และ mark user ว่ายืนยันแล้ว
+func VerifyEmailToken(token string) (*models.User, error) {
+	var record models.EmailVerification
+	if err := database.DB.Where("token_hash = ? AND used = ? AND expires_at > ?", utils.HashToken(token), false, time.Now()).First(&record).Error; err != nil {
+		return nil, ErrEmailVerificationInvalid
+	}
+
+	var user models.User
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// conditional update กัน request ซ้อนที่ใช้ token เดียวกัน
+		result := tx.Model(&models.EmailVerification{}).Where("id = ? AND used = ?", record.ID, false).Update("used", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrEmailVerificationInvalid
+		}
+
+		if err := tx.First(&user, record.UserID).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		user.EmailVerified = true
+		user.EmailVerifiedAt = &now
+		return tx.Model(&user).Updates(map[string]interface{}{"email_verified": true, "email_verified_at": now}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
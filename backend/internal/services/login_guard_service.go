@@ -0,0 +1,197 @@
+package services
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ============================================================
+// 🔒 LOGIN GUARD - backoff / lockout สำหรับ password, PIN และ 2FA
+// ============================================================
+
+const (
+	// หลังผิดครบ BackoffAfterFailures ครั้ง ต้องรอ 1s, 2s, 4s, ... (สูงสุด MaxBackoff) ก่อนลองใหม่
+	BackoffAfterFailures = 3
+	MaxBackoff           = 5 * time.Minute
+
+	// ผิดครบจำนวนนี้จะถูกล็อกชั่วคราว
+	UserLockoutThreshold = 10
+	IPLockoutThreshold   = 50
+	LockoutDuration      = 15 * time.Minute
+
+	// PIN ผิดติดกันครบจำนวนนี้ ระบบจะปิด PIN login อัตโนมัติ
+	PINDisableThreshold = 5
+
+	// ถ้าไม่มีการผิดเพิ่มภายในช่วงนี้ ตัวนับจะเริ่มใหม่
+	FailureWindow = 1 * time.Hour
+)
+
+// LoginBlock ผลการตรวจสอบก่อน login - RetryAfter > 0 หมายถึงยังห้าม login
+type LoginBlock struct {
+	Locked     bool // true = ถูกล็อก (ครบ threshold), false = อยู่ในช่วง backoff
+	RetryAfter time.Duration
+}
+
+// LoginFailureResult สิ่งที่เกิดขึ้นหลังบันทึกการ login ผิด (ใช้บันทึก audit log)
+type LoginFailureResult struct {
+	UserLocked  bool
+	IPLocked    bool
+	PINDisabled bool
+	Failures    int
+}
+
+func userThrottleKey(userID uint) string { return fmt.Sprintf("user:%d", userID) }
+func ipThrottleKey(ip string) string     { return "ip:" + ip }
+
+// CheckLoginAllowed ตรวจสอบว่า user/IP ถูกล็อกหรืออยู่ในช่วง backoff หรือไม่
+// userID = 0 เมื่อไม่พบ username (ตรวจเฉพาะ IP)
+func CheckLoginAllowed(userID uint, ip string) *LoginBlock {
+	keys := []string{ipThrottleKey(ip)}
+	if userID != 0 {
+		keys = append(keys, userThrottleKey(userID))
+	}
+
+	var throttles []models.LoginThrottle
+	database.DB.Where("key IN ?", keys).Find(&throttles)
+
+	now := time.Now()
+	var block *LoginBlock
+	for _, t := range throttles {
+		if t.LastFailureAt == nil || now.Sub(*t.LastFailureAt) > FailureWindow {
+			continue
+		}
+
+		if t.LockedUntil != nil && t.LockedUntil.After(now) {
+			wait := t.LockedUntil.Sub(now)
+			if block == nil || !block.Locked || wait > block.RetryAfter {
+				block = &LoginBlock{Locked: true, RetryAfter: wait}
+			}
+			continue
+		}
+
+		if wait := backoffRemaining(t, now); wait > 0 && (block == nil || (!block.Locked && wait > block.RetryAfter)) {
+			block = &LoginBlock{RetryAfter: wait}
+		}
+	}
+	return block
+}
+
+// backoffRemaining คำนวณเวลาที่ต้องรอแบบ exponential จากจำนวนครั้งที่ผิด
+func backoffRemaining(t models.LoginThrottle, now time.Time) time.Duration {
+	if t.Failures < BackoffAfterFailures || t.LastFailureAt == nil {
+		return 0
+	}
+	exp := float64(t.Failures - BackoffAfterFailures)
+	delay := time.Duration(math.Min(math.Pow(2, exp), MaxBackoff.Seconds())) * time.Second
+	return t.LastFailureAt.Add(delay).Sub(now)
+}
+
+// RecordLoginFailure บันทึกการ login ผิดของ user (ถ้ารู้จัก) และ IP
+// method: "password", "pin", "2fa"
+func RecordLoginFailure(userID uint, ip string, method string) LoginFailureResult {
+	var result LoginFailureResult
+
+	ipThrottle := incrementThrottle(ipThrottleKey(ip), false)
+	if ipThrottle != nil && reachedLockout(ipThrottle.Failures, IPLockoutThreshold) {
+		lockThrottle(ipThrottle)
+		result.IPLocked = true
+	}
+
+	if userID == 0 {
+		return result
+	}
+
+	userThrottle := incrementThrottle(userThrottleKey(userID), method == "pin")
+	if userThrottle == nil {
+		return result
+	}
+	result.Failures = userThrottle.Failures
+
+	if reachedLockout(userThrottle.Failures, UserLockoutThreshold) {
+		lockThrottle(userThrottle)
+		result.UserLocked = true
+	}
+
+	if method == "pin" && userThrottle.PINFailures >= PINDisableThreshold {
+		res := database.DB.Model(&models.User{}).
+			Where("id = ? AND pin_enabled = ?", userID, true).
+			Updates(map[string]interface{}{"pin_enabled": false, "pin": ""})
+		result.PINDisabled = res.Error == nil && res.RowsAffected > 0
+	}
+
+	return result
+}
+
+// reachedLockout ตรวจว่าจำนวนครั้งที่ผิดครบ threshold หรือไม่
+// ล็อกทุกครั้งที่ผิดครบ threshold (ผิดต่อหลังปลดล็อกจะถูกล็อกอีกทุก ๆ threshold ครั้ง)
+func reachedLockout(failures, threshold int) bool {
+	return failures > 0 && failures%threshold == 0
+}
+
+// applyLoginFailure นับการผิดเพิ่มหนึ่งครั้ง (เริ่มนับใหม่ถ้าครั้งล่าสุดเก่ากว่า FailureWindow)
+func applyLoginFailure(throttle *models.LoginThrottle, now time.Time, pin bool) {
+	if throttle.LastFailureAt != nil && now.Sub(*throttle.LastFailureAt) > FailureWindow {
+		throttle.Failures = 0
+		throttle.PINFailures = 0
+		throttle.LockedUntil = nil
+	}
+
+	throttle.Failures++
+	if pin {
+		throttle.PINFailures++
+	}
+	throttle.LastFailureAt = &now
+}
+
+// incrementThrottle เพิ่มตัวนับของ key
+// lock แถว (SELECT ... FOR UPDATE) ก่อนนับ - login ผิดพร้อมกันหลาย request ต้องนับครบทุกครั้ง
+func incrementThrottle(key string, pin bool) *models.LoginThrottle {
+	var throttle models.LoginThrottle
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.LoginThrottle{Key: key}).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&throttle).Error; err != nil {
+			return err
+		}
+
+		applyLoginFailure(&throttle, time.Now(), pin)
+		return tx.Save(&throttle).Error
+	})
+	if err != nil {
+		return nil
+	}
+	return &throttle
+}
+
+func lockThrottle(throttle *models.LoginThrottle) {
+	until := time.Now().Add(LockoutDuration)
+	throttle.LockedUntil = &until
+	database.DB.Model(throttle).Update("locked_until", until)
+}
+
+// ResetLoginFailures ล้างตัวนับของ user หลัง login สำเร็จ
+func ResetLoginFailures(userID uint) {
+	database.DB.Where("key = ?", userThrottleKey(userID)).Delete(&models.LoginThrottle{})
+}
+
+// GetLoginThrottle คืนสถานะ lockout ของ user (nil ถ้าไม่มีการผิด)
+func GetLoginThrottle(userID uint) *models.LoginThrottle {
+	var throttle models.LoginThrottle
+	if err := database.DB.Where("key = ?", userThrottleKey(userID)).First(&throttle).Error; err != nil {
+		return nil
+	}
+	return &throttle
+}
+
+// UnlockUser ปลดล็อก user (admin) - คืน true ถ้ามีการล็อก/ตัวนับอยู่ก่อน
+func UnlockUser(userID uint) bool {
+	result := database.DB.Where("key = ?", userThrottleKey(userID)).Delete(&models.LoginThrottle{})
+	return result.RowsAffected > 0
+}
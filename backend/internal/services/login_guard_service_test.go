@@ -0,0 +1,49 @@
+package services
+
+import (
+	"backend/internal/models"
+	"testing"
+	"time"
+)
+
+func TestReachedLockout(t *testing.T) {
+	tests := []struct {
+		failures  int
+		threshold int
+		want      bool
+	}{
+		{0, UserLockoutThreshold, false},
+		{UserLockoutThreshold - 1, UserLockoutThreshold, false},
+		{UserLockoutThreshold, UserLockoutThreshold, true},
+		{UserLockoutThreshold + 1, UserLockoutThreshold, false},
+		{2 * UserLockoutThreshold, UserLockoutThreshold, true},
+		{IPLockoutThreshold - 1, IPLockoutThreshold, false},
+		{IPLockoutThreshold, IPLockoutThreshold, true},
+		{2 * IPLockoutThreshold, IPLockoutThreshold, true},
+	}
+	for _, tt := range tests {
+		if got := reachedLockout(tt.failures, tt.threshold); got != tt.want {
+			t.Errorf("reachedLockout(%d, %d) = %v, want %v", tt.failures, tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestApplyLoginFailure(t *testing.T) {
+	now := time.Now()
+
+	recent := now.Add(-time.Minute)
+	throttle := models.LoginThrottle{Failures: 4, PINFailures: 2, LastFailureAt: &recent}
+	applyLoginFailure(&throttle, now, true)
+	if throttle.Failures != 5 || throttle.PINFailures != 3 || !throttle.LastFailureAt.Equal(now) {
+		t.Errorf("recent failure: got failures=%d pin=%d, want 5 / 3", throttle.Failures, throttle.PINFailures)
+	}
+
+	// ผิดครั้งล่าสุดนานกว่า FailureWindow - เริ่มนับใหม่
+	old := now.Add(-FailureWindow - time.Minute)
+	locked := old.Add(LockoutDuration)
+	throttle = models.LoginThrottle{Failures: 9, PINFailures: 4, LastFailureAt: &old, LockedUntil: &locked}
+	applyLoginFailure(&throttle, now, false)
+	if throttle.Failures != 1 || throttle.PINFailures != 0 || throttle.LockedUntil != nil {
+		t.Errorf("stale counter: got failures=%d pin=%d locked=%v, want 1 / 0 / nil", throttle.Failures, throttle.PINFailures, throttle.LockedUntil)
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/pkg/utils"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	// MagicLinkTTL อายุของ magic link
+	MagicLinkTTL = 15 * time.Minute
+
+	// rate limit ต่อ email: ไม่เกิน MagicLinkMaxPerWindow ครั้งใน MagicLinkWindow
+	// และต้องห่างกันอย่างน้อย MagicLinkMinInterval
+	MagicLinkMaxPerWindow = 3
+	MagicLinkWindow       = 1 * time.Hour
+	MagicLinkMinInterval  = 1 * time.Minute
+)
+
+var (
+	ErrMagicLinkInvalid     = utils.ErrMagicLinkInvalid
+	ErrMagicLinkRateLimited = errors.New("too many login links requested")
+)
+
+// magicLinkRateLimited ตรวจสอบจำนวน link ที่ขอด้วย email นี้ในช่วงเวลาล่าสุด
+func magicLinkRateLimited(email string) bool {
+	var links []models.MagicLink
+	database.DB.Select("created_at").
+		Where("email = ? AND created_at > ?", email, time.Now().Add(-MagicLinkWindow)).
+		Order("created_at desc").
+		Find(&links)
+
+	if len(links) >= MagicLinkMaxPerWindow {
+		return true
+	}
+	return len(links) > 0 && time.Since(links[0].CreatedAt) < MagicLinkMinInterval
+}
+
+// CreateMagicLink สร้าง magic link ให้ user คืน token จริง (ส่งทาง email เท่านั้น)
+// link เดิมที่ยังไม่ได้ใช้จะถูกยกเลิก เหลือ link ล่าสุดใช้ได้ link เดียว
+func CreateMagicLink(user *models.User, ip string) (string, *models.MagicLink, error) {
+	email := strings.ToLower(user.Email)
+	if magicLinkRateLimited(email) {
+		return "", nil, ErrMagicLinkRateLimited
+	}
+
+	token, err := utils.GenerateResetToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	database.DB.Model(&models.MagicLink{}).
+		Where("user_id = ? AND used_at IS NULL AND expires_at > ?", user.ID, now).
+		Update("expires_at", now)
+
+	link := models.MagicLink{
+		UserID:    user.ID,
+		Email:     email,
+		TokenHash: utils.HashToken(token),
+		IPAddress: ip,
+		ExpiresAt: now.Add(MagicLinkTTL),
+	}
+	if err := database.DB.Create(&link).Error; err != nil {
+		return "", nil, err
+	}
+	return token, &link, nil
+}
+
+// ConsumeMagicLink ใช้ magic link (ใช้ได้ครั้งเดียว) คืน record ของ link
+func ConsumeMagicLink(token string) (*models.MagicLink, error) {
+	var link models.MagicLink
+	if err := database.DB.Where("token_hash = ?", utils.HashToken(token)).First(&link).Error; err != nil {
+		return nil, ErrMagicLinkInvalid
+	}
+
+	// conditional update กัน request ซ้อนที่ใช้ link เดียวกัน
+	now := time.Now()
+	result := database.DB.Model(&models.MagicLink{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", link.ID, now).
+		Update("used_at", now)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return &link, ErrMagicLinkInvalid
+	}
+	link.UsedAt = &now
+	return &link, nil
+}
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/pkg/i18n"
+	"backend/pkg/utils"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// MaxMenuDepth จำนวนชั้นสูงสุดของเมนู (sidebar แสดงได้ 3 ชั้น)
+const MaxMenuDepth = 3
+
+var (
+	ErrMenuCycle          = utils.ErrMenuCycle
+	ErrMenuTooDeep        = utils.ErrMenuTooDeep
+	ErrMenuNotFound       = utils.ErrMenuNotFound
+	ErrMenuTreeIncomplete = utils.ErrMenuTreeIncomplete
+	ErrMenuLocale         = utils.ErrMenuLocale
+)
+
+// Delete modes ของเมนูที่มี children
+const (
+	MenuDeleteReparent = "reparent" // ย้าย children ขึ้นไปอยู่ใต้ parent ของเมนูที่ลบ
+	MenuDeleteCascade  = "cascade"  // ลบ children ทั้งหมดด้วย
+)
+
+// MenuTreeNode โครงสร้างเมนูที่ส่งมาจากหน้า drag-and-drop (ลำดับใน array = order)
+type MenuTreeNode struct {
+	ID       uint           `json:"id"`
+	Children []MenuTreeNode `json:"children"`
+}
+
+// menuPlacement ตำแหน่งใหม่ของเมนูหนึ่งตัวหลัง flatten tree
+type menuPlacement struct {
+	ID       uint
+	ParentID *uint
+	Order    int
+}
+
+// BuildMenuTree จัดเมนูแบบ flat ให้เป็น tree เรียงตาม order
+// เมนูที่ parent ไม่มีอยู่แล้วถือเป็น root
+func BuildMenuTree(menus []models.Menu) []models.Menu {
+	exists := make(map[uint]bool, len(menus))
+	for _, m := range menus {
+		exists[m.ID] = true
+	}
+
+	childrenByParent := make(map[uint][]models.Menu)
+	var roots []models.Menu
+	for _, m := range menus {
+		m.Children = nil
+		if m.ParentID != nil && exists[*m.ParentID] && *m.ParentID != m.ID {
+			childrenByParent[*m.ParentID] = append(childrenByParent[*m.ParentID], m)
+		} else {
+			roots = append(roots, m)
+		}
+	}
+
+	// seen กันข้อมูลเก่าที่ parent วนกันเอง (เมนูในวงจะไม่ถูกแสดง)
+	seen := map[uint]bool{}
+	var attach func(nodes []models.Menu, depth int) []models.Menu
+	attach = func(nodes []models.Menu, depth int) []models.Menu {
+		sortMenus(nodes)
+		result := make([]models.Menu, 0, len(nodes))
+		for _, m := range nodes {
+			if seen[m.ID] {
+				continue
+			}
+			seen[m.ID] = true
+			m.Children = []models.Menu{}
+			if depth < MaxMenuDepth {
+				m.Children = attach(childrenByParent[m.ID], depth+1)
+			}
+			result = append(result, m)
+		}
+		return result
+	}
+	return attach(roots, 1)
+}
+
+func sortMenus(menus []models.Menu) {
+	sort.SliceStable(menus, func(i, j int) bool {
+		if menus[i].Order != menus[j].Order {
+			return menus[i].Order < menus[j].Order
+		}
+		return menus[i].ID < menus[j].ID
+	})
+}
+
+// FilterMenuTree ตัดเมนูที่ user ไม่มีสิทธิ์ออกทุกชั้น
+// เมนูที่ถูกซ่อนจะซ่อน children ทั้งหมดด้วย และ group menu (path="#") ที่ไม่เหลือ child จะถูกซ่อน
+func FilterMenuTree(tree []models.Menu, perms map[string]bool) []models.Menu {
+	result := []models.Menu{}
+	for _, m := range tree {
+		if !m.IsActive || (m.PermissionSlug != "" && !perms[m.PermissionSlug]) {
+			continue
+		}
+		m.Children = FilterMenuTree(m.Children, perms)
+		if m.Path == "#" && len(m.Children) == 0 {
+			continue
+		}
+		result = append(result, m)
+	}
+	return result
+}
+
+// LocalizeMenuTree ใช้ชื่อเมนูตามภาษา (ไม่มีคำแปล = Menu.Title) และตัด translations ออกจาก response
+func LocalizeMenuTree(tree []models.Menu, locale string) []models.Menu {
+	for i := range tree {
+		tree[i].Title = tree[i].LocalizedTitle(locale)
+		tree[i].Translations = nil
+		tree[i].Children = LocalizeMenuTree(tree[i].Children, locale)
+	}
+	return tree
+}
+
+// SaveMenuTranslations บันทึกชื่อเมนูตามภาษา (locale -> title) - title ว่าง = ลบคำแปลของภาษานั้น
+func SaveMenuTranslations(tx *gorm.DB, menuID uint, titles map[string]string) error {
+	for locale := range titles {
+		if !i18n.IsSupported(locale) {
+			return ErrMenuLocale
+		}
+	}
+	for locale, title := range titles {
+		if title == "" {
+			if err := tx.Where("menu_id = ? AND locale = ?", menuID, locale).Delete(&models.MenuTranslation{}).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		var translation models.MenuTranslation
+		if err := tx.Where(models.MenuTranslation{MenuID: menuID, Locale: locale}).
+			Assign(models.MenuTranslation{Title: title}).
+			FirstOrCreate(&translation).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flattenMenuTree ตรวจ tree ที่ส่งมาและแปลงเป็นตำแหน่งของแต่ละเมนู
+// เมนูทุกตัวใน existing ต้องอยู่ใน tree ครั้งเดียวพอดี (id ซ้ำ = วน)
+func flattenMenuTree(nodes []MenuTreeNode, existing map[uint]bool) ([]menuPlacement, error) {
+	var placements []menuPlacement
+	seen := map[uint]bool{}
+
+	var walk func(nodes []MenuTreeNode, parentID *uint, depth int) error
+	walk = func(nodes []MenuTreeNode, parentID *uint, depth int) error {
+		if len(nodes) > 0 && depth > MaxMenuDepth {
+			return ErrMenuTooDeep
+		}
+		for i, node := range nodes {
+			if !existing[node.ID] {
+				return ErrMenuNotFound
+			}
+			if seen[node.ID] {
+				return ErrMenuCycle
+			}
+			seen[node.ID] = true
+			placements = append(placements, menuPlacement{ID: node.ID, ParentID: parentID, Order: i + 1})
+
+			id := node.ID
+			if err := walk(node.Children, &id, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(nodes, nil, 1); err != nil {
+		return nil, err
+	}
+
+	if len(seen) != len(existing) {
+		return nil, ErrMenuTreeIncomplete
+	}
+	return placements, nil
+}
+
+// SaveMenuTree เขียน parent และ order ของทุกเมนูใหม่ตาม tree ใน transaction เดียว
+func SaveMenuTree(nodes []MenuTreeNode) error {
+	var ids []uint
+	if err := database.DB.Model(&models.Menu{}).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	existing := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		existing[id] = true
+	}
+
+	placements, err := flattenMenuTree(nodes, existing)
+	if err != nil {
+		return err
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, p := range placements {
+			if err := tx.Model(&models.Menu{}).Where("id = ?", p.ID).
+				Updates(map[string]interface{}{"parent_id": p.ParentID, "order": p.Order}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// checkMenuParent ตรวจว่าย้าย menuID (0 = เมนูใหม่) ไปอยู่ใต้ parentID ได้หรือไม่
+func checkMenuParent(menus []models.Menu, menuID uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+
+	parentOf := make(map[uint]*uint, len(menus))
+	for _, m := range menus {
+		parentOf[m.ID] = m.ParentID
+	}
+	if _, ok := parentOf[*parentID]; !ok {
+		return ErrMenuNotFound
+	}
+
+	// ชั้นของ parent (นับจาก root = 1)
+	depth := 0
+	for next := parentID; next != nil; next = parentOf[*next] {
+		if menuID != 0 && *next == menuID {
+			return ErrMenuCycle
+		}
+		depth++
+		if depth > MaxMenuDepth {
+			return ErrMenuTooDeep // ข้อมูลเก่าที่วนอยู่หรือลึกเกิน
+		}
+	}
+
+	// ความสูงของ subtree ของเมนูที่ย้าย (ตัวเอง = 1)
+	height := 1
+	if menuID != 0 {
+		height = menuSubtreeHeight(menus, menuID, 0)
+	}
+	if depth+height > MaxMenuDepth {
+		return ErrMenuTooDeep
+	}
+	return nil
+}
+
+func menuSubtreeHeight(menus []models.Menu, menuID uint, level int) int {
+	if level > MaxMenuDepth {
+		return level // กันข้อมูลที่วนอยู่
+	}
+	height := 1
+	for _, m := range menus {
+		if m.ParentID != nil && *m.ParentID == menuID {
+			if h := menuSubtreeHeight(menus, m.ID, level+1) + 1; h > height {
+				height = h
+			}
+		}
+	}
+	return height
+}
+
+// ValidateMenuParent ตรวจ parent ของเมนูก่อนสร้าง/แก้ไข (กันวนและลึกเกิน MaxMenuDepth)
+func ValidateMenuParent(menuID uint, parentID *uint) error {
+	var menus []models.Menu
+	if err := database.DB.Select("id", "parent_id").Find(&menus).Error; err != nil {
+		return err
+	}
+	return checkMenuParent(menus, menuID, parentID)
+}
+
+// DeleteMenu ลบเมนู และจัดการ children ตาม mode (MenuDeleteReparent / MenuDeleteCascade)
+// คืนจำนวนเมนูที่ถูกลบทั้งหมด
+func DeleteMenu(menu *models.Menu, mode string) (int, error) {
+	var menus []models.Menu
+	if err := database.DB.Select("id", "parent_id", "order").Find(&menus).Error; err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if mode == MenuDeleteCascade {
+			ids := menuDescendants(menus, menu.ID)
+			ids = append(ids, menu.ID)
+			deleted = len(ids)
+			if err := tx.Where("menu_id IN ?", ids).Delete(&models.MenuTranslation{}).Error; err != nil {
+				return err
+			}
+			return tx.Where("id IN ?", ids).Delete(&models.Menu{}).Error
+		}
+
+		// ต่อท้าย children ไว้หลังเมนูพี่น้องเดิมใต้ parent ใหม่
+		maxOrder := 0
+		for _, m := range menus {
+			if sameMenuParent(m.ParentID, menu.ParentID) && m.ID != menu.ID && m.Order > maxOrder {
+				maxOrder = m.Order
+			}
+		}
+		var children []models.Menu
+		for _, m := range menus {
+			if m.ParentID != nil && *m.ParentID == menu.ID {
+				children = append(children, m)
+			}
+		}
+		sortMenus(children)
+		for i, child := range children {
+			if err := tx.Model(&models.Menu{}).Where("id = ?", child.ID).
+				Updates(map[string]interface{}{"parent_id": menu.ParentID, "order": maxOrder + i + 1}).Error; err != nil {
+				return err
+			}
+		}
+		deleted = 1
+		if err := tx.Where("menu_id = ?", menu.ID).Delete(&models.MenuTranslation{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(menu).Error
+	})
+	return deleted, err
+}
+
+// menuDescendants คืน id ของเมนูลูกหลานทั้งหมด
+func menuDescendants(menus []models.Menu, menuID uint) []uint {
+	var ids []uint
+	seen := map[uint]bool{menuID: true}
+	queue := []uint{menuID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, m := range menus {
+			if m.ParentID != nil && *m.ParentID == current && !seen[m.ID] {
+				seen[m.ID] = true
+				ids = append(ids, m.ID)
+				queue = append(queue, m.ID)
+			}
+		}
+	}
+	return ids
+}
+
+func sameMenuParent(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"backend/internal/models"
+	"errors"
+	"testing"
+)
+
+func menuParent(id uint) *uint { return &id }
+
+func testMenus() []models.Menu {
+	return []models.Menu{
+		{ID: 1, Title: "Dashboard", Path: "/admin", PermissionSlug: "dashboard.view", Order: 1, IsActive: true},
+		{ID: 2, Title: "HR", Path: "#", Order: 2, IsActive: true},
+		{ID: 3, Title: "Employees", Path: "/admin/employees", PermissionSlug: "employees.view", ParentID: menuParent(2), Order: 2, IsActive: true},
+		{ID: 4, Title: "Leaves", Path: "/admin/leaves", PermissionSlug: "leaves.view", ParentID: menuParent(2), Order: 1, IsActive: true},
+		{ID: 5, Title: "Leave Types", Path: "/admin/leaves/types", PermissionSlug: "leaves.manage", ParentID: menuParent(4), Order: 1, IsActive: true},
+	}
+}
+
+func TestBuildMenuTree(t *testing.T) {
+	tree := BuildMenuTree(testMenus())
+	if len(tree) != 2 || tree[0].ID != 1 || tree[1].ID != 2 {
+		t.Fatalf("unexpected roots %+v", tree)
+	}
+	hr := tree[1]
+	if len(hr.Children) != 2 || hr.Children[0].ID != 4 || hr.Children[1].ID != 3 {
+		t.Fatalf("children should be sorted by order, got %+v", hr.Children)
+	}
+	if len(hr.Children[0].Children) != 1 || hr.Children[0].Children[0].ID != 5 {
+		t.Errorf("expected Leave Types under Leaves, got %+v", hr.Children[0].Children)
+	}
+}
+
+func TestFilterMenuTree(t *testing.T) {
+	tree := BuildMenuTree(testMenus())
+
+	// ไม่มี leaves.view - Leave Types ใต้ Leaves ต้องหายไปด้วยแม้มี leaves.manage
+	got := FilterMenuTree(tree, map[string]bool{"dashboard.view": true, "leaves.manage": true})
+	if len(got) != 1 || got[0].ID != 1 {
+		t.Fatalf("group without visible children should be hidden, got %+v", got)
+	}
+
+	got = FilterMenuTree(tree, map[string]bool{"leaves.view": true})
+	if len(got) != 1 || got[0].ID != 2 || len(got[0].Children) != 1 || got[0].Children[0].ID != 4 {
+		t.Fatalf("unexpected tree %+v", got)
+	}
+	if len(got[0].Children[0].Children) != 0 {
+		t.Error("Leave Types requires leaves.manage")
+	}
+}
+
+func TestFlattenMenuTree(t *testing.T) {
+	existing := map[uint]bool{1: true, 2: true, 3: true}
+
+	placements, err := flattenMenuTree([]MenuTreeNode{
+		{ID: 2, Children: []MenuTreeNode{{ID: 1}}},
+		{ID: 3},
+	}, existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := map[uint]menuPlacement{}
+	for _, p := range placements {
+		byID[p.ID] = p
+	}
+	if byID[1].ParentID == nil || *byID[1].ParentID != 2 || byID[1].Order != 1 {
+		t.Errorf("menu 1 should be first child of 2, got %+v", byID[1])
+	}
+	if byID[3].ParentID != nil || byID[3].Order != 2 {
+		t.Errorf("menu 3 should be second root, got %+v", byID[3])
+	}
+
+	tests := []struct {
+		name  string
+		nodes []MenuTreeNode
+		want  error
+	}{
+		{"duplicate", []MenuTreeNode{{ID: 1, Children: []MenuTreeNode{{ID: 2, Children: []MenuTreeNode{{ID: 1}}}}}, {ID: 3}}, ErrMenuCycle},
+		{"unknown", []MenuTreeNode{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 9}}, ErrMenuNotFound},
+		{"missing", []MenuTreeNode{{ID: 1}, {ID: 2}}, ErrMenuTreeIncomplete},
+		{"too deep", []MenuTreeNode{{ID: 1, Children: []MenuTreeNode{{ID: 2, Children: []MenuTreeNode{{ID: 3, Children: []MenuTreeNode{{ID: 4}}}}}}}}, ErrMenuTooDeep},
+	}
+	existing[4] = true
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := flattenMenuTree(tt.nodes, existing); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckMenuParent(t *testing.T) {
+	menus := testMenus()
+
+	if err := checkMenuParent(menus, 2, menuParent(5)); !errors.Is(err, ErrMenuCycle) {
+		t.Errorf("moving HR under its grandchild: err = %v, want cycle", err)
+	}
+	if err := checkMenuParent(menus, 0, menuParent(5)); !errors.Is(err, ErrMenuTooDeep) {
+		t.Errorf("new menu under level 3: err = %v, want too deep", err)
+	}
+	if err := checkMenuParent(menus, 4, menuParent(1)); err != nil {
+		t.Errorf("moving Leaves (height 2) under Dashboard: err = %v", err)
+	}
+	if err := checkMenuParent(menus, 2, menuParent(1)); !errors.Is(err, ErrMenuTooDeep) {
+		t.Errorf("moving HR (height 3) under Dashboard: err = %v, want too deep", err)
+	}
+	if err := checkMenuParent(menus, 1, menuParent(99)); !errors.Is(err, ErrMenuNotFound) {
+		t.Errorf("unknown parent: err = %v, want not found", err)
+	}
+}
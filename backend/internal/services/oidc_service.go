@@ -0,0 +1,355 @@
+package services
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/pkg/oidc"
+	"backend/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// OAuthStateTTL เวลาที่ผู้ใช้มีให้ login ที่ provider ให้เสร็จ
+const OAuthStateTTL = 10 * time.Minute
+
+var (
+	ErrOIDCProviderNotFound    = utils.ErrOIDCProviderNotFound
+	ErrOIDCStateInvalid        = utils.ErrOIDCStateInvalid
+	ErrOIDCEmailNotVerified    = utils.ErrOIDCEmailNotVerified
+	ErrOIDCDomainNotAllowed    = utils.ErrOIDCDomainNotAllowed
+	ErrOIDCNoAccount           = utils.ErrOIDCNoAccount
+	ErrOIDCIdentityNotFound    = utils.ErrOIDCIdentityNotFound
+	ErrOIDCProviderUnavailable = utils.ErrOIDCProviderUnavailable
+	ErrOIDCAccountUnverified   = utils.ErrOIDCAccountUnverified
+)
+
+var (
+	oidcMu        sync.Mutex
+	oidcConfigs   = map[string]oidc.ProviderConfig{}
+	oidcProviders = map[string]*oidc.Provider{} // cache หลัง discovery สำเร็จ
+	oidcOrder     []string
+	// oidcDiscovering lock ต่อ provider ระหว่าง discovery (ไม่ถือ oidcMu ระหว่างรอ network)
+	oidcDiscovering = map[string]*sync.Mutex{}
+)
+
+// InitOIDCProviders โหลด provider config จาก environment (discovery ทำตอนใช้งานครั้งแรก)
+func InitOIDCProviders() {
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+
+	oidcConfigs = map[string]oidc.ProviderConfig{}
+	oidcProviders = map[string]*oidc.Provider{}
+	oidcDiscovering = map[string]*sync.Mutex{}
+	oidcOrder = nil
+	for _, cfg := range oidc.LoadProviderConfigs() {
+		oidcConfigs[cfg.Name] = cfg
+		oidcOrder = append(oidcOrder, cfg.Name)
+		log.Printf("OIDC provider configured: %s (%s)", cfg.Name, cfg.Issuer)
+	}
+}
+
+// OIDCProviders รายการ provider ที่เปิดใช้ (สำหรับแสดงปุ่ม login)
+func OIDCProviders() []oidc.ProviderConfig {
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+
+	result := make([]oidc.ProviderConfig, 0, len(oidcOrder))
+	for _, name := range oidcOrder {
+		result = append(result, oidcConfigs[name])
+	}
+	return result
+}
+
+// getOIDCProvider คืน provider ที่ผ่าน discovery แล้ว (discovery ครั้งแรกที่ใช้งาน)
+// discovery ใช้เวลาได้หลายวินาที - ถือเฉพาะ lock ของ provider นั้น provider อื่นจึงไม่ต้องรอ
+func getOIDCProvider(ctx context.Context, name string) (*oidc.Provider, error) {
+	oidcMu.Lock()
+	if p, ok := oidcProviders[name]; ok {
+		oidcMu.Unlock()
+		return p, nil
+	}
+	cfg, ok := oidcConfigs[name]
+	if !ok {
+		oidcMu.Unlock()
+		return nil, ErrOIDCProviderNotFound
+	}
+	discovering, ok := oidcDiscovering[name]
+	if !ok {
+		discovering = &sync.Mutex{}
+		oidcDiscovering[name] = discovering
+	}
+	oidcMu.Unlock()
+
+	// request ที่รอ lock อยู่ใช้ผลของ discovery ที่เพิ่งเสร็จ
+	discovering.Lock()
+	defer discovering.Unlock()
+	oidcMu.Lock()
+	p, ok := oidcProviders[name]
+	oidcMu.Unlock()
+	if ok {
+		return p, nil
+	}
+
+	p, err := oidc.Discover(ctx, cfg, nil)
+	if err != nil {
+		log.Printf("OIDC discovery failed: %v", err)
+		return nil, ErrOIDCProviderUnavailable
+	}
+
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+	// provider ถูกเอาออกระหว่าง discovery (InitOIDCProviders ใหม่) - ไม่ cache
+	if _, ok := oidcConfigs[name]; ok {
+		oidcProviders[name] = p
+	}
+	return p, nil
+}
+
+// StartOIDCLogin สร้าง state / nonce / PKCE verifier และคืน URL ของ provider
+func StartOIDCLogin(ctx context.Context, providerName string) (string, error) {
+	provider, err := getOIDCProvider(ctx, providerName)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := oidc.GenerateCodeVerifier()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidc.GenerateCodeVerifier()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := oidc.GenerateCodeVerifier()
+	if err != nil {
+		return "", err
+	}
+
+	// ลบ state ที่หมดอายุไปแล้ว
+	database.DB.Where("expires_at < ?", time.Now()).Delete(&models.OAuthState{})
+
+	record := models.OAuthState{
+		StateHash:    utils.HashToken(state),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(OAuthStateTTL),
+	}
+	if err := database.DB.Create(&record).Error; err != nil {
+		return "", err
+	}
+
+	return provider.AuthCodeURL(state, nonce, oidc.CodeChallengeS256(verifier)), nil
+}
+
+// OIDCLoginResult ผลของการ login ผ่าน OIDC (ใช้บันทึก audit log)
+type OIDCLoginResult struct {
+	Provider    string
+	Email       string
+	Linked      bool // ผูก identity กับ user เดิมด้วย verified email ในครั้งนี้
+	Provisioned bool // สร้าง user ใหม่อัตโนมัติ
+}
+
+// CompleteOIDCLogin แลก code, ตรวจ ID token และหา / ผูก / สร้าง user
+// คืน user ที่ preload Role.Permissions แล้ว (พร้อมส่งต่อให้ beginLogin)
+func CompleteOIDCLogin(ctx context.Context, providerName, code, state string) (*models.User, *OIDCLoginResult, error) {
+	result := &OIDCLoginResult{Provider: providerName}
+
+	// state ใช้ได้ครั้งเดียว - ลบทิ้งทันที (conditional delete กัน request ซ้อน)
+	var record models.OAuthState
+	if err := database.DB.Where("state_hash = ? AND provider = ?", utils.HashToken(state), providerName).First(&record).Error; err != nil {
+		return nil, result, ErrOIDCStateInvalid
+	}
+	deleted := database.DB.Where("id = ?", record.ID).Delete(&models.OAuthState{})
+	if deleted.Error != nil || deleted.RowsAffected == 0 || time.Now().After(record.ExpiresAt) {
+		return nil, result, ErrOIDCStateInvalid
+	}
+
+	provider, err := getOIDCProvider(ctx, providerName)
+	if err != nil {
+		return nil, result, err
+	}
+
+	token, err := provider.Exchange(ctx, code, record.CodeVerifier)
+	if err != nil {
+		return nil, result, fmt.Errorf("code exchange failed: %w", err)
+	}
+	claims, err := provider.VerifyIDToken(ctx, token.IDToken, record.Nonce)
+	if err != nil {
+		return nil, result, err
+	}
+	result.Email = claims.Email
+
+	cfg := provider.Config
+	if !utils.EmailDomainAllowed(claims.Email, cfg.AllowedDomains) {
+		return nil, result, ErrOIDCDomainNotAllowed
+	}
+
+	user, err := resolveOIDCUser(cfg, claims, result)
+	if err != nil {
+		return nil, result, err
+	}
+
+	now := time.Now()
+	database.DB.Model(&models.UserIdentity{}).
+		Where("provider = ? AND subject = ?", providerName, claims.Subject).
+		Updates(map[string]interface{}{"last_login_at": now, "email": claims.Email})
+
+	if err := database.DB.Preload("Role.Permissions").First(user, user.ID).Error; err != nil {
+		return nil, result, err
+	}
+	return user, result, nil
+}
+
+// resolveOIDCUser หา user จาก identity ที่ผูกไว้ → ผูกด้วย verified email → สร้างใหม่ (ถ้าเปิด AutoProvision)
+func resolveOIDCUser(cfg oidc.ProviderConfig, claims *oidc.IDTokenClaims, result *OIDCLoginResult) (*models.User, error) {
+	var identity models.UserIdentity
+	if err := database.DB.Where("provider = ? AND subject = ?", cfg.Name, claims.Subject).First(&identity).Error; err == nil {
+		var user models.User
+		if err := database.DB.First(&user, identity.UserID).Error; err != nil {
+			return nil, ErrOIDCNoAccount
+		}
+		return &user, nil
+	}
+
+	// ผูกบัญชีด้วย email ได้เฉพาะเมื่อ provider ยืนยัน email แล้ว (กันการยึดบัญชีด้วย email ปลอม)
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	var user models.User
+	err := database.DB.Where("LOWER(email) = ?", strings.ToLower(claims.Email)).First(&user).Error
+	if err == nil {
+		// ผูกอัตโนมัติเฉพาะบัญชีที่ยืนยัน email แล้ว - บัญชีที่ยังไม่ยืนยันอาจถูกสมัครดักไว้ด้วย email ของผู้อื่น
+		// (ถ้าผูกให้ รหัสผ่านของผู้สมัครดักจะยัง login บัญชีเดียวกันได้)
+		if !user.EmailVerified {
+			return nil, ErrOIDCAccountUnverified
+		}
+		if err := linkIdentity(database.DB, &user, cfg.Name, claims); err != nil {
+			return nil, err
+		}
+		result.Linked = true
+		return &user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if !cfg.AutoProvision {
+		return nil, ErrOIDCNoAccount
+	}
+	if err := provisionOIDCUser(cfg, claims, &user); err != nil {
+		return nil, err
+	}
+	result.Provisioned = true
+	return &user, nil
+}
+
+func linkIdentity(tx *gorm.DB, user *models.User, provider string, claims *oidc.IDTokenClaims) error {
+	identity := models.UserIdentity{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}
+	return tx.Create(&identity).Error
+}
+
+var usernameInvalidChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// provisionOIDCUser สร้าง user ใหม่จาก ID token (just-in-time provisioning)
+// รหัสผ่านเป็นค่าสุ่มที่ไม่มีใครรู้ - login ได้ผ่าน provider หรือ forgot password เท่านั้น
+// ผ่านนโยบายการสมัครเดียวกับการสมัครเอง (registration_mode / registration_allowed_domains)
+func provisionOIDCUser(cfg oidc.ProviderConfig, claims *oidc.IDTokenClaims, user *models.User) error {
+	approval, err := SelfRegistrationStatus(claims.Email)
+	if err != nil {
+		return err
+	}
+
+	randomPassword, err := utils.GenerateResetToken()
+	if err != nil {
+		return err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(randomPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	*user = models.User{
+		Username:        uniqueUsername(claims.Email),
+		Password:        string(hashed),
+		Email:           claims.Email,
+		FirstName:       claims.GivenName,
+		LastName:        claims.FamilyName,
+		Active:          true,
+		EmailVerified:   true,
+		EmailVerifiedAt: &now,
+		ApprovalStatus:  approval,
+	}
+	if user.FirstName == "" && user.LastName == "" {
+		user.FirstName = claims.Name
+	}
+
+	var role models.Role
+	if err := database.DB.Where("name = ?", cfg.DefaultRole).First(&role).Error; err == nil {
+		user.RoleID = &role.ID
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return linkIdentity(tx, user, cfg.Name, claims)
+	})
+}
+
+// uniqueUsername สร้าง username จากส่วนหน้าของ email (เติมตัวเลขถ้าซ้ำ)
+func uniqueUsername(email string) string {
+	base := strings.ToLower(email)
+	if at := strings.Index(base, "@"); at > 0 {
+		base = base[:at]
+	}
+	base = strings.Trim(usernameInvalidChars.ReplaceAllString(base, ""), ".-_")
+	if base == "" {
+		base = "user"
+	}
+
+	candidate := base
+	for i := 2; ; i++ {
+		var count int64
+		database.DB.Model(&models.User{}).Where("username = ?", candidate).Count(&count)
+		if count == 0 {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s%d", base, i)
+	}
+}
+
+// ListUserIdentities บัญชีภายนอกที่ผูกกับ user
+func ListUserIdentities(userID uint) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	err := database.DB.Where("user_id = ?", userID).Order("created_at asc").Find(&identities).Error
+	return identities, err
+}
+
+// UnlinkUserIdentity ยกเลิกการผูกบัญชีภายนอก
+func UnlinkUserIdentity(userID, identityID uint) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	if err := database.DB.Where("id = ? AND user_id = ?", identityID, userID).First(&identity).Error; err != nil {
+		return nil, ErrOIDCIdentityNotFound
+	}
+	if err := database.DB.Delete(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/pkg/utils"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// PasswordPolicyError รหัสผ่านไม่ผ่าน policy - Messages ใช้แสดงผู้ใช้
+type PasswordPolicyError struct {
+	Messages []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password does not meet policy requirements"
+}
+
+const (
+	msgPasswordBreached = "This password has appeared in a data breach, please choose a different one"
+	msgPasswordReused   = "Password was used recently, please choose a different one"
+)
+
+func settingInt(key string, fallback int) int {
+	n, err := strconv.Atoi(GetSetting(key, strconv.Itoa(fallback)))
+	if err != nil || n < 0 {
+		return fallback
+	}
+	return n
+}
+
+func settingBool(key string, fallback bool) bool {
+	b, err := strconv.ParseBool(GetSetting(key, strconv.FormatBool(fallback)))
+	if err != nil {
+		return fallback
+	}
+	return b
+}
+
+// CurrentPasswordPolicy อ่าน password policy จาก settings (group "security")
+func CurrentPasswordPolicy() utils.PasswordPolicy {
+	def := utils.DefaultPasswordPolicy()
+	return utils.PasswordPolicy{
+		MinLength:      settingInt("password_min_length", def.MinLength),
+		RequireLower:   settingBool("password_require_lowercase", def.RequireLower),
+		RequireUpper:   settingBool("password_require_uppercase", def.RequireUpper),
+		RequireDigit:   settingBool("password_require_digit", def.RequireDigit),
+		RequireSpecial: settingBool("password_require_special", def.RequireSpecial),
+		MaxAgeDays:     settingInt("password_max_age_days", def.MaxAgeDays),
+		HistoryDepth:   settingInt("password_history_depth", def.HistoryDepth),
+		CheckBreached:  settingBool("password_breach_check", def.CheckBreached),
+	}
+}
+
+// ValidateNewPassword ตรวจรหัสผ่านใหม่ตาม policy, breached list และ history
+// user = nil สำหรับ user ที่ยังไม่ถูกสร้าง (ไม่มี history ให้ตรวจ)
+// คืน *PasswordPolicyError ถ้าไม่ผ่าน
+func ValidateNewPassword(user *models.User, password string) error {
+	policy := CurrentPasswordPolicy()
+
+	result := utils.ValidatePasswordPolicy(password, policy)
+	if !result.IsValid {
+		return &PasswordPolicyError{Messages: result.Messages}
+	}
+
+	if policy.CheckBreached {
+		breached, err := utils.IsPasswordBreached(os.Getenv("PASSWORD_BREACH_DIR"), password)
+		if err != nil {
+			// รายการเสียหาย/อ่านไม่ได้ไม่ควรทำให้เปลี่ยนรหัสผ่านไม่ได้ทั้งระบบ
+			log.Printf("Breached password check failed: %v", err)
+		} else if breached {
+			return &PasswordPolicyError{Messages: []string{msgPasswordBreached}}
+		}
+	}
+
+	if user != nil && user.ID != 0 && policy.HistoryDepth > 0 {
+		if passwordReused(user, password, policy.HistoryDepth) {
+			return &PasswordPolicyError{Messages: []string{msgPasswordReused}}
+		}
+	}
+
+	return nil
+}
+
+// passwordReused ตรวจรหัสผ่านปัจจุบันและ history ล่าสุด depth รายการ
+func passwordReused(user *models.User, password string, depth int) bool {
+	hashes := []string{user.Password}
+	var history []models.PasswordHistory
+	database.DB.Where("user_id = ?", user.ID).Order("created_at DESC, id DESC").Limit(depth).Find(&history)
+	for _, h := range history {
+		hashes = append(hashes, h.PasswordHash)
+	}
+
+	for _, hash := range hashes {
+		if hash != "" && bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// SetUserPassword hash รหัสผ่านใหม่ บันทึกรหัสผ่านเดิมลง history และเริ่มนับอายุใหม่
+// mustChange = true เมื่อ admin เป็นผู้ตั้ง (ผู้ใช้ต้องเปลี่ยนเองตอน login ครั้งถัดไป)
+// ต้องเรียก ValidateNewPassword ก่อน - ฟังก์ชันนี้ไม่ตรวจ policy
+func SetUserPassword(tx *gorm.DB, user *models.User, password string, mustChange bool) error {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	if user.ID != 0 && user.Password != "" {
+		if err := tx.Create(&models.PasswordHistory{UserID: user.ID, PasswordHash: user.Password}).Error; err != nil {
+			return err
+		}
+		if err := prunePasswordHistory(tx, user.ID); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	user.Password = string(hashed)
+	user.PasswordChangedAt = &now
+	user.MustChangePassword = mustChange
+
+	if user.ID == 0 {
+		return nil // user ใหม่ - caller เป็นผู้ Create
+	}
+	return tx.Model(user).Updates(map[string]interface{}{
+		"password":             user.Password,
+		"password_changed_at":  now,
+		"must_change_password": mustChange,
+	}).Error
+}
+
+// prunePasswordHistory เก็บ history ไว้เท่าที่ policy ต้องใช้
+func prunePasswordHistory(tx *gorm.DB, userID uint) error {
+	keep := CurrentPasswordPolicy().HistoryDepth
+	var ids []uint
+	if err := tx.Model(&models.PasswordHistory{}).Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").Offset(keep).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return tx.Delete(&models.PasswordHistory{}, ids).Error
+}
+
+// PasswordChangeRequired รหัสผ่านหมดอายุ (password_max_age_days) หรือถูกบังคับให้เปลี่ยน
+func PasswordChangeRequired(user *models.User) bool {
+	if user.MustChangePassword {
+		return true
+	}
+	maxAge := settingInt("password_max_age_days", 0)
+	if maxAge == 0 || user.PasswordChangedAt == nil {
+		return false
+	}
+	return time.Since(*user.PasswordChangedAt) > time.Duration(maxAge)*24*time.Hour
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/pkg/utils"
+	"log"
+	"os"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrProjectStatus        = utils.ErrProjectStatusInvalid
+	ErrProjectPublishAt     = utils.ErrProjectPublishAtInvalid
+	ErrProjectPublishDenied = utils.ErrProjectPublishDenied
+)
+
+// PublishedProjects จำกัด query ให้เหลือเฉพาะ project ที่หน้าเว็บสาธารณะเห็นได้ ณ เวลา now
+// เงื่อนไขเดียวกับ Project.IsPublic - ไม่ต้องรอ scheduler ก็แสดงตรงเวลา
+func PublishedProjects(query *gorm.DB, now time.Time) *gorm.DB {
+	return query.Where("is_active = ? AND publish_status = ? AND (publish_at IS NULL OR publish_at <= ?)", true, models.ProjectPublished, now)
+}
+
+// ChangeProjectStatus เปลี่ยนสถานะการเผยแพร่ของ project
+// published: publishAt = nil หรือเวลาที่ผ่านมาแล้วคือเผยแพร่ทันที, เวลาในอนาคตคือตั้งเวลาเผยแพร่
+// สถานะอื่น: ล้างเวลาเผยแพร่ทิ้ง
+func ChangeProjectStatus(tx *gorm.DB, project *models.Project, status string, publishAt *time.Time, now time.Time) error {
+	if !models.IsValidProjectStatus(status) {
+		return ErrProjectStatus
+	}
+	if publishAt != nil && status != models.ProjectPublished {
+		return ErrProjectPublishAt
+	}
+
+	project.PublishStatus = status
+	project.PublishAt = nil
+	project.PublishedAt = nil
+	if status == models.ProjectPublished {
+		at := now
+		if publishAt != nil {
+			at = *publishAt
+		}
+		project.PublishAt = &at
+		if !at.After(now) {
+			project.PublishedAt = &now
+		}
+	}
+
+	return tx.Model(project).Updates(map[string]interface{}{
+		"publish_status": project.PublishStatus,
+		"publish_at":     project.PublishAt,
+		"published_at":   project.PublishedAt,
+	}).Error
+}
+
+// PublishScheduledProjects ปิดงาน project ที่ตั้งเวลาเผยแพร่ไว้และถึงเวลาแล้ว (ตั้ง published_at)
+// คืนรายการ project ที่เพิ่งขึ้นหน้าเว็บเพื่อบันทึก audit log
+func PublishScheduledProjects() ([]models.Project, error) {
+	now := time.Now()
+	var due []models.Project
+	if err := database.DB.
+		Where("publish_status = ? AND published_at IS NULL AND publish_at <= ?", models.ProjectPublished, now).
+		Find(&due).Error; err != nil {
+		return nil, err
+	}
+
+	var result []models.Project
+	for _, project := range due {
+		// conditional update กันทำซ้ำถ้ามีหลาย instance รัน job พร้อมกัน
+		res := database.DB.Model(&models.Project{}).
+			Where("id = ? AND publish_status = ? AND published_at IS NULL", project.ID, models.ProjectPublished).
+			Update("published_at", now)
+		if res.Error != nil {
+			return result, res.Error
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+		project.PublishedAt = &now
+		result = append(result, project)
+	}
+	return result, nil
+}
+
+// StartProjectPublishScheduler เริ่ม cron job เผยแพร่ project ที่ตั้งเวลาไว้ (default ทุก 1 นาที)
+// override ได้ผ่าน environment variable PROJECT_PUBLISH_SCHEDULE
+func StartProjectPublishScheduler() *cron.Cron {
+	schedule := os.Getenv("PROJECT_PUBLISH_SCHEDULE")
+	if schedule == "" {
+		schedule = "@every 1m"
+	}
+
+	scheduler := cron.New()
+	_, err := scheduler.AddFunc(schedule, func() {
+		published, err := PublishScheduledProjects()
+		if err != nil {
+			log.Printf("[ProjectPublish] Error publishing scheduled projects: %v", err)
+		}
+		for _, project := range published {
+			CreateSystemAuditLog("PROJECT_PUBLISHED", project.ID, "project", map[string]interface{}{
+				"title":      project.Title,
+				"slug":       project.Slug,
+				"publish_at": project.PublishAt,
+			}, 0)
+		}
+	})
+	if err != nil {
+		log.Printf("[ProjectPublish] Could not schedule publish job: %v", err)
+		return nil
+	}
+
+	scheduler.Start()
+	log.Printf("[ProjectPublish] Scheduler started - schedule: %s", schedule)
+	return scheduler
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/pkg/utils"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrProjectRevisionNotFound = utils.ErrProjectRevisionNotFound
+
+// RevisionAuthor ผู้แก้ไขที่บันทึกใน revision (ID = 0 คือระบบ)
+type RevisionAuthor struct {
+	ID       uint
+	Username string
+}
+
+// RecordProjectRevision บันทึก snapshot ของ project เป็น revision ถัดไป
+// ต้องเรียกใน transaction เดียวกับการแก้ไข - โหลด project ใหม่พร้อม lock แถว
+// (snapshot ตรงกับที่บันทึกจริง และเลข revision ไม่ชนกันเมื่อแก้พร้อมกัน)
+func RecordProjectRevision(tx *gorm.DB, project *models.Project, action string, author RevisionAuthor, restoredFrom *int) (*models.ProjectRevision, error) {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(project, project.ID).Error; err != nil {
+		return nil, err
+	}
+
+	var last int
+	if err := tx.Model(&models.ProjectRevision{}).Where("project_id = ?", project.ID).
+		Select("COALESCE(MAX(revision), 0)").Scan(&last).Error; err != nil {
+		return nil, err
+	}
+
+	revision := models.ProjectRevision{
+		ProjectID:    project.ID,
+		Revision:     last + 1,
+		Action:       action,
+		Snapshot:     project.Snapshot(),
+		RestoredFrom: restoredFrom,
+		Author:       author.Username,
+	}
+	if author.ID != 0 {
+		revision.AuthorID = &author.ID
+	}
+	if err := tx.Create(&revision).Error; err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// FindProjectRevision หา revision ตามเลขของ project
+func FindProjectRevision(tx *gorm.DB, projectID uint, revision int) (*models.ProjectRevision, error) {
+	var rev models.ProjectRevision
+	err := tx.Where("project_id = ? AND revision = ?", projectID, revision).First(&rev).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrProjectRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// RestoreProjectRevision นำเนื้อหาของ revision กลับมาใช้ และบันทึกเป็น revision ใหม่ (action = restore)
+// สถานะการเผยแพร่ไม่ถูกย้อน (ต้องผ่าน PUT /projects/:id/status ที่ตรวจสิทธิ์ projects.publish)
+// slug เดิมต้องยังว่าง และหมวดหมู่ที่ถูกลบไปแล้วจะคงหมวดหมู่ปัจจุบันไว้
+func RestoreProjectRevision(tx *gorm.DB, project *models.Project, rev *models.ProjectRevision, author RevisionAuthor) (*models.ProjectRevision, error) {
+	snap := rev.Snapshot
+
+	if snap.Slug != "" && snap.Slug != project.Slug {
+		slug, err := NormalizeProjectSlug(tx, snap.Slug, project.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := ChangeProjectSlug(tx, project, slug); err != nil {
+			return nil, err
+		}
+	}
+
+	category, err := ResolveProjectCategory(tx, snap.CategoryID, snap.Category)
+	switch {
+	case err == nil:
+		project.SetCategory(category)
+	case !errors.Is(err, ErrProjectCategory):
+		return nil, err
+	}
+
+	project.Title = snap.Title
+	project.Location = snap.Location
+	project.LocationMapLink = snap.LocationMapLink
+	project.Owner = snap.Owner
+	project.Images = snap.Images
+	project.Description = snap.Description
+	project.Status = snap.Status
+	project.SortOrder = snap.SortOrder
+	project.IsActive = snap.IsActive
+
+	// Select ทุก field ที่ restore - ค่าว่าง / false ต้องถูกเขียนทับด้วย
+	if err := tx.Model(project).
+		Select("title", "location", "location_map_link", "owner", "category", "category_id", "images", "description", "status", "sort_order", "is_active").
+		Updates(project).Error; err != nil {
+		return nil, err
+	}
+
+	return RecordProjectRevision(tx, project, models.ProjectRevisionRestore, author, &rev.Revision)
+}
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/pkg/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrProjectSlugTaken   = utils.ErrProjectSlugTaken
+	ErrProjectSlugInvalid = utils.ErrProjectSlugInvalid
+	ErrProjectCategory    = utils.ErrCategoryNotFound.WithStatus(http.StatusBadRequest) // หมวดหมู่ที่ส่งมากับ project ไม่มีอยู่
+	ErrProjectSort        = utils.ErrProjectSortInvalid
+)
+
+// ProjectFilter ตัวกรองรายการ projects (ค่าว่าง = ไม่กรอง)
+type ProjectFilter struct {
+	Public        bool  // หน้าเว็บสาธารณะ - เฉพาะ project ที่เผยแพร่แล้ว (ดู PublishedProjects)
+	Active        *bool // admin เท่านั้น
+	PublishStatus string
+	Category      string // category slug
+	Status        string
+	Owner         string
+	Location      string
+	Year          int // ปีที่ลง project (created_at)
+	Search        string
+	Sort          string
+}
+
+// projectSorts ตัวเลือก sort ของรายการ projects
+var projectSorts = map[string]string{
+	"order":  "sort_order asc, id asc",
+	"newest": "created_at desc, id desc",
+	"oldest": "created_at asc, id asc",
+	"title":  "title asc, id asc",
+	"-title": "title desc, id desc",
+}
+
+// ProjectSortRelevance เรียงตามความตรงกับคำค้นหา (default เมื่อมี search)
+const ProjectSortRelevance = "relevance"
+
+// projectSlugFallback ใช้เมื่อชื่อ project ถอดเป็น slug ไม่ได้เลย (เช่น มีแต่สัญลักษณ์)
+const projectSlugFallback = "project"
+
+// projectSlugOwner คืน id ของ project ที่จอง slug ไว้ (slug ปัจจุบันหรือใน history) - 0 ถ้ายังว่าง
+func projectSlugOwner(tx *gorm.DB, slug string) (uint, error) {
+	var project models.Project
+	err := tx.Select("id").Where("slug = ?", slug).First(&project).Error
+	if err == nil {
+		return project.ID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	var history models.ProjectSlugHistory
+	err = tx.Where("slug = ?", slug).First(&history).Error
+	if err == nil {
+		return history.ProjectID, nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	return 0, err
+}
+
+// UniqueProjectSlug สร้าง slug จากข้อความที่ยังไม่ถูก project อื่นจอง (เติม -2, -3, ... ถ้าซ้ำ)
+// projectID คือ project ที่จะใช้ slug (0 = project ใหม่)
+func UniqueProjectSlug(tx *gorm.DB, text string, projectID uint) (string, error) {
+	base := utils.Slugify(text)
+	if base == "" {
+		base = projectSlugFallback
+	}
+
+	slug := base
+	for n := 2; ; n++ {
+		owner, err := projectSlugOwner(tx, slug)
+		if err != nil {
+			return "", err
+		}
+		if owner == 0 || owner == projectID {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// pgUniqueViolation SQLSTATE ของ postgres เมื่อ insert / update ชน unique index
+const pgUniqueViolation = "23505"
+
+// ProjectSlugConflict แปลง unique violation ตอนบันทึก project เป็น ErrProjectSlugTaken
+// UniqueProjectSlug / NormalizeProjectSlug ตรวจก่อนบันทึกโดยไม่ lock - request ที่ใช้ slug เดียวกันพร้อมกันผ่านการตรวจได้ทั้งคู่
+// แล้วตัวหลังไปชน unique index ของ projects.slug หรือ project_slug_histories.slug
+func ProjectSlugConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return ErrProjectSlugTaken
+	}
+	return err
+}
+
+// NormalizeProjectSlug แปลง slug ที่ผู้ใช้กรอกให้อยู่ในรูปแบบมาตรฐานและตรวจว่ายังว่าง
+func NormalizeProjectSlug(tx *gorm.DB, input string, projectID uint) (string, error) {
+	slug := utils.Slugify(input)
+	if !utils.IsValidSlug(slug) {
+		return "", ErrProjectSlugInvalid
+	}
+	owner, err := projectSlugOwner(tx, slug)
+	if err != nil {
+		return "", err
+	}
+	if owner != 0 && owner != projectID {
+		return "", ErrProjectSlugTaken
+	}
+	return slug, nil
+}
+
+// ChangeProjectSlug เปลี่ยน slug ของ project - slug เดิมย้ายไปเก็บใน history เพื่อให้ลิงก์เก่ายัง redirect ได้
+// slug ต้องผ่าน NormalizeProjectSlug มาก่อน
+func ChangeProjectSlug(tx *gorm.DB, project *models.Project, slug string) error {
+	if slug == project.Slug {
+		return nil
+	}
+
+	// กลับไปใช้ slug เก่าของตัวเอง - เอาออกจาก history
+	if err := tx.Where("project_id = ? AND slug = ?", project.ID, slug).Delete(&models.ProjectSlugHistory{}).Error; err != nil {
+		return err
+	}
+	if project.Slug != "" {
+		if err := tx.Create(&models.ProjectSlugHistory{ProjectID: project.ID, Slug: project.Slug}).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Model(project).Update("slug", slug).Error; err != nil {
+		return err
+	}
+	project.Slug = slug
+	return nil
+}
+
+// FindProjectBySlug หา project จาก slug ปัจจุบัน หรือ slug เดิมใน history
+// moved = true เมื่อพบจาก history (caller ควร redirect ไป slug ปัจจุบัน)
+// public = true หาเฉพาะ project ที่เผยแพร่แล้ว
+func FindProjectBySlug(tx *gorm.DB, slug string, public bool) (project *models.Project, moved bool, err error) {
+	projects := func() *gorm.DB {
+		if public {
+			return PublishedProjects(tx, time.Now())
+		}
+		return tx
+	}
+
+	project = &models.Project{}
+	err = projects().Where("slug = ?", slug).First(project).Error
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return project, false, err
+	}
+
+	var history models.ProjectSlugHistory
+	if err := tx.Where("slug = ?", slug).First(&history).Error; err != nil {
+		return nil, false, err
+	}
+	if err := projects().First(project, history.ProjectID).Error; err != nil {
+		return nil, false, err
+	}
+	return project, true, nil
+}
+
+// ResolveProjectCategory หาหมวดหมู่ของ project จาก category_id (0 = ไม่มีหมวดหมู่)
+// หรือจากชื่อ / slug สำหรับ client เดิมที่ยังส่ง category เป็นข้อความ - คืน nil เมื่อไม่มีหมวดหมู่
+func ResolveProjectCategory(tx *gorm.DB, categoryID *uint, name string) (*models.Category, error) {
+	var category models.Category
+	var err error
+	name = strings.TrimSpace(name)
+	switch {
+	case categoryID != nil:
+		if *categoryID == 0 {
+			return nil, nil
+		}
+		err = tx.First(&category, *categoryID).Error
+	case name != "":
+		err = tx.Where("LOWER(name) = LOWER(?) OR slug = ?", name, strings.ToLower(name)).First(&category).Error
+	default:
+		return nil, nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrProjectCategory
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// ReassignCategoryProjects ย้าย projects ทั้งหมดของหมวดหมู่ไปหมวดหมู่อื่น (to = nil คือไม่มีหมวดหมู่)
+// คืนจำนวน projects ที่ถูกย้าย
+func ReassignCategoryProjects(tx *gorm.DB, fromID uint, to *models.Category) (int64, error) {
+	var target models.Project
+	target.SetCategory(to)
+	result := tx.Model(&models.Project{}).Where("category_id = ?", fromID).
+		Updates(map[string]interface{}{"category_id": target.CategoryID, "category": target.Category})
+	return result.RowsAffected, result.Error
+}
+
+// FilterProjects ใส่เงื่อนไขของ filter ลงใน query
+// search ใช้ full-text (config simple - ตัดคำตามช่องว่าง) ร่วมกับ ILIKE ที่มี trigram index รองรับ
+// ภาษาไทยไม่เว้นวรรคระหว่างคำ full-text จึงหาคำกลางประโยคไม่เจอ - ILIKE ครอบส่วนนี้
+func FilterProjects(query *gorm.DB, f ProjectFilter) *gorm.DB {
+	if f.Public {
+		query = PublishedProjects(query, time.Now())
+	}
+	if f.Active != nil {
+		query = query.Where("is_active = ?", *f.Active)
+	}
+	if f.PublishStatus != "" {
+		query = query.Where("publish_status = ?", f.PublishStatus)
+	}
+	if f.Category != "" {
+		query = query.Where("category_id IN (SELECT id FROM categories WHERE slug = ?)", f.Category)
+	}
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+	if f.Owner != "" {
+		query = query.Where("owner ILIKE ?", containsPattern(f.Owner))
+	}
+	if f.Location != "" {
+		query = query.Where("location ILIKE ?", containsPattern(f.Location))
+	}
+	if f.Year > 0 {
+		query = query.Where("EXTRACT(YEAR FROM created_at) = ?", f.Year)
+	}
+	if search := strings.TrimSpace(f.Search); search != "" {
+		query = query.Where(
+			"to_tsvector('simple', "+models.ProjectSearchDocument+") @@ plainto_tsquery('simple', ?) OR ("+models.ProjectSearchDocument+") ILIKE ?",
+			search, containsPattern(search),
+		)
+	}
+	return query
+}
+
+// ProjectOrder คืน ORDER BY ของ sort ที่เลือก (ค่าว่าง = relevance ถ้ามี search ไม่งั้นตาม sort_order)
+func ProjectOrder(sort, search string) (interface{}, error) {
+	search = strings.TrimSpace(search)
+	if sort == "" {
+		sort = "order"
+		if search != "" {
+			sort = ProjectSortRelevance
+		}
+	}
+
+	if sort == ProjectSortRelevance {
+		if search == "" {
+			return projectSorts["order"], nil
+		}
+		return clause.OrderBy{Expression: clause.Expr{
+			SQL:                "ts_rank(to_tsvector('simple', " + models.ProjectSearchDocument + "), plainto_tsquery('simple', ?)) DESC, sort_order ASC, id ASC",
+			Vars:               []interface{}{search},
+			WithoutParentheses: true,
+		}}, nil
+	}
+
+	order, ok := projectSorts[sort]
+	if !ok {
+		return nil, ErrProjectSort
+	}
+	return order, nil
+}
+
+// containsPattern pattern ของ ILIKE แบบ "มีคำนี้อยู่" (escape % และ _ ที่ผู้ใช้พิมพ์มา)
+func containsPattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(strings.TrimSpace(s))
+	return "%" + s + "%"
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm/clause"
+)
+
+func TestProjectOrder(t *testing.T) {
+	tests := []struct {
+		sort, search string
+		want         string
+	}{
+		{"", "", "sort_order asc, id asc"},
+		{"newest", "", "created_at desc, id desc"},
+		{"-title", "บ้าน", "title desc, id desc"},
+		{"relevance", "", "sort_order asc, id asc"},
+	}
+	for _, tt := range tests {
+		got, err := ProjectOrder(tt.sort, tt.search)
+		if err != nil || got != tt.want {
+			t.Errorf("ProjectOrder(%q, %q) = %v, %v, want %q", tt.sort, tt.search, got, err, tt.want)
+		}
+	}
+
+	// มีคำค้นหา - default เรียงตาม relevance
+	for _, sort := range []string{"", "relevance"} {
+		got, err := ProjectOrder(sort, "villa")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := got.(clause.OrderBy); !ok {
+			t.Errorf("ProjectOrder(%q, villa) = %v, want relevance order", sort, got)
+		}
+	}
+
+	if _, err := ProjectOrder("sort_order; DROP TABLE projects", ""); !errors.Is(err, ErrProjectSort) {
+		t.Errorf("unknown sort error = %v, want ErrProjectSort", err)
+	}
+}
+
+func TestContainsPattern(t *testing.T) {
+	tests := map[string]string{
+		" บ้านสวน ": "%บ้านสวน%",
+		"100%":      `%100\%%`,
+		"a_b":       `%a\_b%`,
+		`c:\`:       `%c:\\%`,
+	}
+	for in, want := range tests {
+		if got := containsPattern(in); got != want {
+			t.Errorf("containsPattern(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestProjectSlugConflict(t *testing.T) {
+	duplicate := &pgconn.PgError{Code: "23505", ConstraintName: "idx_projects_slug"}
+	if err := ProjectSlugConflict(fmt.Errorf("create project: %w", duplicate)); !errors.Is(err, ErrProjectSlugTaken) {
+		t.Errorf("unique violation = %v, want ErrProjectSlugTaken", err)
+	}
+	other := &pgconn.PgError{Code: "23503"}
+	if err := ProjectSlugConflict(other); err != other {
+		t.Errorf("other database errors must pass through, got %v", err)
+	}
+	if err := ProjectSlugConflict(nil); err != nil {
+		t.Errorf("nil = %v", err)
+	}
+}
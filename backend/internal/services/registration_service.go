@@ -0,0 +1,108 @@
+package services
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/pkg/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ค่าของ setting "registration_mode"
+const (
+	RegistrationOpen             = "open"              // ใครก็สมัครได้
+	RegistrationInviteOnly       = "invite_only"       // ต้องมี invite link
+	RegistrationApprovalRequired = "approval_required" // สมัครได้ แต่ต้องรอ admin อนุมัติ (ยกเว้นมาจาก invite)
+	RegistrationClosed           = "closed"            // ปิดรับสมัคร
+)
+
+// DefaultInvitationTTL อายุของ invite link ถ้า admin ไม่ได้ระบุ
+const DefaultInvitationTTL = 7 * 24 * time.Hour
+
+var ErrInvitationInvalid = utils.ErrInvitationInvalid
+
+// RegistrationMode อ่านโหมดการสมัครสมาชิกจาก settings (ค่าที่ไม่รู้จักถือเป็น closed)
+func RegistrationMode() string {
+	switch mode := GetSetting("registration_mode", RegistrationOpen); mode {
+	case RegistrationOpen, RegistrationInviteOnly, RegistrationApprovalRequired, RegistrationClosed:
+		return mode
+	default:
+		return RegistrationClosed
+	}
+}
+
+// AllowedEmailDomains รายการ domain ที่อนุญาตให้สมัครเอง (ว่าง = ทุก domain)
+func AllowedEmailDomains() []string {
+	return utils.ParseDomainList(GetSetting("registration_allowed_domains", ""))
+}
+
+// SelfRegistrationStatus ตรวจนโยบายการสมัครของ user ใหม่ที่ไม่ได้มาจาก invitation
+// ใช้ทั้งการสมัครเองและการสร้าง user อัตโนมัติตอน login ผ่าน SSO - คืน approval status ของ user ใหม่
+func SelfRegistrationStatus(email string) (string, error) {
+	return registrationStatus(RegistrationMode(), AllowedEmailDomains(), email)
+}
+
+func registrationStatus(mode string, allowedDomains []string, email string) (string, error) {
+	switch mode {
+	case RegistrationClosed:
+		return "", utils.ErrRegistrationClosed
+	case RegistrationInviteOnly:
+		return "", utils.ErrInvitationRequired
+	}
+	if !utils.EmailDomainAllowed(email, allowedDomains) {
+		return "", utils.ErrEmailDomainNotAllowed
+	}
+	if mode == RegistrationApprovalRequired {
+		return models.ApprovalPending, nil
+	}
+	return models.ApprovalApproved, nil
+}
+
+// CreateInvitation สร้าง invite link ใหม่ คืน token จริง (แสดงครั้งเดียว) พร้อม record
+func CreateInvitation(email string, roleID *uint, createdByID uint, ttl time.Duration) (string, *models.Invitation, error) {
+	token, err := utils.GenerateResetToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	invitation := models.Invitation{
+		TokenHash:   utils.HashToken(token),
+		Email:       strings.TrimSpace(email),
+		RoleID:      roleID,
+		CreatedByID: createdByID,
+		ExpiresAt:   time.Now().Add(ttl),
+	}
+	if err := database.DB.Create(&invitation).Error; err != nil {
+		return "", nil, err
+	}
+	return token, &invitation, nil
+}
+
+// FindValidInvitation หา invitation ที่ยังใช้ได้ (ยังไม่ใช้ / ไม่ถูกยกเลิก / ไม่หมดอายุ)
+func FindValidInvitation(token string) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := database.DB.Preload("Role").
+		Where("token_hash = ? AND used_at IS NULL AND revoked_at IS NULL AND expires_at > ?", utils.HashToken(token), time.Now()).
+		First(&invitation).Error
+	if err != nil {
+		return nil, ErrInvitationInvalid
+	}
+	return &invitation, nil
+}
+
+// ConsumeInvitation mark invitation ว่าถูกใช้แล้ว (conditional update กันใช้ซ้ำพร้อมกัน)
+// เรียกภายใน transaction เดียวกับการสร้าง user
+func ConsumeInvitation(tx *gorm.DB, invitationID uint, userID uint) error {
+	result := tx.Model(&models.Invitation{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", invitationID).
+		Updates(map[string]interface{}{"used_at": time.Now(), "used_by_id": userID})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvitationInvalid
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/pkg/utils"
+	"errors"
+	"testing"
+)
+
+func TestRegistrationStatus(t *testing.T) {
+	domains := []string{"1931.co.th"}
+
+	tests := []struct {
+		name    string
+		mode    string
+		domains []string
+		email   string
+		want    string
+		wantErr error
+	}{
+		{"open", RegistrationOpen, nil, "a@gmail.com", models.ApprovalApproved, nil},
+		{"approval required", RegistrationApprovalRequired, nil, "a@gmail.com", models.ApprovalPending, nil},
+		{"closed", RegistrationClosed, nil, "a@1931.co.th", "", utils.ErrRegistrationClosed},
+		{"invite only", RegistrationInviteOnly, nil, "a@1931.co.th", "", utils.ErrInvitationRequired},
+		{"allowed domain", RegistrationOpen, domains, "a@1931.co.th", models.ApprovalApproved, nil},
+		{"other domain", RegistrationOpen, domains, "a@gmail.com", "", utils.ErrEmailDomainNotAllowed},
+	}
+	for _, tt := range tests {
+		got, err := registrationStatus(tt.mode, tt.domains, tt.email)
+		if got != tt.want || (tt.wantErr == nil && err != nil) || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+			t.Errorf("%s: registrationStatus = %q, %v - want %q, %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/pkg/utils"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// MaxRoleDepth จำนวนชั้นสูงสุดของ role hierarchy (กันข้อมูลวนหรือลึกผิดปกติ)
+const MaxRoleDepth = 10
+
+var (
+	ErrRoleCycle    = utils.ErrRoleCycle
+	ErrRoleTooDeep  = utils.ErrRoleTooDeep
+	ErrRoleNotFound = utils.ErrRoleNotFound
+)
+
+// RoleRef อ้างอิง role แบบย่อ (ใช้บอกว่า permission สืบทอดมาจาก role ไหน)
+type RoleRef struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// EffectivePermission permission ที่ role ได้รับ - InheritedFrom = nil คือกำหนดให้ role นี้โดยตรง
+type EffectivePermission struct {
+	models.Permission
+	InheritedFrom *RoleRef `json:"inherited_from"`
+}
+
+// RoleChain คืน role และ parent ทั้งหมดขึ้นไป (ตัวเองอยู่ลำดับแรก) พร้อม preload Permissions
+func RoleChain(roleID uint) ([]models.Role, error) {
+	var chain []models.Role
+	seen := map[uint]bool{}
+	next := &roleID
+	for next != nil {
+		if seen[*next] || len(chain) >= MaxRoleDepth {
+			break // ข้อมูลเก่าที่วนอยู่ - หยุดที่จุดที่เห็นซ้ำ
+		}
+		seen[*next] = true
+
+		var role models.Role
+		if err := database.DB.Preload("Permissions").First(&role, *next).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) && len(chain) > 0 {
+				break // parent ถูกลบไปแล้ว
+			}
+			return nil, err
+		}
+		chain = append(chain, role)
+		next = role.ParentID
+	}
+	return chain, nil
+}
+
+// EffectivePermissions รวม permission ของ role กับที่สืบทอดจาก parent
+// permission ที่ได้ทั้งโดยตรงและจาก parent นับเป็นโดยตรง
+func EffectivePermissions(roleID uint) ([]EffectivePermission, error) {
+	chain, err := RoleChain(roleID)
+	if err != nil {
+		return nil, err
+	}
+	return mergeRolePermissions(chain), nil
+}
+
+// mergeRolePermissions รวม permissions ของ chain (ตัวเองก่อน แล้วไล่ขึ้นไปทาง parent)
+func mergeRolePermissions(chain []models.Role) []EffectivePermission {
+	var result []EffectivePermission
+	seen := map[uint]bool{}
+	for i, role := range chain {
+		var from *RoleRef
+		if i > 0 {
+			from = &RoleRef{ID: role.ID, Name: role.Name}
+		}
+		for _, p := range role.Permissions {
+			if !seen[p.ID] {
+				seen[p.ID] = true
+				result = append(result, EffectivePermission{Permission: p, InheritedFrom: from})
+			}
+		}
+	}
+	return result
+}
+
+// ValidateRoleParent ตรวจว่าตั้ง parentID เป็น parent ของ roleID ได้ (ไม่วน และไม่ลึกเกิน)
+func ValidateRoleParent(roleID, parentID uint) error {
+	if roleID == parentID {
+		return ErrRoleCycle
+	}
+	depth := 1
+	next := &parentID
+	for next != nil {
+		if *next == roleID {
+			return ErrRoleCycle
+		}
+		if depth >= MaxRoleDepth {
+			return ErrRoleTooDeep
+		}
+		var role models.Role
+		if err := database.DB.Select("id", "parent_id").First(&role, *next).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRoleNotFound
+			}
+			return err
+		}
+		next = role.ParentID
+		depth++
+	}
+	return nil
+}
+
+// RoleWithDescendants คืน roleID และ role ลูกหลานทั้งหมด (ได้รับผลเมื่อ permission ของ role นี้เปลี่ยน)
+func RoleWithDescendants(roleID uint) ([]uint, error) {
+	result := []uint{roleID}
+	seen := map[uint]bool{roleID: true}
+	frontier := []uint{roleID}
+	for len(frontier) > 0 {
+		var ids []uint
+		if err := database.DB.Model(&models.Role{}).Where("parent_id IN ?", frontier).Pluck("id", &ids).Error; err != nil {
+			return nil, err
+		}
+		frontier = frontier[:0]
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				result = append(result, id)
+				frontier = append(frontier, id)
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"backend/internal/models"
+	"testing"
+)
+
+func TestMergeRolePermissions(t *testing.T) {
+	dashboard := models.Permission{ID: 1, Slug: "dashboard.view"}
+	leavesView := models.Permission{ID: 2, Slug: "leaves.view"}
+	leavesManage := models.Permission{ID: 3, Slug: "leaves.manage"}
+
+	chain := []models.Role{
+		{ID: 3, Name: "HR Manager", Permissions: []models.Permission{leavesManage, leavesView}},
+		{ID: 2, Name: "HR Staff", Permissions: []models.Permission{leavesView}},
+		{ID: 1, Name: "User", Permissions: []models.Permission{dashboard}},
+	}
+
+	got := mergeRolePermissions(chain)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 effective permissions, got %d", len(got))
+	}
+
+	bySlug := map[string]EffectivePermission{}
+	for _, p := range got {
+		bySlug[p.Slug] = p
+	}
+	if bySlug["leaves.manage"].InheritedFrom != nil {
+		t.Error("leaves.manage should be direct")
+	}
+	// ได้ทั้งโดยตรงและจาก parent = นับเป็นโดยตรง
+	if bySlug["leaves.view"].InheritedFrom != nil {
+		t.Error("leaves.view granted directly should not be reported as inherited")
+	}
+	if from := bySlug["dashboard.view"].InheritedFrom; from == nil || from.Name != "User" {
+		t.Errorf("dashboard.view should be inherited from User, got %+v", from)
+	}
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/pkg/utils"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrOutOfScope พนักงานอยู่นอกขอบเขตสิทธิ์ของผู้ใช้ (แผนก/สายบังคับบัญชา)
+var ErrOutOfScope = utils.ErrEmployeeOutOfScope
+
+// EmployeeScope กลุ่มพนักงานที่ผู้ใช้มีสิทธิ์จัดการ
+// All = ไม่จำกัด, ไม่งั้นใช้ได้กับพนักงานใน DepartmentIDs หรือ EmployeeIDs
+type EmployeeScope struct {
+	All           bool
+	DepartmentIDs []uint
+	EmployeeIDs   []uint
+}
+
+// ResolveEmployeeScope หาขอบเขตพนักงานจาก permission ที่ user ถืออยู่ใน claims (รวมทุก slug - union)
+// permission ที่ role ถือโดยไม่มี scope = All
+func ResolveEmployeeScope(claims *utils.Claims, slugs ...string) (*EmployeeScope, error) {
+	scope := &EmployeeScope{}
+
+	var held []string
+	for _, slug := range slugs {
+		if claims.HasPermission(slug) {
+			held = append(held, slug)
+		}
+	}
+	roleIDs := claims.RoleIDs
+	if len(roleIDs) == 0 && claims.RoleID != nil {
+		roleIDs = []uint{*claims.RoleID}
+	}
+	if len(held) == 0 || len(roleIDs) == 0 {
+		return scope, nil
+	}
+
+	// permission อาจได้มาจาก role เพิ่มเติมหรือ parent role - scope ผูกกับ role ที่ให้ permission นั้น
+	var chain []models.Role
+	for _, roleID := range roleIDs {
+		roles, err := RoleChain(roleID)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, roles...)
+	}
+	heldSet := map[string]bool{}
+	for _, slug := range held {
+		heldSet[slug] = true
+	}
+	type grant struct{ roleID, permissionID uint }
+	var grants []grant
+	chainIDs := make([]uint, 0, len(chain))
+	for _, role := range chain {
+		chainIDs = append(chainIDs, role.ID)
+		for _, p := range role.Permissions {
+			if heldSet[p.Slug] {
+				grants = append(grants, grant{role.ID, p.ID})
+			}
+		}
+	}
+
+	var rows []models.RolePermissionScope
+	if err := database.DB.Where("role_id IN ?", chainIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	scoped := map[grant]bool{}
+	for _, row := range rows {
+		scoped[grant{row.RoleID, row.PermissionID}] = true
+	}
+	// มี grant อย่างน้อยหนึ่งตัวที่ไม่ถูกจำกัด = เห็นทุกคน
+	var applicable []models.RolePermissionScope
+	for _, g := range grants {
+		if !scoped[g] {
+			scope.All = true
+			return scope, nil
+		}
+	}
+	for _, row := range rows {
+		for _, g := range grants {
+			if g.roleID == row.RoleID && g.permissionID == row.PermissionID {
+				applicable = append(applicable, row)
+				break
+			}
+		}
+	}
+	rows = applicable
+
+	var self *models.Employee
+	for _, row := range rows {
+		switch row.ScopeType {
+		case models.ScopeDepartment:
+			if row.DepartmentID != nil {
+				scope.DepartmentIDs = append(scope.DepartmentIDs, *row.DepartmentID)
+			}
+		case models.ScopeOwnDepartment, models.ScopeReports:
+			if self == nil {
+				var emp models.Employee
+				if err := database.DB.Where("user_id = ?", claims.UserID).First(&emp).Error; err != nil {
+					if errors.Is(err, gorm.ErrRecordNotFound) {
+						continue // ไม่มีข้อมูลพนักงาน = ไม่มีแผนก/ลูกทีม
+					}
+					return nil, err
+				}
+				self = &emp
+			}
+			if row.ScopeType == models.ScopeOwnDepartment {
+				if self.DepartmentID != nil {
+					scope.DepartmentIDs = append(scope.DepartmentIDs, *self.DepartmentID)
+				}
+				continue
+			}
+			reports, err := ReportingLine(self.ID)
+			if err != nil {
+				return nil, err
+			}
+			scope.EmployeeIDs = append(scope.EmployeeIDs, reports...)
+		}
+	}
+	return scope, nil
+}
+
+// ReportingLine คืน Employee.ID ของลูกทีมทั้งหมดใต้ managerID (ทางตรงและทางอ้อม)
+func ReportingLine(managerID uint) ([]uint, error) {
+	var result []uint
+	seen := map[uint]bool{managerID: true}
+	frontier := []uint{managerID}
+	for len(frontier) > 0 {
+		var ids []uint
+		if err := database.DB.Model(&models.Employee{}).Where("manager_id IN ?", frontier).Pluck("id", &ids).Error; err != nil {
+			return nil, err
+		}
+		frontier = frontier[:0]
+		for _, id := range ids {
+			if !seen[id] { // กันวนซ้ำถ้าข้อมูลสายบังคับบัญชาผิดพลาด
+				seen[id] = true
+				result = append(result, id)
+				frontier = append(frontier, id)
+			}
+		}
+	}
+	return result, nil
+}
+
+// Allows ตรวจว่าพนักงานอยู่ใน scope หรือไม่
+func (s *EmployeeScope) Allows(employee *models.Employee) bool {
+	if s.All {
+		return true
+	}
+	for _, id := range s.EmployeeIDs {
+		if id == employee.ID {
+			return true
+		}
+	}
+	if employee.DepartmentID != nil {
+		return s.AllowsDepartment(*employee.DepartmentID)
+	}
+	return false
+}
+
+// AllowsDepartment ตรวจว่าแผนกอยู่ใน scope หรือไม่ (ใช้ตอนสร้าง/ย้ายแผนกพนักงาน)
+func (s *EmployeeScope) AllowsDepartment(departmentID uint) bool {
+	if s.All {
+		return true
+	}
+	for _, id := range s.DepartmentIDs {
+		if id == departmentID {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers ตรวจว่า scope นี้ครอบ other ทั้งหมดหรือไม่ (other ไม่กว้างกว่า)
+// พนักงานรายคนของ other ต้องอยู่ในรายชื่อของ scope นี้ด้วย (ไม่ตรวจแผนกของพนักงานเหล่านั้น - ถือแบบเข้มไว้ก่อน)
+func (s *EmployeeScope) Covers(other *EmployeeScope) bool {
+	if s.All {
+		return true
+	}
+	if other.All {
+		return false
+	}
+	for _, id := range other.DepartmentIDs {
+		if !s.AllowsDepartment(id) {
+			return false
+		}
+	}
+	for _, id := range other.EmployeeIDs {
+		if !s.Allows(&models.Employee{ID: id}) {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter จำกัด query ให้เหลือเฉพาะแถวที่ column (employee id) อยู่ใน scope
+func (s *EmployeeScope) Filter(db *gorm.DB, column string) *gorm.DB {
+	if s.All {
+		return db
+	}
+	if len(s.DepartmentIDs) == 0 && len(s.EmployeeIDs) == 0 {
+		return db.Where("1 = 0")
+	}
+	sub := database.DB.Model(&models.Employee{}).Select("id")
+	switch {
+	case len(s.DepartmentIDs) > 0 && len(s.EmployeeIDs) > 0:
+		sub = sub.Where("department_id IN ? OR id IN ?", s.DepartmentIDs, s.EmployeeIDs)
+	case len(s.DepartmentIDs) > 0:
+		sub = sub.Where("department_id IN ?", s.DepartmentIDs)
+	default:
+		sub = sub.Where("id IN ?", s.EmployeeIDs)
+	}
+	return db.Where(column+" IN (?)", sub)
+}
+
+// EmployeeScopeAllows โหลดพนักงานแล้วตรวจ scope - คืน ErrOutOfScope ถ้าอยู่นอกขอบเขต
+func EmployeeScopeAllows(scope *EmployeeScope, employeeID uint) error {
+	if scope.All {
+		return nil
+	}
+	var employee models.Employee
+	if err := database.DB.Select("id", "department_id").First(&employee, employeeID).Error; err != nil {
+		return err
+	}
+	if !scope.Allows(&employee) {
+		return ErrOutOfScope
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"backend/internal/models"
+	"testing"
+)
+
+func TestEmployeeScopeAllows(t *testing.T) {
+	sales, hr := uint(1), uint(2)
+
+	scope := &EmployeeScope{DepartmentIDs: []uint{sales}, EmployeeIDs: []uint{42}}
+
+	tests := []struct {
+		name     string
+		employee models.Employee
+		want     bool
+	}{
+		{"same department", models.Employee{ID: 7, DepartmentID: &sales}, true},
+		{"other department", models.Employee{ID: 8, DepartmentID: &hr}, false},
+		{"direct report in other department", models.Employee{ID: 42, DepartmentID: &hr}, true},
+		{"no department", models.Employee{ID: 9}, false},
+	}
+	for _, tt := range tests {
+		if got := scope.Allows(&tt.employee); got != tt.want {
+			t.Errorf("%s: Allows = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if scope.AllowsDepartment(hr) {
+		t.Error("expected department outside scope to be rejected")
+	}
+	all := &EmployeeScope{All: true}
+	if !all.Allows(&models.Employee{ID: 9}) || !all.AllowsDepartment(hr) {
+		t.Error("expected unscoped grant to allow everything")
+	}
+}
+
+func TestEmployeeScopeCovers(t *testing.T) {
+	sales, hr := uint(1), uint(2)
+
+	all := &EmployeeScope{All: true}
+	salesOnly := &EmployeeScope{DepartmentIDs: []uint{sales}}
+	salesAndHR := &EmployeeScope{DepartmentIDs: []uint{sales, hr}}
+	reports := &EmployeeScope{EmployeeIDs: []uint{42}}
+
+	tests := []struct {
+		name          string
+		scope, target *EmployeeScope
+		want          bool
+	}{
+		{"unscoped covers everything", all, salesAndHR, true},
+		{"scoped never covers unscoped", salesAndHR, all, false},
+		{"same department", salesOnly, salesOnly, true},
+		{"narrower target", salesAndHR, salesOnly, true},
+		{"wider target", salesOnly, salesAndHR, false},
+		{"reports not listed", salesOnly, reports, false},
+		{"reports listed", &EmployeeScope{DepartmentIDs: []uint{sales}, EmployeeIDs: []uint{42}}, reports, true},
+		{"empty target", salesOnly, &EmployeeScope{}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.scope.Covers(tt.target); got != tt.want {
+			t.Errorf("%s: Covers = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
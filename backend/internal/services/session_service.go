@@ -0,0 +1,44 @@
+package services
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/pkg/utils"
+)
+
+var ErrSessionNotFound = utils.ErrSessionNotFound
+
+// IsSessionActive ตรวจสอบว่า session ของ access token ยังไม่ถูก revoke
+// ใช้ใน middleware.Protected ทุก request
+func IsSessionActive(sessionID uint, userID uint) bool {
+	if sessionID == 0 {
+		return false
+	}
+	var count int64
+	database.DB.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Count(&count)
+	return count > 0
+}
+
+// ListActiveSessions คืน session ที่ยังใช้งานได้ของ user เรียงจากใช้งานล่าสุด
+func ListActiveSessions(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := database.DB.
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("last_used_at desc").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// RevokeSession revoke session ของ user (พร้อม refresh token ทั้ง family)
+func RevokeSession(userID uint, sessionID uint) (*models.Session, error) {
+	var session models.Session
+	if err := database.DB.Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).First(&session).Error; err != nil {
+		return nil, ErrSessionNotFound
+	}
+	if err := RevokeRefreshFamily(session.FamilyID, models.RefreshTokenRevokedLogout); err != nil {
+		return &session, err
+	}
+	return &session, nil
+}
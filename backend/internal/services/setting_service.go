@@ -0,0 +1,20 @@
+package services
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"strings"
+)
+
+// GetSetting อ่านค่าจากตาราง settings คืน fallback ถ้าไม่พบ key หรือค่าว่าง
+func GetSetting(key, fallback string) string {
+	var setting models.Setting
+	if err := database.DB.Where("key = ?", key).First(&setting).Error; err != nil {
+		return fallback
+	}
+	value := strings.TrimSpace(setting.Value)
+	if value == "" {
+		return fallback
+	}
+	return value
+}
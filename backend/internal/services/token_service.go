@@ -0,0 +1,326 @@
+package services
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/pkg/utils"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefreshTokenTTL อายุของ refresh token แต่ละตัว (นับใหม่ทุกครั้งที่ rotate)
+const RefreshTokenTTL = 7 * 24 * time.Hour
+
+var (
+	ErrRefreshTokenInvalid = utils.ErrRefreshTokenInvalid
+	ErrRefreshTokenReused  = utils.ErrRefreshTokenReused
+	ErrRefreshTokenStale   = utils.ErrTokenVersionChanged
+)
+
+// TokenPair access token + refresh token ที่ส่งกลับให้ client
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	FamilyID     string
+	SessionID    uint
+
+	recordID uint
+}
+
+// SessionMeta ข้อมูลอุปกรณ์ที่ใช้ login (อ่านจาก request)
+type SessionMeta struct {
+	IPAddress   string
+	UserAgent   string
+	LoginMethod string
+}
+
+// IssueTokens ออก token คู่ใหม่สำหรับการ login
+// สร้าง session ใหม่พร้อม refresh token family ใหม่
+func IssueTokens(user *models.User, meta SessionMeta) (*TokenPair, error) {
+	var pair *TokenPair
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		session := models.Session{
+			UserID:       user.ID,
+			FamilyID:     uuid.New().String(),
+			Device:       utils.DescribeDevice(meta.UserAgent),
+			IPAddress:    meta.IPAddress,
+			UserAgent:    meta.UserAgent,
+			LoginMethod:  meta.LoginMethod,
+			LastUsedAt:   time.Now(),
+			TokenVersion: user.TokenVersion,
+		}
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+
+		var err error
+		pair, err = issueTokens(tx, user, &session)
+		return err
+	})
+	return pair, err
+}
+
+// PermissionSlugs คืน permission slug ทั้งหมดของ user
+// รวม role หลัก, role เพิ่มเติมที่มีผลอยู่ (UserRole) และที่สืบทอดจาก parent role
+func PermissionSlugs(user *models.User) []string {
+	permissions := []string{}
+	roleIDs, err := ActiveRoleIDs(user)
+	if err != nil {
+		log.Printf("Failed to load role grants of user %d: %v", user.ID, err)
+	}
+
+	seen := map[string]bool{}
+	for _, roleID := range roleIDs {
+		effective, err := EffectivePermissions(roleID)
+		if err != nil {
+			log.Printf("Failed to resolve permissions of role %d: %v", roleID, err)
+			continue
+		}
+		for _, p := range effective {
+			if !seen[p.Slug] {
+				seen[p.Slug] = true
+				permissions = append(permissions, p.Slug)
+			}
+		}
+	}
+	return permissions
+}
+
+// accessClaims สร้าง claims ของ access token จากสถานะปัจจุบันของ user
+func accessClaims(user *models.User) utils.Claims {
+	roleIDs, _ := ActiveRoleIDs(user)
+	return utils.Claims{
+		UserID:                 user.ID,
+		Username:               user.Username,
+		RoleID:                 user.RoleID,
+		RoleIDs:                roleIDs,
+		Permissions:            PermissionSlugs(user),
+		TokenVersion:           user.TokenVersion,
+		TwoFactorSetupRequired: TwoFactorRequiredByRole(user) && !user.TwoFactorEnabled,
+		EmailUnverified:        EmailVerificationPending(user),
+		PasswordChangeRequired: PasswordChangeRequired(user),
+		Locale:                 user.Locale,
+	}
+}
+
+// issueTokens สร้าง access token และบันทึก refresh token (hash) ลงใน family ของ session
+func issueTokens(tx *gorm.DB, user *models.User, session *models.Session) (*TokenPair, error) {
+	claims := accessClaims(user)
+	claims.SessionID = session.ID
+	claims.LoginMethod = session.LoginMethod
+	accessToken, err := utils.GenerateAccessToken(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.GenerateResetToken()
+	if err != nil {
+		return nil, err
+	}
+
+	record := models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  session.FamilyID,
+		TokenHash: utils.HashToken(refreshToken),
+		ExpiresAt: time.Now().Add(RefreshTokenTTL),
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		FamilyID:     session.FamilyID,
+		SessionID:    session.ID,
+		recordID:     record.ID,
+	}, nil
+}
+
+// checkRefreshToken ตรวจสถานะของ refresh token ก่อน rotate
+// token ที่ถูก rotate ไปแล้ว = ถูกนำกลับมาใช้ซ้ำ (ErrRefreshTokenReused)
+func checkRefreshToken(record *models.RefreshToken, now time.Time) error {
+	if record.RevokedAt != nil {
+		if record.RevokedReason == models.RefreshTokenRevokedRotated {
+			return ErrRefreshTokenReused
+		}
+		return ErrRefreshTokenInvalid
+	}
+	if now.After(record.ExpiresAt) {
+		return ErrRefreshTokenInvalid
+	}
+	return nil
+}
+
+// revokeFamily ใช้ revoke family ระหว่าง rotate (แทนที่ได้ใน test)
+var revokeFamily = RevokeRefreshFamily
+
+// revokeReusedFamily revoke ทั้ง family เมื่อพบ token ถูกใช้ซ้ำ
+// ถ้า revoke ไม่สำเร็จ family ที่อาจถูกขโมยยังใช้งานได้ - คืน error ของการ revoke แทน ErrRefreshTokenReused
+// เพื่อไม่ให้ caller ถือว่าจัดการเรียบร้อยแล้ว
+func revokeReusedFamily(familyID string) error {
+	if err := revokeFamily(familyID, models.RefreshTokenRevokedReused); err != nil {
+		log.Printf("Failed to revoke refresh token family %s after reuse: %v", familyID, err)
+		return fmt.Errorf("revoke reused refresh token family: %w", err)
+	}
+	return ErrRefreshTokenReused
+}
+
+// revokeFamilyOrLog revoke family ที่ไม่ควรใช้ต่อ (user ถูกปิด / permissions เปลี่ยน) - refresh ถูกปฏิเสธอยู่แล้ว จึงแค่บันทึก log
+func revokeFamilyOrLog(familyID string, reason string) {
+	if err := revokeFamily(familyID, reason); err != nil {
+		log.Printf("Failed to revoke refresh token family %s (%s): %v", familyID, reason, err)
+	}
+}
+
+// RotateRefreshToken แลก refresh token เป็น token คู่ใหม่ใน family เดิม
+// ⚠️ ถ้า token ถูก rotate ไปแล้วและถูกนำกลับมาใช้ซ้ำ จะ revoke ทั้ง family
+// และคืน ErrRefreshTokenReused พร้อม token record เดิมเพื่อให้ caller บันทึก audit log
+// (ถ้า revoke ไม่สำเร็จคืน error อื่นแทน - refresh ล้มเหลวและ family ยังต้องถูก revoke)
+func RotateRefreshToken(rawToken string, meta SessionMeta) (*models.User, *TokenPair, *models.RefreshToken, error) {
+	var record models.RefreshToken
+	if err := database.DB.Where("token_hash = ?", utils.HashToken(rawToken)).First(&record).Error; err != nil {
+		return nil, nil, nil, ErrRefreshTokenInvalid
+	}
+
+	if err := checkRefreshToken(&record, time.Now()); err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) {
+			return nil, nil, &record, revokeReusedFamily(record.FamilyID)
+		}
+		return nil, nil, &record, err
+	}
+
+	var session models.Session
+	if err := database.DB.Where("family_id = ? AND revoked_at IS NULL", record.FamilyID).First(&session).Error; err != nil {
+		return nil, nil, &record, ErrRefreshTokenInvalid
+	}
+
+	var user models.User
+	if err := database.DB.Preload("Role.Permissions").First(&user, record.UserID).Error; err != nil {
+		return nil, nil, &record, ErrRefreshTokenInvalid
+	}
+	if !user.Active {
+		revokeFamilyOrLog(record.FamilyID, models.RefreshTokenRevokedLogout)
+		return &user, nil, &record, ErrRefreshTokenInvalid
+	}
+
+	// role / permissions / สถานะของ user เปลี่ยนหลังออก session - ต้อง login ใหม่
+	if session.TokenVersion != user.TokenVersion {
+		revokeFamilyOrLog(record.FamilyID, models.RefreshTokenRevokedStale)
+		return &user, nil, &record, ErrRefreshTokenStale
+	}
+
+	var pair *TokenPair
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// mark token เดิมว่าถูก rotate แล้ว (conditional update กัน request ซ้อนกัน)
+		now := time.Now()
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", record.ID).
+			Updates(map[string]interface{}{"revoked_at": now, "revoked_reason": models.RefreshTokenRevokedRotated})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+
+		var err error
+		pair, err = issueTokens(tx, &user, &session)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.RefreshToken{}).Where("id = ?", record.ID).Update("replaced_by_id", pair.recordID).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&session).Updates(map[string]interface{}{"last_used_at": now, "ip_address": meta.IPAddress}).Error
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		return &user, nil, &record, revokeReusedFamily(record.FamilyID)
+	}
+	if err != nil {
+		return &user, nil, &record, err
+	}
+
+	return &user, pair, &record, nil
+}
+
+// RevokeRefreshFamily revoke refresh token ที่ยังใช้งานได้ทั้งหมดใน family และ session ที่ผูกอยู่
+func RevokeRefreshFamily(familyID string, reason string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Updates(map[string]interface{}{"revoked_at": now, "revoked_reason": reason}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Session{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", now).Error
+	})
+}
+
+// RevokeRefreshToken ใช้ตอน logout - revoke family ของ refresh token ที่ส่งมา
+// คืน record ของ token (ถ้าพบ) เพื่อใช้บันทึก audit log
+func RevokeRefreshToken(rawToken string) (*models.RefreshToken, error) {
+	var record models.RefreshToken
+	if err := database.DB.Where("token_hash = ?", utils.HashToken(rawToken)).First(&record).Error; err != nil {
+		return nil, ErrRefreshTokenInvalid
+	}
+	if err := RevokeRefreshFamily(record.FamilyID, models.RefreshTokenRevokedLogout); err != nil {
+		return &record, err
+	}
+	return &record, nil
+}
+
+// RevokeAllSessions revoke ทุก session และ refresh token ของ user (logout ทุกอุปกรณ์)
+// คืนจำนวน session ที่ถูก revoke
+func RevokeAllSessions(userID uint) (int64, error) {
+	var revoked int64
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Updates(map[string]interface{}{"revoked_at": now, "revoked_reason": models.RefreshTokenRevokedLogoutAll}).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&models.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now)
+		revoked = result.RowsAffected
+		return result.Error
+	})
+	return revoked, err
+}
+
+// BumpTokenVersion เพิ่ม token_version ของ user เมื่อ role / สถานะเปลี่ยน
+// access token เดิมใช้ได้จนหมดอายุ แต่ refresh token ของ session เดิมจะถูกปฏิเสธ
+func BumpTokenVersion(userIDs ...uint) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	return database.DB.Model(&models.User{}).
+		Where("id IN ?", userIDs).
+		Update("token_version", gorm.Expr("token_version + 1")).Error
+}
+
+// BumpRoleTokenVersion เพิ่ม token_version ของทุก user ใน role และ role ลูกหลาน (เช่น แก้ permissions ของ role)
+// รวม user ที่ได้ role เป็น role เพิ่มเติม (UserRole ที่ยังไม่ถูกถอน)
+func BumpRoleTokenVersion(roleID uint) error {
+	// role ลูกหลานสืบทอด permission จาก role นี้ด้วย
+	roleIDs, err := RoleWithDescendants(roleID)
+	if err != nil {
+		return err
+	}
+	grantees := database.DB.Model(&models.UserRole{}).Select("user_id").
+		Where("role_id IN ? AND revoked_at IS NULL", roleIDs)
+	return database.DB.Model(&models.User{}).
+		Where("role_id IN ? OR id IN (?)", roleIDs, grantees).
+		Update("token_version", gorm.Expr("token_version + 1")).Error
+}
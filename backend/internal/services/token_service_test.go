@@ -0,0 +1,55 @@
+package services
+
+import (
+	"backend/internal/models"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheckRefreshToken(t *testing.T) {
+	now := time.Now()
+	revokedAt := now.Add(-time.Minute)
+
+	tests := []struct {
+		name   string
+		record models.RefreshToken
+		want   error
+	}{
+		{"active", models.RefreshToken{ExpiresAt: now.Add(time.Hour)}, nil},
+		{"expired", models.RefreshToken{ExpiresAt: now.Add(-time.Second)}, ErrRefreshTokenInvalid},
+		{"rotated then reused", models.RefreshToken{ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt, RevokedReason: models.RefreshTokenRevokedRotated}, ErrRefreshTokenReused},
+		{"logged out", models.RefreshToken{ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt, RevokedReason: models.RefreshTokenRevokedLogout}, ErrRefreshTokenInvalid},
+		{"revoked after reuse", models.RefreshToken{ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt, RevokedReason: models.RefreshTokenRevokedReused}, ErrRefreshTokenInvalid},
+	}
+	for _, tt := range tests {
+		if got := checkRefreshToken(&tt.record, now); !errors.Is(got, tt.want) || (tt.want == nil && got != nil) {
+			t.Errorf("%s: checkRefreshToken = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRevokeReusedFamily(t *testing.T) {
+	original := revokeFamily
+	defer func() { revokeFamily = original }()
+
+	var revoked, reason string
+	revokeFamily = func(familyID string, r string) error {
+		revoked, reason = familyID, r
+		return nil
+	}
+	if err := revokeReusedFamily("family-1"); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("revokeReusedFamily = %v, want ErrRefreshTokenReused", err)
+	}
+	if revoked != "family-1" || reason != models.RefreshTokenRevokedReused {
+		t.Errorf("revoked family %q with reason %q, want family-1 / %s", revoked, reason, models.RefreshTokenRevokedReused)
+	}
+
+	// revoke ไม่สำเร็จ - ต้องไม่รายงานว่าจัดการ reuse เรียบร้อยแล้ว
+	dbErr := errors.New("connection reset")
+	revokeFamily = func(string, string) error { return dbErr }
+	err := revokeReusedFamily("family-1")
+	if errors.Is(err, ErrRefreshTokenReused) || !errors.Is(err, dbErr) {
+		t.Errorf("revokeReusedFamily on failure = %v, want the revoke error", err)
+	}
+}
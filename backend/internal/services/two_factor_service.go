@@ -0,0 +1,157 @@
+package services
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/pkg/utils"
+	"log"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RecoveryCodeCount จำนวน recovery codes ที่สร้างให้ต่อครั้ง
+const RecoveryCodeCount = 10
+
+// TwoFactorChallengeTTL อายุของ challenge token ระหว่างรอกรอก 2FA code
+const TwoFactorChallengeTTL = 5 * time.Minute
+
+var (
+	ErrInvalidTwoFactorCode   = utils.ErrTwoFactorCodeInvalid
+	ErrTwoFactorChallengeUsed = utils.ErrChallengeInvalid
+)
+
+// TOTPIssuer ชื่อที่แสดงใน authenticator app (override ได้ด้วย TOTP_ISSUER)
+func TOTPIssuer() string {
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "1931 Design"
+}
+
+// TwoFactorRequiredByRole ตรวจสอบว่า role ใดของ user บังคับใช้ 2FA หรือไม่
+// รวม role หลักและ role เพิ่มเติมที่มีผลอยู่ (UserRole)
+func TwoFactorRequiredByRole(user *models.User) bool {
+	if user.RoleID != nil && user.Role.ID == *user.RoleID && user.Role.RequireTwoFactor {
+		return true
+	}
+
+	roleIDs, err := ActiveRoleIDs(user)
+	if err != nil {
+		log.Printf("Failed to load role grants of user %d: %v", user.ID, err)
+	}
+	if len(roleIDs) == 0 {
+		return false
+	}
+	var required int64
+	database.DB.Model(&models.Role{}).Where("id IN ? AND require_two_factor = ?", roleIDs, true).Count(&required)
+	return required > 0
+}
+
+// NewRecoveryCodes สร้าง recovery codes ใหม่ คืน (codes สำหรับแสดงผู้ใช้ครั้งเดียว, hashes สำหรับเก็บ)
+func NewRecoveryCodes() ([]string, []string, error) {
+	codes, err := utils.GenerateRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashToken(code)
+	}
+	return codes, hashes, nil
+}
+
+// VerifyTOTPCode ตรวจสอบ TOTP code และบันทึก time step ที่ใช้ เพื่อกันการใช้ code เดิมซ้ำ
+func VerifyTOTPCode(user *models.User, code string) error {
+	if user.TwoFactorSecret == "" {
+		return ErrInvalidTwoFactorCode
+	}
+	step, ok := utils.ValidateTOTP(user.TwoFactorSecret, code, time.Now())
+	if !ok || step <= user.TwoFactorLastStep {
+		return ErrInvalidTwoFactorCode
+	}
+
+	// conditional update กัน request ซ้อนที่ใช้ code เดียวกัน
+	result := database.DB.Model(&models.User{}).
+		Where("id = ? AND two_factor_last_step < ?", user.ID, step).
+		Update("two_factor_last_step", step)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	user.TwoFactorLastStep = step
+	return nil
+}
+
+// removeRecoveryCode คืน hashes ที่เหลือหลังตัด hash ที่ใช้ออกหนึ่งตัว (false ถ้าไม่พบ)
+func removeRecoveryCode(hashes []string, hash string) ([]string, bool) {
+	remaining := make([]string, 0, len(hashes))
+	found := false
+	for _, h := range hashes {
+		if !found && h == hash {
+			found = true
+			continue
+		}
+		remaining = append(remaining, h)
+	}
+	return remaining, found
+}
+
+// ConsumeRecoveryCode ใช้ recovery code (ใช้ได้ครั้งเดียว - ลบ hash ออกหลังใช้)
+// lock แถวของ user แล้วอ่าน codes ใหม่ - request ซ้อนที่ใช้ code เดียวกันจะสำเร็จได้แค่ครั้งเดียว
+func ConsumeRecoveryCode(user *models.User, code string) error {
+	hash := utils.HashToken(utils.NormalizeRecoveryCode(code))
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "recovery_codes").First(&current, user.ID).Error; err != nil {
+			return err
+		}
+
+		remaining, found := removeRecoveryCode(current.RecoveryCodes, hash)
+		if !found {
+			return ErrInvalidTwoFactorCode
+		}
+
+		current.RecoveryCodes = remaining
+		if err := tx.Model(&current).Select("RecoveryCodes").Updates(&current).Error; err != nil {
+			return err
+		}
+		user.RecoveryCodes = remaining
+		return nil
+	})
+}
+
+// ConsumeTwoFactorChallenge บันทึกว่า challenge token ถูกใช้ login สำเร็จแล้ว (ใช้ได้ครั้งเดียว)
+// insert jti แบบ ON CONFLICT DO NOTHING - request ซ้อนที่ใช้ token เดียวกันจะสำเร็จได้แค่ครั้งเดียว
+func ConsumeTwoFactorChallenge(claims *utils.Claims) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return ErrTwoFactorChallengeUsed
+	}
+
+	now := time.Now()
+	// token ที่หมดอายุแล้วใช้ไม่ได้อยู่ดี - ไม่ต้องเก็บ jti ต่อ
+	database.DB.Where("expires_at < ?", now).Delete(&models.UsedTwoFactorChallenge{})
+
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UsedTwoFactorChallenge{
+		TokenID:   claims.ID,
+		UserID:    claims.UserID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTwoFactorChallengeUsed
+	}
+	return nil
+}
+
+// VerifySecondFactor ตรวจสอบ TOTP code หรือ recovery code อย่างใดอย่างหนึ่ง
+// คืน true ถ้าใช้ recovery code (caller ควรบันทึก audit log)
+func VerifySecondFactor(user *models.User, code, recoveryCode string) (bool, error) {
+	if recoveryCode != "" {
+		return true, ConsumeRecoveryCode(user, recoveryCode)
+	}
+	return false, VerifyTOTPCode(user, code)
+}
@@ -0,0 +1,22 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRemoveRecoveryCode(t *testing.T) {
+	hashes := []string{"a", "b", "a", "c"}
+
+	remaining, found := removeRecoveryCode(hashes, "a")
+	if !found || !reflect.DeepEqual(remaining, []string{"b", "a", "c"}) {
+		t.Errorf("removeRecoveryCode(a) = %v, %v - want only the first match removed", remaining, found)
+	}
+	if len(hashes) != 4 {
+		t.Error("removeRecoveryCode should not modify the input slice")
+	}
+
+	if remaining, found := removeRecoveryCode(hashes, "z"); found || len(remaining) != len(hashes) {
+		t.Errorf("removeRecoveryCode(z) = %v, %v - want not found", remaining, found)
+	}
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/pkg/utils"
+	"log"
+	"os"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRoleGrantInvalidWindow = utils.ErrRoleGrantWindowInvalid
+	ErrRoleGrantNotFound      = utils.ErrRoleGrantNotFound
+	ErrRoleGrantDuplicate     = utils.ErrRoleGrantDuplicate
+	ErrRoleGrantEscalation    = utils.ErrRoleGrantEscalation
+)
+
+// activeGrantsQuery เงื่อนไข grant ที่มีผล ณ เวลา now
+func activeGrantsQuery(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Where("revoked_at IS NULL").
+		Where("valid_from IS NULL OR valid_from <= ?", now).
+		Where("valid_until IS NULL OR valid_until > ?", now)
+}
+
+// ActiveRoleIDs คืน role หลักและ role เพิ่มเติมที่มีผลอยู่ของ user (role หลักอยู่ลำดับแรก)
+func ActiveRoleIDs(user *models.User) ([]uint, error) {
+	var ids []uint
+	if user.RoleID != nil {
+		ids = append(ids, *user.RoleID)
+	}
+
+	var granted []uint
+	if err := activeGrantsQuery(database.DB.Model(&models.UserRole{}), time.Now()).
+		Where("user_id = ?", user.ID).Order("id").Pluck("role_id", &granted).Error; err != nil {
+		return ids, err
+	}
+	seen := map[uint]bool{}
+	for _, id := range ids {
+		seen[id] = true
+	}
+	for _, id := range granted {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// GrantRole ให้ role เพิ่มเติมแก่ user (มีช่วงเวลาได้)
+// ⚠️ actor ต้องมีทุก permission ของ role (รวมที่สืบทอดจาก parent) - กันให้ role ที่สิทธิ์สูงกว่าตัวเอง (รวมถึงให้ตัวเอง)
+func GrantRole(actor *utils.Claims, userID, roleID uint, validFrom, validUntil *time.Time, reason string) (*models.UserRole, error) {
+	now := time.Now()
+	if validUntil != nil && (!validUntil.After(now) || (validFrom != nil && !validUntil.After(*validFrom))) {
+		return nil, ErrRoleGrantInvalidWindow
+	}
+
+	var role models.Role
+	if err := database.DB.First(&role, roleID).Error; err != nil {
+		return nil, ErrRoleNotFound
+	}
+	perms, err := EffectivePermissions(role.ID)
+	if err != nil {
+		return nil, err
+	}
+	if missing := missingPermissions(actor, perms); len(missing) > 0 {
+		return nil, ErrRoleGrantEscalation.WithDetails(missing)
+	}
+
+	var user models.User
+	if err := database.DB.Select("id", "role_id").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	if user.RoleID != nil && *user.RoleID == roleID {
+		return nil, ErrRoleGrantDuplicate
+	}
+
+	// grant เดิมของ role เดียวกันที่ยังไม่หมดอายุ (รวมที่ยังไม่ถึง valid_from)
+	var existing int64
+	database.DB.Model(&models.UserRole{}).
+		Where("user_id = ? AND role_id = ? AND revoked_at IS NULL", userID, roleID).
+		Where("valid_until IS NULL OR valid_until > ?", now).
+		Count(&existing)
+	if existing > 0 {
+		return nil, ErrRoleGrantDuplicate
+	}
+
+	grant := models.UserRole{
+		UserID:     userID,
+		RoleID:     roleID,
+		ValidFrom:  validFrom,
+		ValidUntil: validUntil,
+		Reason:     reason,
+	}
+	if grantedBy := actor.UserID; grantedBy != 0 {
+		grant.GrantedByID = &grantedBy
+	}
+	if err := database.DB.Create(&grant).Error; err != nil {
+		return nil, err
+	}
+	grant.Role = role
+	// session เดิมต้อง login ใหม่เพื่อรับ permissions / ข้อบังคับ 2FA ของ role ที่ได้เพิ่ม
+	return &grant, BumpTokenVersion(userID)
+}
+
+// missingPermissions คืน slug ของ permission ที่ actor ไม่มี
+func missingPermissions(actor *utils.Claims, perms []EffectivePermission) []string {
+	var missing []string
+	for _, p := range perms {
+		if !actor.HasPermission(p.Slug) {
+			missing = append(missing, p.Slug)
+		}
+	}
+	return missing
+}
+
+// ListRoleGrants คืน grant ทั้งหมดของ user (รวมที่หมดอายุ/ถูกถอนแล้ว) ใหม่สุดก่อน
+func ListRoleGrants(userID uint) ([]models.UserRole, error) {
+	var grants []models.UserRole
+	err := database.DB.Preload("Role").Where("user_id = ?", userID).Order("created_at DESC").Find(&grants).Error
+	return grants, err
+}
+
+// RevokeRoleGrant ถอน grant และบังคับ user login ใหม่ (permission ใน token เดิมไม่ตรงแล้ว)
+func RevokeRoleGrant(userID, grantID uint) (*models.UserRole, error) {
+	var grant models.UserRole
+	if err := database.DB.Preload("Role").Where("id = ? AND user_id = ? AND revoked_at IS NULL", grantID, userID).First(&grant).Error; err != nil {
+		return nil, ErrRoleGrantNotFound
+	}
+
+	now := time.Now()
+	grant.RevokedAt = &now
+	grant.RevokeReason = models.RoleGrantRevoked
+	if err := database.DB.Model(&grant).Select("RevokedAt", "RevokeReason").Updates(&grant).Error; err != nil {
+		return nil, err
+	}
+	return &grant, BumpTokenVersion(userID)
+}
+
+// ExpireRoleGrants ปิด grant ที่เลย valid_until แล้ว คืนรายการที่ถูกปิดในรอบนี้
+func ExpireRoleGrants() ([]models.UserRole, error) {
+	now := time.Now()
+	var expired []models.UserRole
+	if err := database.DB.Preload("Role").
+		Where("revoked_at IS NULL AND valid_until IS NOT NULL AND valid_until <= ?", now).
+		Find(&expired).Error; err != nil {
+		return nil, err
+	}
+
+	var result []models.UserRole
+	for _, grant := range expired {
+		// conditional update กันทำซ้ำถ้ามีหลาย instance รัน job พร้อมกัน
+		res := database.DB.Model(&models.UserRole{}).
+			Where("id = ? AND revoked_at IS NULL", grant.ID).
+			Updates(map[string]interface{}{"revoked_at": now, "revoke_reason": models.RoleGrantExpired})
+		if res.Error != nil {
+			return result, res.Error
+		}
+		if res.RowsAffected == 0 {
+			continue
+		}
+		grant.RevokedAt = &now
+		grant.RevokeReason = models.RoleGrantExpired
+		if err := BumpTokenVersion(grant.UserID); err != nil {
+			log.Printf("[RoleGrants] Failed to invalidate tokens of user %d: %v", grant.UserID, err)
+		}
+		result = append(result, grant)
+	}
+	return result, nil
+}
+
+// StartRoleGrantScheduler เริ่ม job ปิด role grant ที่หมดอายุ (default ทุกนาที, override ด้วย ROLE_GRANT_EXPIRY_SCHEDULE)
+func StartRoleGrantScheduler() *cron.Cron {
+	schedule := os.Getenv("ROLE_GRANT_EXPIRY_SCHEDULE")
+	if schedule == "" {
+		schedule = "@every 1m"
+	}
+
+	scheduler := cron.New()
+	_, err := scheduler.AddFunc(schedule, func() {
+		expired, err := ExpireRoleGrants()
+		if err != nil {
+			log.Printf("[RoleGrants] Error expiring grants: %v", err)
+		}
+		for _, grant := range expired {
+			CreateSystemAuditLog("ROLE_GRANT_EXPIRED", grant.UserID, "user", map[string]interface{}{
+				"grant_id":    grant.ID,
+				"role_id":     grant.RoleID,
+				"role":        grant.Role.Name,
+				"valid_until": grant.ValidUntil,
+			}, 0)
+		}
+	})
+	if err != nil {
+		log.Printf("[RoleGrants] Could not schedule expiry job: %v", err)
+		return nil
+	}
+
+	scheduler.Start()
+	log.Printf("[RoleGrants] Expiry scheduler started - schedule: %s", schedule)
+	return scheduler
+}
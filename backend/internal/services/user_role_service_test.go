@@ -0,0 +1,34 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/pkg/utils"
+	"reflect"
+	"testing"
+)
+
+func TestMissingPermissions(t *testing.T) {
+	perms := mergeRolePermissions([]models.Role{
+		{ID: 3, Name: "HR Manager", Permissions: []models.Permission{{ID: 3, Slug: "leaves.manage"}}},
+		{ID: 2, Name: "HR Staff", Permissions: []models.Permission{{ID: 2, Slug: "leaves.view"}}},
+		{ID: 1, Name: "User", Permissions: []models.Permission{{ID: 1, Slug: "dashboard.view"}}},
+	})
+
+	tests := []struct {
+		name  string
+		actor []string
+		want  []string
+	}{
+		{"actor has every permission", []string{"dashboard.view", "leaves.view", "leaves.manage", "users.manage"}, nil},
+		{"direct permission missing", []string{"dashboard.view", "leaves.view", "users.manage"}, []string{"leaves.manage"}},
+		{"inherited permissions count too", []string{"leaves.manage", "users.manage"}, []string{"leaves.view", "dashboard.view"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := missingPermissions(&utils.Claims{UserID: 1, Permissions: tt.actor}, perms)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("missingPermissions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
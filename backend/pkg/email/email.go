@@ -2,9 +2,11 @@ package email
 
 import (
 	"fmt"
+	"html"
 	"net/smtp"
 	"os"
 	"strings"
+	"time"
 )
 
 // SendEmail sends an email using the configured SMTP server.
@@ -107,3 +109,102 @@ func SendPasswordResetEmail(email, username, resetLink string) error {
 
 	return SendEmail([]string{email}, subject, body)
 }
+
+// SendVerificationEmail ส่ง email พร้อม link สำหรับยืนยันอีเมลหลังสมัครสมาชิก
+func SendVerificationEmail(email, username, verifyLink string) error {
+	subject := "Verify Your Email Address"
+	body := fmt.Sprintf(`
+		<!DOCTYPE html>
+		<html>
+		<head>
+			<style>
+				body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+				.container { max-width: 600px; margin: 0 auto; padding: 20px; }
+				.header { background: linear-gradient(135deg, #667eea 0%%, #764ba2 100%%); padding: 30px; text-align: center; border-radius: 10px 10px 0 0; }
+				.header h1 { color: white; margin: 0; }
+				.content { background: #f9f9f9; padding: 30px; border-radius: 0 0 10px 10px; }
+				.button { display: inline-block; background: linear-gradient(135deg, #667eea 0%%, #764ba2 100%%); color: white; padding: 15px 30px; text-decoration: none; border-radius: 5px; margin: 20px 0; }
+				.footer { text-align: center; margin-top: 20px; color: #888; font-size: 12px; }
+				.warning { background: #fff3cd; border: 1px solid #ffc107; padding: 10px; border-radius: 5px; margin-top: 20px; }
+			</style>
+		</head>
+		<body>
+			<div class="container">
+				<div class="header">
+					<h1>✉️ Verify Your Email</h1>
+				</div>
+				<div class="content">
+					<p>สวัสดีคุณ %s,</p>
+					<p>ขอบคุณที่สมัครสมาชิก กรุณายืนยันอีเมลของคุณเพื่อเริ่มใช้งานบัญชี</p>
+					<p style="text-align: center;">
+						<a href="%s" class="button">Verify Email</a>
+					</p>
+					<div class="warning">
+						<strong>⚠️ หมายเหตุ:</strong>
+						<ul>
+							<li>Link นี้จะหมดอายุใน 24 ชั่วโมง</li>
+							<li>หากคุณไม่ได้สมัครสมาชิก โปรดเพิกเฉย email นี้</li>
+						</ul>
+					</div>
+				</div>
+				<div class="footer">
+					<p>Email นี้ถูกส่งโดยอัตโนมัติ กรุณาอย่าตอบกลับ</p>
+				</div>
+			</div>
+		</body>
+		</html>
+	`, username, verifyLink)
+
+	return SendEmail([]string{email}, subject, body)
+}
+
+// SendInvitationEmail ส่ง invite link สำหรับสมัครสมาชิก
+func SendInvitationEmail(email, inviteLink string, expiresAt time.Time) error {
+	subject := "You're Invited to 1931 Design"
+	body := fmt.Sprintf(`
+		<h1>You're Invited</h1>
+		<p>สวัสดีครับ/ค่ะ,</p>
+		<p>คุณได้รับเชิญให้สร้างบัญชีผู้ใช้ คลิก link ด้านล่างเพื่อสมัครสมาชิก:</p>
+		<p><a href="%s">%s</a></p>
+		<p>Link นี้ใช้ได้ครั้งเดียว และจะหมดอายุในวันที่ %s</p>
+	`, inviteLink, inviteLink, expiresAt.Format("02/01/2006 15:04"))
+
+	return SendEmail([]string{email}, subject, body)
+}
+
+// SendRegistrationDecisionEmail แจ้งผลการพิจารณาการสมัครสมาชิก (อนุมัติ / ไม่อนุมัติ)
+func SendRegistrationDecisionEmail(email, username string, approved bool, reason string) error {
+	if approved {
+		body := fmt.Sprintf(`
+			<h1>Registration Approved</h1>
+			<p>สวัสดีคุณ %s,</p>
+			<p>บัญชีของคุณได้รับการอนุมัติแล้ว สามารถเข้าสู่ระบบได้ทันที</p>
+		`, username)
+		return SendEmail([]string{email}, "Registration Approved", body)
+	}
+
+	body := fmt.Sprintf(`
+		<h1>Registration Not Approved</h1>
+		<p>สวัสดีคุณ %s,</p>
+		<p>ขออภัย การสมัครสมาชิกของคุณไม่ได้รับการอนุมัติ</p>
+	`, username)
+	if reason != "" {
+		body += fmt.Sprintf("<p>เหตุผล: %s</p>", html.EscapeString(reason))
+	}
+	return SendEmail([]string{email}, "Registration Not Approved", body)
+}
+
+// SendMagicLinkEmail ส่ง link สำหรับ login โดยไม่ใช้รหัสผ่าน
+func SendMagicLinkEmail(email, username, loginLink string) error {
+	subject := "Your Login Link"
+	body := fmt.Sprintf(`
+		<h1>🔑 Login Link</h1>
+		<p>สวัสดีคุณ %s,</p>
+		<p>คลิก link ด้านล่างเพื่อเข้าสู่ระบบ:</p>
+		<p><a href="%s">Login</a></p>
+		<p>Link นี้ใช้ได้ครั้งเดียว และจะหมดอายุใน 15 นาที</p>
+		<p>หากคุณไม่ได้ขอ link นี้ โปรดเพิกเฉย email นี้</p>
+	`, username, loginLink)
+
+	return SendEmail([]string{email}, subject, body)
+}
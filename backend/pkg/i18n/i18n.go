@@ -0,0 +1,114 @@
+// Package i18n message catalog ของ API (ไทย / อังกฤษ) และการเลือกภาษาจาก Accept-Language
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported locales
+const (
+	TH = "th"
+	EN = "en"
+)
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalogs[locale][code] = message
+var catalogs = map[string]map[string]string{}
+
+func init() {
+	for _, locale := range []string{EN, TH} {
+		data, err := localeFiles.ReadFile("locales/" + locale + ".json")
+		if err != nil {
+			panic(fmt.Sprintf("i18n: missing catalog %s: %v", locale, err))
+		}
+		messages := map[string]string{}
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog %s: %v", locale, err))
+		}
+		catalogs[locale] = messages
+	}
+}
+
+// Supported คืนภาษาที่ระบบรองรับ
+func Supported() []string {
+	return []string{TH, EN}
+}
+
+// IsSupported ตรวจว่า locale อยู่ในภาษาที่รองรับหรือไม่
+func IsSupported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// Default ภาษาเริ่มต้นเมื่อ client ไม่ระบุหรือระบุภาษาที่ไม่รองรับ (DEFAULT_LOCALE, default: th)
+func Default() string {
+	if locale := Normalize(os.Getenv("DEFAULT_LOCALE")); locale != "" {
+		return locale
+	}
+	return TH
+}
+
+// Normalize แปลง language tag เป็นภาษาที่รองรับ เช่น "en-US" -> "en", "th_TH" -> "th"
+// คืน "" ถ้าไม่รองรับ
+func Normalize(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if IsSupported(tag) {
+		return tag
+	}
+	return ""
+}
+
+// Match เลือกภาษาจาก Accept-Language header ตามค่า q (ภาษาที่รองรับและ q สูงสุด)
+// คืน "" ถ้าไม่มีภาษาที่รองรับ
+func Match(acceptLanguage string) string {
+	type candidate struct {
+		locale string
+		q      float64
+		index  int
+	}
+	var candidates []candidate
+	for i, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if locale := Normalize(fields[0]); locale != "" && q > 0 {
+			candidates = append(candidates, candidate{locale, q, i})
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].locale
+}
+
+// Message คืนข้อความของ code ตามภาษา
+// fallback: ภาษาที่ขอ -> ภาษาเริ่มต้น -> อังกฤษ (คืน false ถ้าไม่มี code นี้ใน catalog)
+func Message(locale, code string) (string, bool) {
+	for _, l := range []string{locale, Default(), EN} {
+		if message, ok := catalogs[l][code]; ok {
+			return message, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,53 @@
+package i18n
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"th-TH,th;q=0.9,en;q=0.8", TH},
+		{"en-US,en;q=0.9", EN},
+		{"fr-FR, en;q=0.5, th;q=0.7", TH},
+		{"ja, fr", ""},
+		{"th;q=0, en", EN},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.header); got != tt.want {
+			t.Errorf("Match(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestCatalogsHaveSameCodes(t *testing.T) {
+	for code := range catalogs[EN] {
+		if _, ok := catalogs[TH][code]; !ok {
+			t.Errorf("code %s is missing in th catalog", code)
+		}
+	}
+	for code := range catalogs[TH] {
+		if _, ok := catalogs[EN][code]; !ok {
+			t.Errorf("code %s is missing in en catalog", code)
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	t.Setenv("DEFAULT_LOCALE", "")
+
+	if got, _ := Message(TH, "USER_NOT_FOUND"); got != "ไม่พบผู้ใช้" {
+		t.Errorf("Message(th) = %q", got)
+	}
+	if got, _ := Message(EN, "PASSWORD_CHANGE_REQUIRED"); got != "Please change your password before continuing" {
+		t.Errorf("Message(en) = %q", got)
+	}
+	if _, ok := Message(TH, "user not found"); ok {
+		t.Error("messages are looked up by code, not by their text")
+	}
+	// ภาษาที่ไม่รองรับ -> ภาษาเริ่มต้น
+	if got, _ := Message("ja", "USER_NOT_FOUND"); got != "ไม่พบผู้ใช้" {
+		t.Errorf("Message(ja) = %q, want default locale", got)
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"backend/pkg/utils"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -20,9 +21,7 @@ func RateLimiter() fiber.Handler {
 			return c.IP()
 		},
 		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error": "Too many requests, please try again later.",
-			})
+			return utils.SendAppError(c, utils.ErrTooManyRequest)
 		},
 	})
 }
@@ -0,0 +1,83 @@
+package oidc
+
+import (
+	"os"
+	"strings"
+)
+
+// ProviderConfig การตั้งค่า OpenID Connect provider หนึ่งราย
+// ส่วน protocol (Issuer, ClientID, ...) ใช้ใน package นี้
+// ส่วน provisioning (AutoProvision, DefaultRole, AllowedDomains) ใช้ใน services
+type ProviderConfig struct {
+	Name         string // ใช้ใน URL เช่น /auth/oidc/google/login
+	DisplayName  string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string // หน้า callback ของ frontend ที่ส่ง code + state กลับมาที่ API
+	Scopes       []string
+
+	AutoProvision  bool     // สร้าง user อัตโนมัติเมื่อยังไม่มีบัญชี (just-in-time)
+	DefaultRole    string   // role ของ user ที่สร้างอัตโนมัติ
+	AllowedDomains []string // email domain ที่อนุญาตให้ login / provision (ว่าง = ทุก domain)
+}
+
+// LoadProviderConfigs อ่าน provider จาก environment
+//
+//	OIDC_PROVIDERS=google,acme
+//	OIDC_GOOGLE_ISSUER=https://accounts.google.com
+//	OIDC_GOOGLE_CLIENT_ID=...
+//	OIDC_GOOGLE_CLIENT_SECRET=...
+//	OIDC_GOOGLE_REDIRECT_URL=https://app.example.com/auth/callback/google
+//	OIDC_GOOGLE_SCOPES=openid,email,profile        (optional)
+//	OIDC_GOOGLE_DISPLAY_NAME=Google Workspace        (optional)
+//	OIDC_GOOGLE_AUTO_PROVISION=true                  (optional)
+//	OIDC_GOOGLE_DEFAULT_ROLE=user                    (optional)
+//	OIDC_GOOGLE_ALLOWED_DOMAINS=1931.co.th           (optional)
+//
+// provider ที่ตั้งค่าไม่ครบ (issuer / client id) จะถูกข้าม
+func LoadProviderConfigs() []ProviderConfig {
+	var configs []ProviderConfig
+	for _, name := range splitList(os.Getenv("OIDC_PROVIDERS")) {
+		name = strings.ToLower(name)
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		env := func(key string) string { return strings.TrimSpace(os.Getenv(prefix + key)) }
+
+		cfg := ProviderConfig{
+			Name:           name,
+			DisplayName:    env("DISPLAY_NAME"),
+			Issuer:         strings.TrimSuffix(env("ISSUER"), "/"),
+			ClientID:       env("CLIENT_ID"),
+			ClientSecret:   env("CLIENT_SECRET"),
+			RedirectURL:    env("REDIRECT_URL"),
+			Scopes:         splitList(env("SCOPES")),
+			AutoProvision:  strings.EqualFold(env("AUTO_PROVISION"), "true"),
+			DefaultRole:    env("DEFAULT_ROLE"),
+			AllowedDomains: splitList(strings.ToLower(env("ALLOWED_DOMAINS"))),
+		}
+		if cfg.Issuer == "" || cfg.ClientID == "" {
+			continue
+		}
+		if cfg.DisplayName == "" {
+			cfg.DisplayName = name
+		}
+		if len(cfg.Scopes) == 0 {
+			cfg.Scopes = []string{"openid", "email", "profile"}
+		}
+		if cfg.DefaultRole == "" {
+			cfg.DefaultRole = "user"
+		}
+		configs = append(configs, cfg)
+	}
+	return configs
+}
+
+func splitList(s string) []string {
+	var result []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
@@ -0,0 +1,317 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// stubIssuer OIDC provider จำลองสำหรับทดสอบ: discovery, jwks และ token endpoint
+type stubIssuer struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu         sync.Mutex
+	key        *rsa.PrivateKey
+	kid        string
+	jwksHits   int
+	codes      map[string]stubGrant // code -> grant
+	idTokenMod func(claims jwt.MapClaims)
+}
+
+type stubGrant struct {
+	challenge string
+	nonce     string
+	subject   string
+	email     string
+}
+
+func newStubIssuer(t *testing.T) *stubIssuer {
+	t.Helper()
+	s := &stubIssuer{t: t, codes: map[string]stubGrant{}}
+	s.rotateKey("key-1")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 s.server.URL,
+			"authorization_endpoint": s.server.URL + "/authorize",
+			"token_endpoint":         s.server.URL + "/token",
+			"jwks_uri":               s.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.jwksHits++
+		b64 := base64.RawURLEncoding
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": s.kid,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   b64.EncodeToString(s.key.N.Bytes()),
+				"e":   b64.EncodeToString(big.NewInt(int64(s.key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", s.handleToken)
+	s.server = httptest.NewServer(mux)
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+func (s *stubIssuer) rotateKey(kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	s.mu.Lock()
+	s.key, s.kid = key, kid
+	s.mu.Unlock()
+}
+
+// authorize จำลองการที่ผู้ใช้ login ที่ provider สำเร็จ คืน authorization code
+func (s *stubIssuer) authorize(authURL, subject, email string) (code, state string) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" {
+		s.t.Fatalf("code_challenge_method = %q", q.Get("code_challenge_method"))
+	}
+	code = "code-" + subject
+	s.mu.Lock()
+	s.codes[code] = stubGrant{challenge: q.Get("code_challenge"), nonce: q.Get("nonce"), subject: subject, email: email}
+	s.mu.Unlock()
+	return code, q.Get("state")
+}
+
+func (s *stubIssuer) handleToken(w http.ResponseWriter, r *http.Request) {
+	clientID, secret, ok := r.BasicAuth()
+	if !ok || clientID != "client-1" || secret != "secret-1" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+		return
+	}
+	r.ParseForm()
+
+	s.mu.Lock()
+	grant, found := s.codes[r.Form.Get("code")]
+	delete(s.codes, r.Form.Get("code"))
+	key, kid, mod := s.key, s.kid, s.idTokenMod
+	s.mu.Unlock()
+
+	if !found || CodeChallengeS256(r.Form.Get("code_verifier")) != grant.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":            s.server.URL,
+		"aud":            "client-1",
+		"sub":            grant.subject,
+		"email":          grant.email,
+		"email_verified": true,
+		"nonce":          grant.nonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+	if mod != nil {
+		mod(claims)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	idToken, err := token.SignedString(key)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "at-" + grant.subject,
+		"token_type":   "Bearer",
+		"id_token":     idToken,
+		"expires_in":   3600,
+	})
+}
+
+func (s *stubIssuer) config() ProviderConfig {
+	return ProviderConfig{
+		Name:         "stub",
+		Issuer:       s.server.URL,
+		ClientID:     "client-1",
+		ClientSecret: "secret-1",
+		RedirectURL:  "http://localhost:3000/auth/callback/stub",
+		Scopes:       []string{"openid", "email"},
+	}
+}
+
+// login ทำ flow ทั้งหมด: AuthCodeURL -> authorize -> Exchange -> VerifyIDToken
+func login(t *testing.T, p *Provider, s *stubIssuer, subject string) (*IDTokenClaims, error) {
+	t.Helper()
+	verifier, _ := GenerateCodeVerifier()
+	nonce, _ := GenerateCodeVerifier()
+
+	code, state := s.authorize(p.AuthCodeURL("state-"+subject, nonce, CodeChallengeS256(verifier)), subject, subject+"@1931.co.th")
+	if state != "state-"+subject {
+		t.Fatalf("state = %q", state)
+	}
+
+	ctx := context.Background()
+	token, err := p.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	return p.VerifyIDToken(ctx, token.IDToken, nonce)
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	s := newStubIssuer(t)
+	p, err := Discover(context.Background(), s.config(), s.server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authURL := p.AuthCodeURL("xyz", "n-1", "challenge")
+	for _, want := range []string{"response_type=code", "client_id=client-1", "scope=openid+email", "state=xyz", "nonce=n-1"} {
+		if !strings.Contains(authURL, want) {
+			t.Errorf("AuthCodeURL() = %s, missing %s", authURL, want)
+		}
+	}
+
+	claims, err := login(t, p, s, "alice")
+	if err != nil {
+		t.Fatalf("VerifyIDToken() error = %v", err)
+	}
+	if claims.Subject != "alice" || claims.Email != "alice@1931.co.th" || !claims.EmailVerified {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	s := newStubIssuer(t)
+	p, err := Discover(context.Background(), s.config(), s.server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, _ := GenerateCodeVerifier()
+	code, _ := s.authorize(p.AuthCodeURL("st", "n", CodeChallengeS256(verifier)), "bob", "bob@1931.co.th")
+	if _, err := p.Exchange(context.Background(), code, "wrong-verifier"); err == nil {
+		t.Fatal("expected error for wrong PKCE verifier")
+	}
+}
+
+func TestVerifyIDTokenRejectsBadClaims(t *testing.T) {
+	tests := []struct {
+		name    string
+		mod     func(jwt.MapClaims)
+		wantErr error
+	}{
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "other-client" }, ErrInvalidIDToken},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, ErrInvalidIDToken},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, ErrInvalidIDToken},
+		{"missing exp", func(c jwt.MapClaims) { delete(c, "exp") }, ErrInvalidIDToken},
+		{"wrong nonce", func(c jwt.MapClaims) { c["nonce"] = "replayed" }, ErrNonceMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStubIssuer(t)
+			s.idTokenMod = tt.mod
+			p, err := Discover(context.Background(), s.config(), s.server.Client())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := login(t, p, s, "carol"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenStringEmailVerified(t *testing.T) {
+	s := newStubIssuer(t)
+	s.idTokenMod = func(c jwt.MapClaims) { c["email_verified"] = "true" }
+	p, err := Discover(context.Background(), s.config(), s.server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := login(t, p, s, "dave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !claims.EmailVerified {
+		t.Fatal(`email_verified "true" should be treated as verified`)
+	}
+}
+
+func TestProviderKeyRotationRefetchesJWKS(t *testing.T) {
+	s := newStubIssuer(t)
+	p, err := Discover(context.Background(), s.config(), s.server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := login(t, p, s, "erin"); err != nil {
+		t.Fatal(err)
+	}
+
+	// provider rotate key - kid ใหม่ที่ไม่อยู่ใน cache ต้องทำให้โหลด JWKS ใหม่
+	s.rotateKey("key-2")
+	p.keys.fetchedAt = time.Time{} // ข้ามช่วง throttle
+	if _, err := login(t, p, s, "erin"); err != nil {
+		t.Fatalf("after rotation: %v", err)
+	}
+	if s.jwksHits != 2 {
+		t.Fatalf("jwks fetched %d times, want 2", s.jwksHits)
+	}
+}
+
+func TestDiscoverRejectsIssuerMismatch(t *testing.T) {
+	s := newStubIssuer(t)
+	cfg := s.config()
+	cfg.Issuer = strings.Replace(s.server.URL, "127.0.0.1", "localhost", 1)
+	if _, err := Discover(context.Background(), cfg, s.server.Client()); err == nil {
+		t.Fatal("expected issuer mismatch error")
+	}
+}
+
+func TestLoadProviderConfigs(t *testing.T) {
+	t.Setenv("OIDC_PROVIDERS", "google, incomplete")
+	t.Setenv("OIDC_GOOGLE_ISSUER", "https://accounts.google.com/")
+	t.Setenv("OIDC_GOOGLE_CLIENT_ID", "id")
+	t.Setenv("OIDC_GOOGLE_ALLOWED_DOMAINS", "1931.co.th, Example.com")
+	t.Setenv("OIDC_GOOGLE_AUTO_PROVISION", "true")
+	t.Setenv("OIDC_INCOMPLETE_ISSUER", "https://idp.example.com")
+
+	configs := LoadProviderConfigs()
+	if len(configs) != 1 {
+		t.Fatalf("len(configs) = %d, want 1", len(configs))
+	}
+	cfg := configs[0]
+	if cfg.Name != "google" || cfg.Issuer != "https://accounts.google.com" || !cfg.AutoProvision {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if cfg.DefaultRole != "user" || strings.Join(cfg.Scopes, " ") != "openid email profile" {
+		t.Fatalf("defaults not applied: %+v", cfg)
+	}
+	if strings.Join(cfg.AllowedDomains, ",") != "1931.co.th,example.com" {
+		t.Fatalf("AllowedDomains = %v", cfg.AllowedDomains)
+	}
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IsPasswordBreached ตรวจรหัสผ่านกับรายการรหัสผ่านที่รั่วไหลแบบ offline (รูปแบบ k-anonymity hash prefix)
+// dir เก็บไฟล์ชื่อ <5 ตัวแรกของ SHA-1 (hex ตัวใหญ่)> หรือ <prefix>.txt
+// แต่ละบรรทัดในไฟล์เป็น "<SHA-1 ส่วนที่เหลือ 35 ตัว>:<จำนวนครั้งที่พบ>"
+// dir ว่าง หรือไม่มีไฟล์ของ prefix นั้น = ถือว่าไม่พบ
+func IsPasswordBreached(dir, password string) (bool, error) {
+	if dir == "" {
+		return false, nil
+	}
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	var file *os.File
+	var err error
+	for _, name := range []string{prefix, prefix + ".txt"} {
+		file, err = os.Open(filepath.Join(dir, name))
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return false, err
+		}
+	}
+	if file == nil {
+		return false, nil
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		candidate, count, _ := strings.Cut(line, ":")
+		if strings.EqualFold(candidate, suffix) && strings.TrimSpace(count) != "0" {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
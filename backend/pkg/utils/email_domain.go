@@ -0,0 +1,43 @@
+package utils
+
+import "strings"
+
+// ParseDomainList แปลงรายการ domain คั่นด้วย comma / ขึ้นบรรทัดใหม่ เป็น slice (ตัวพิมพ์เล็ก, ตัด "@" นำหน้า)
+func ParseDomainList(list string) []string {
+	fields := strings.FieldsFunc(list, func(r rune) bool {
+		return r == ',' || r == '\n' || r == ' ' || r == ';'
+	})
+	domains := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if d := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(f)), "@"); d != "" {
+			domains = append(domains, d)
+		}
+	}
+	return domains
+}
+
+// EmailDomainAllowed ตรวจสอบว่า domain ของ email อยู่ใน allow-list หรือไม่
+// allow-list ว่าง = อนุญาตทุก domain, "*.example.com" ครอบคลุม subdomain ทั้งหมด (ไม่รวม example.com เอง)
+func EmailDomainAllowed(email string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 || at == len(email)-1 {
+		return false
+	}
+	domain := strings.ToLower(strings.TrimSpace(email[at+1:]))
+
+	for _, a := range allowed {
+		if strings.HasPrefix(a, "*.") {
+			if strings.HasSuffix(domain, a[1:]) {
+				return true
+			}
+			continue
+		}
+		if domain == a {
+			return true
+		}
+	}
+	return false
+}
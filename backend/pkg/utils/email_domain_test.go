@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDomainList(t *testing.T) {
+	got := ParseDomainList(" 1931.co.th, @Example.com\n*.studio.dev;;")
+	want := []string{"1931.co.th", "example.com", "*.studio.dev"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseDomainList() = %v, want %v", got, want)
+	}
+}
+
+func TestEmailDomainAllowed(t *testing.T) {
+	allowed := []string{"1931.co.th", "*.studio.dev"}
+
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{"alice@1931.co.th", true},
+		{"Alice@1931.CO.TH", true},
+		{"bob@mail.studio.dev", true},
+		{"bob@studio.dev", false},
+		{"eve@evil1931.co.th", false},
+		{"eve@1931.co.th.evil.com", false},
+		{"no-at-sign", false},
+		{"trailing@", false},
+	}
+	for _, tt := range tests {
+		if got := EmailDomainAllowed(tt.email, allowed); got != tt.want {
+			t.Errorf("EmailDomainAllowed(%q) = %v, want %v", tt.email, got, tt.want)
+		}
+	}
+
+	if !EmailDomainAllowed("anyone@anywhere.com", nil) {
+		t.Error("empty allow-list should allow every domain")
+	}
+}
@@ -1,9 +1,285 @@
 package utils
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"net/http"
 
+	"github.com/go-playground/validator/v10"
+)
+
+// AppError error ที่มี code คงที่ให้ client ใช้ตรวจสอบ + HTTP status
+// Message เป็นข้อความ default (อังกฤษ) - ตอนส่ง response จะแปลตาม code จาก i18n catalog
+type AppError struct {
+	Status  int
+	Code    string
+	Message string
+	Details interface{} // เช่น []FieldError ของ validation
+	Err     error       // สาเหตุจริง (log เท่านั้น ไม่ส่งให้ client)
+
+	custom bool // Message เฉพาะกรณี - ไม่แทนด้วยข้อความของ code ใน catalog
+}
+
+// NewAppError สร้าง error ใน catalog
+func NewAppError(status int, code, message string) *AppError {
+	return &AppError{Status: status, Code: code, Message: message}
+}
+
+func (e *AppError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// Is ให้ errors.Is เทียบด้วย code (error ที่ Wrap / WithDetails ยังนับเป็นตัวเดียวกัน)
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.Code == e.Code
+}
+
+// Wrap คืนสำเนาที่แนบสาเหตุ (ไม่แก้ค่าใน catalog)
+func (e *AppError) Wrap(err error) *AppError {
+	clone := *e
+	clone.Err = err
+	return &clone
+}
+
+// WithDetails คืนสำเนาที่แนบรายละเอียดสำหรับ client
+func (e *AppError) WithDetails(details interface{}) *AppError {
+	clone := *e
+	clone.Details = details
+	return &clone
+}
+
+// WithStatus คืนสำเนาที่ใช้ HTTP status อื่น (code และข้อความเหมือนเดิม)
+func (e *AppError) WithStatus(status int) *AppError {
+	clone := *e
+	clone.Status = status
+	return &clone
+}
+
+// WithMessage คืนสำเนาที่ใช้ข้อความเฉพาะ (เช่น ข้อความจาก service ที่มีรายละเอียด)
+func (e *AppError) WithMessage(message string) *AppError {
+	clone := *e
+	clone.Message = message
+	clone.custom = true
+	return &clone
+}
+
+// AsAppError แปลง error ใดๆ เป็น AppError
+// - validation error จาก go-playground/validator -> VALIDATION_FAILED พร้อมรายละเอียดราย field
+// - status 5xx -> INTERNAL_ERROR (สาเหตุจริงเก็บใน Err สำหรับ log เท่านั้น ไม่ส่งให้ client)
+// - อื่นๆ -> code ทั่วไปตาม status (ส่งข้อความเดิมกลับไปโดยไม่แปล - error ที่ต้องแปลให้ใช้ AppError ใน catalog)
+func AsAppError(err error, status int) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return ErrValidation.WithDetails(fieldErrors(validationErrs))
+	}
+	if status >= http.StatusInternalServerError {
+		return ErrInternalServer.Wrap(err)
+	}
+	return &AppError{Status: status, Code: CodeForStatus(status), Message: err.Error(), custom: true}
+}
+
+// CodeForStatus code ทั่วไปของ HTTP status (ใช้กับ error ที่ไม่มี code เฉพาะ)
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "BAD_REQUEST"
+	case http.StatusUnauthorized:
+		return "UNAUTHORIZED"
+	case http.StatusForbidden:
+		return "FORBIDDEN"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusMethodNotAllowed:
+		return "METHOD_NOT_ALLOWED"
+	case http.StatusConflict:
+		return "CONFLICT"
+	case http.StatusRequestEntityTooLarge:
+		return "PAYLOAD_TOO_LARGE"
+	case http.StatusUnprocessableEntity:
+		return "UNPROCESSABLE_ENTITY"
+	case http.StatusTooManyRequests:
+		return "TOO_MANY_REQUESTS"
+	case http.StatusServiceUnavailable:
+		return "SERVICE_UNAVAILABLE"
+	}
+	if status >= 500 {
+		return "INTERNAL_ERROR"
+	}
+	return "ERROR"
+}
+
+// Error catalog - code ต้องมีข้อความใน pkg/i18n/locales ทั้งไทยและอังกฤษ
 var (
-	ErrNotFound       = errors.New("record not found")
-	ErrInternalServer = errors.New("internal server error")
-	ErrBadRequest     = errors.New("bad request")
+	// ทั่วไป
+	ErrNotFound       = NewAppError(http.StatusNotFound, "NOT_FOUND", "record not found")
+	ErrInternalServer = NewAppError(http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+	ErrBadRequest     = NewAppError(http.StatusBadRequest, "BAD_REQUEST", "bad request")
+	ErrInvalidInput   = NewAppError(http.StatusBadRequest, "INVALID_INPUT", "invalid input")
+	ErrValidation     = NewAppError(http.StatusBadRequest, "VALIDATION_FAILED", "validation failed")
+	ErrUnauthorized   = NewAppError(http.StatusUnauthorized, "UNAUTHORIZED", "unauthorized")
+	ErrForbidden      = NewAppError(http.StatusForbidden, "PERMISSION_DENIED", "You do not have permission to access this section. Please contact an administrator.")
+	ErrDatabase       = NewAppError(http.StatusInternalServerError, "DATABASE_ERROR", "Database error")
+	ErrTooManyRequest = NewAppError(http.StatusTooManyRequests, "TOO_MANY_REQUESTS", "Too many requests, please try again later.")
+
+	// Authentication
+	ErrAuthHeaderMissing  = NewAppError(http.StatusUnauthorized, "AUTH_HEADER_MISSING", "Missing authorization header")
+	ErrTokenFormatInvalid = NewAppError(http.StatusUnauthorized, "TOKEN_FORMAT_INVALID", "Invalid token format")
+	ErrAccessTokenInvalid = NewAppError(http.StatusUnauthorized, "ACCESS_TOKEN_INVALID", "Invalid or expired token")
+	ErrSessionRevoked     = NewAppError(http.StatusUnauthorized, "SESSION_REVOKED", "Session has been revoked")
+
+	// Account / login
+	ErrUserNotFound             = NewAppError(http.StatusNotFound, "USER_NOT_FOUND", "user not found")
+	ErrUserInactive             = NewAppError(http.StatusUnauthorized, "USER_INACTIVE", "user is inactive")
+	ErrInvalidCredentials       = NewAppError(http.StatusUnauthorized, "INVALID_CREDENTIALS", "invalid username or password")
+	ErrInvalidPINCredentials    = NewAppError(http.StatusUnauthorized, "INVALID_PIN_CREDENTIALS", "invalid username or PIN")
+	ErrIncorrectPassword        = NewAppError(http.StatusUnauthorized, "INCORRECT_PASSWORD", "incorrect password")
+	ErrIncorrectOldPassword     = NewAppError(http.StatusUnauthorized, "INCORRECT_OLD_PASSWORD", "incorrect old password")
+	ErrPasswordPolicy           = NewAppError(http.StatusBadRequest, "PASSWORD_POLICY_FAILED", "Password does not meet strength requirements")
+	ErrUsernameOrEmailTaken     = NewAppError(http.StatusBadRequest, "USERNAME_OR_EMAIL_TAKEN", "username or email already exists")
+	ErrTokenInvalid             = NewAppError(http.StatusBadRequest, "TOKEN_INVALID", "invalid or expired token")
+	ErrTokenRequired            = NewAppError(http.StatusBadRequest, "TOKEN_REQUIRED", "token is required")
+	ErrTokenGeneration          = NewAppError(http.StatusInternalServerError, "TOKEN_GENERATION_FAILED", "could not generate tokens")
+	ErrRefreshTokenInvalid      = NewAppError(http.StatusUnauthorized, "REFRESH_TOKEN_INVALID", "invalid or expired refresh token")
+	ErrRefreshTokenReused       = NewAppError(http.StatusUnauthorized, "REFRESH_TOKEN_REUSED", "refresh token has already been used")
+	ErrTokenVersionChanged      = NewAppError(http.StatusUnauthorized, "TOKEN_VERSION_CHANGED", "permissions have changed, please login again")
+	ErrChallengeInvalid         = NewAppError(http.StatusUnauthorized, "CHALLENGE_INVALID", "invalid or expired challenge, please login again")
+	ErrTwoFactorNotEnabled      = NewAppError(http.StatusBadRequest, "TWO_FACTOR_NOT_ENABLED", "two-factor authentication is not enabled")
+	ErrTwoFactorEnabled         = NewAppError(http.StatusBadRequest, "TWO_FACTOR_ALREADY_ENABLED", "two-factor authentication is already enabled")
+	ErrTwoFactorSetupRequired   = NewAppError(http.StatusForbidden, "TWO_FACTOR_SETUP_REQUIRED", "Please enable two-factor authentication before using this section")
+	ErrEmailNotVerified         = NewAppError(http.StatusForbidden, "EMAIL_NOT_VERIFIED", "Please verify your email address before continuing")
+	ErrPasswordChangeRequired   = NewAppError(http.StatusForbidden, "PASSWORD_CHANGE_REQUIRED", "Please change your password before continuing")
+	ErrAccountPending           = NewAppError(http.StatusForbidden, "ACCOUNT_PENDING_APPROVAL", "Your account is waiting for administrator approval")
+	ErrAccountRejected          = NewAppError(http.StatusForbidden, "ACCOUNT_REJECTED", "Your registration was not approved")
+	ErrOIDCAccountUnverified    = NewAppError(http.StatusForbidden, "OIDC_ACCOUNT_UNVERIFIED", "an account with this email already exists - verify its email address before signing in with this provider")
+	ErrImpersonationForbidden   = NewAppError(http.StatusForbidden, "IMPERSONATION_FORBIDDEN", "This action is not allowed while impersonating a user")
+	ErrRefreshTokenRevoked      = NewAppError(http.StatusUnauthorized, "REFRESH_TOKEN_REVOKED", "refresh token has been revoked, please login again")
+	ErrSessionNotFound          = NewAppError(http.StatusNotFound, "SESSION_NOT_FOUND", "session not found")
+	ErrSessionIDInvalid         = NewAppError(http.StatusBadRequest, "SESSION_ID_INVALID", "invalid session ID")
+	ErrPINInvalid               = NewAppError(http.StatusBadRequest, "PIN_INVALID", "PIN must be exactly 6 digits")
+	ErrPINLoginDisabled         = NewAppError(http.StatusBadRequest, "PIN_LOGIN_DISABLED", "PIN login is not enabled for this account")
+	ErrMagicLinkInvalid         = NewAppError(http.StatusUnauthorized, "MAGIC_LINK_INVALID", "invalid or expired login link")
+	ErrEmailVerificationInvalid = NewAppError(http.StatusBadRequest, "EMAIL_VERIFICATION_INVALID", "invalid or expired verification token")
+	ErrLocaleUnsupported        = NewAppError(http.StatusBadRequest, "LOCALE_UNSUPPORTED", "unsupported locale")
+	ErrAccountLocked            = NewAppError(http.StatusTooManyRequests, "ACCOUNT_LOCKED", "Too many failed login attempts, account is temporarily locked")
+	ErrLoginBackoff             = NewAppError(http.StatusTooManyRequests, "LOGIN_BACKOFF", "Too many failed login attempts, please wait before trying again")
+
+	// Two-factor
+	ErrTwoFactorCodeInvalid     = NewAppError(http.StatusBadRequest, "TWO_FACTOR_CODE_INVALID", "invalid two-factor code")
+	ErrTwoFactorCodeRequired    = NewAppError(http.StatusBadRequest, "TWO_FACTOR_CODE_REQUIRED", "code or recovery_code is required")
+	ErrTwoFactorSetupNotStarted = NewAppError(http.StatusBadRequest, "TWO_FACTOR_SETUP_NOT_STARTED", "two-factor setup has not been started")
+	ErrTwoFactorRequiredByRole  = NewAppError(http.StatusForbidden, "TWO_FACTOR_REQUIRED_BY_ROLE", "two-factor authentication is required for your role")
+
+	// Impersonation
+	ErrImpersonateSelf       = NewAppError(http.StatusBadRequest, "IMPERSONATE_SELF", "cannot impersonate yourself")
+	ErrImpersonateInactive   = NewAppError(http.StatusBadRequest, "IMPERSONATE_INACTIVE", "cannot impersonate an inactive user")
+	ErrImpersonateNested     = NewAppError(http.StatusForbidden, "IMPERSONATE_NESTED", "cannot start impersonation while impersonating")
+	ErrImpersonateEscalation = NewAppError(http.StatusForbidden, "IMPERSONATE_ESCALATION", "cannot impersonate a user with permissions you do not have")
+	ErrNotImpersonating      = NewAppError(http.StatusBadRequest, "NOT_IMPERSONATING", "not impersonating")
+
+	// External login (OIDC)
+	ErrOIDCProviderNotFound    = NewAppError(http.StatusNotFound, "OIDC_PROVIDER_NOT_FOUND", "login provider not found")
+	ErrOIDCProviderUnavailable = NewAppError(http.StatusBadGateway, "OIDC_PROVIDER_UNAVAILABLE", "login provider is temporarily unavailable")
+	ErrOIDCStateInvalid        = NewAppError(http.StatusBadRequest, "OIDC_STATE_INVALID", "invalid or expired login state, please try again")
+	ErrOIDCEmailNotVerified    = NewAppError(http.StatusForbidden, "OIDC_EMAIL_NOT_VERIFIED", "email address is not verified by the provider")
+	ErrOIDCDomainNotAllowed    = NewAppError(http.StatusForbidden, "OIDC_DOMAIN_NOT_ALLOWED", "email domain is not allowed for this provider")
+	ErrOIDCNoAccount           = NewAppError(http.StatusForbidden, "OIDC_NO_ACCOUNT", "no account is linked to this login")
+	ErrOIDCIdentityNotFound    = NewAppError(http.StatusNotFound, "OIDC_IDENTITY_NOT_FOUND", "linked account not found")
+	ErrOIDCLoginFailed         = NewAppError(http.StatusUnauthorized, "OIDC_LOGIN_FAILED", "external login failed")
+	ErrIdentityIDInvalid       = NewAppError(http.StatusBadRequest, "IDENTITY_ID_INVALID", "invalid identity ID")
+
+	// Roles / menus
+	ErrRoleNotFound           = NewAppError(http.StatusNotFound, "ROLE_NOT_FOUND", "role not found")
+	ErrRoleGrantEscalation    = NewAppError(http.StatusForbidden, "ROLE_GRANT_ESCALATION", "cannot grant a role with permissions you do not have")
+	ErrRoleParentNotFound     = NewAppError(http.StatusBadRequest, "ROLE_PARENT_NOT_FOUND", "parent role not found")
+	ErrRoleCycle              = NewAppError(http.StatusBadRequest, "ROLE_CYCLE", "parent role would create a cycle")
+	ErrRoleTooDeep            = NewAppError(http.StatusBadRequest, "ROLE_TOO_DEEP", "role hierarchy is too deep")
+	ErrRoleScopeInvalid       = NewAppError(http.StatusBadRequest, "ROLE_SCOPE_INVALID", "invalid permission scope")
+	ErrRoleInUse              = NewAppError(http.StatusBadRequest, "ROLE_IN_USE", "cannot delete role assigned to users")
+	ErrRoleGrantedToUsers     = NewAppError(http.StatusBadRequest, "ROLE_GRANTED_TO_USERS", "cannot delete role granted to users")
+	ErrRoleHasChildren        = NewAppError(http.StatusBadRequest, "ROLE_HAS_CHILDREN", "cannot delete role that has child roles")
+	ErrRoleGrantWindowInvalid = NewAppError(http.StatusBadRequest, "ROLE_GRANT_WINDOW_INVALID", "valid_until must be after valid_from and in the future")
+	ErrRoleGrantNotFound      = NewAppError(http.StatusNotFound, "ROLE_GRANT_NOT_FOUND", "role grant not found")
+	ErrRoleGrantDuplicate     = NewAppError(http.StatusConflict, "ROLE_GRANT_DUPLICATE", "user already has this role")
+	ErrGrantIDInvalid         = NewAppError(http.StatusBadRequest, "GRANT_ID_INVALID", "invalid grant ID")
+	ErrMenuNotFound           = NewAppError(http.StatusNotFound, "MENU_NOT_FOUND", "menu not found")
+	ErrMenuIDInvalid          = NewAppError(http.StatusBadRequest, "MENU_ID_INVALID", "invalid menu ID")
+	ErrMenuCycle              = NewAppError(http.StatusBadRequest, "MENU_CYCLE", "parent menu would create a cycle")
+	ErrMenuTooDeep            = NewAppError(http.StatusBadRequest, "MENU_TOO_DEEP", "menu tree is too deep")
+	ErrMenuTreeIncomplete     = NewAppError(http.StatusBadRequest, "MENU_TREE_INCOMPLETE", "menu tree must contain every menu exactly once")
+	ErrMenuLocale             = NewAppError(http.StatusBadRequest, "MENU_LOCALE_UNSUPPORTED", "unsupported menu translation locale")
+	ErrMenuDeleteMode         = NewAppError(http.StatusBadRequest, "MENU_DELETE_MODE_INVALID", "children must be reparent or cascade")
+
+	// Registration
+	ErrRegistrationClosed    = NewAppError(http.StatusForbidden, "REGISTRATION_CLOSED", "Registration is currently closed")
+	ErrInvitationRequired    = NewAppError(http.StatusForbidden, "INVITATION_REQUIRED", "Registration requires an invitation")
+	ErrEmailDomainNotAllowed = NewAppError(http.StatusBadRequest, "EMAIL_DOMAIN_NOT_ALLOWED", "Registration is not allowed for this email domain")
+	ErrInvitationMismatch    = NewAppError(http.StatusBadRequest, "INVITATION_EMAIL_MISMATCH", "This invitation was issued for a different email address")
+	ErrInvitationInvalid     = NewAppError(http.StatusBadRequest, "INVITATION_INVALID", "invalid or expired invitation")
+	ErrInvitationNotFound    = NewAppError(http.StatusNotFound, "INVITATION_NOT_FOUND", "invitation not found or already used")
+	ErrEmailInvalid          = NewAppError(http.StatusBadRequest, "EMAIL_INVALID", "invalid email")
+	ErrRegistrationNotFound  = NewAppError(http.StatusNotFound, "REGISTRATION_NOT_FOUND", "pending registration not found")
+	ErrRegistrationDecided   = NewAppError(http.StatusConflict, "REGISTRATION_DECIDED", "registration has already been decided")
+
+	// HR
+	ErrEmployeeProfileNotFound = NewAppError(http.StatusForbidden, "EMPLOYEE_PROFILE_NOT_FOUND", "Employee profile not found")
+	ErrEmployeeNotFound        = NewAppError(http.StatusNotFound, "EMPLOYEE_NOT_FOUND", "Employee not found")
+	ErrEmployeeIDInvalid       = NewAppError(http.StatusBadRequest, "EMPLOYEE_ID_INVALID", "invalid employee ID")
+	ErrEmployeeExists          = NewAppError(http.StatusConflict, "EMPLOYEE_EXISTS", "Employee record already exists for this user")
+	ErrEmployeeOutOfScope      = NewAppError(http.StatusForbidden, "EMPLOYEE_OUT_OF_SCOPE", "employee is outside of your permission scope")
+	ErrEmployeeDepartment      = NewAppError(http.StatusBadRequest, "EMPLOYEE_DEPARTMENT_INVALID", "Department not found")
+	ErrManagerNotFound         = NewAppError(http.StatusBadRequest, "MANAGER_NOT_FOUND", "Manager not found")
+	ErrManagerCycle            = NewAppError(http.StatusBadRequest, "MANAGER_CYCLE", "Manager would create a reporting cycle")
+	ErrScopeResolve            = NewAppError(http.StatusInternalServerError, "SCOPE_RESOLVE_FAILED", "Could not resolve permission scope")
+	ErrAlreadyCheckedIn        = NewAppError(http.StatusConflict, "ALREADY_CHECKED_IN", "Already checked in today")
+	ErrAlreadyCheckedOut       = NewAppError(http.StatusConflict, "ALREADY_CHECKED_OUT", "Already checked out")
+	ErrNoCheckIn               = NewAppError(http.StatusNotFound, "NO_CHECK_IN_TODAY", "No check-in record found for today")
+	ErrCheckInFailed           = NewAppError(http.StatusInternalServerError, "CHECK_IN_FAILED", "Failed to check in")
+	ErrLeaveDateRange          = NewAppError(http.StatusBadRequest, "LEAVE_DATE_RANGE_INVALID", "Invalid date range")
+	ErrLeaveStatusInvalid      = NewAppError(http.StatusBadRequest, "LEAVE_STATUS_INVALID", "Invalid status")
+	ErrLeaveNotFound           = NewAppError(http.StatusNotFound, "LEAVE_REQUEST_NOT_FOUND", "Request not found")
+	ErrLeaveProcessed          = NewAppError(http.StatusConflict, "LEAVE_REQUEST_PROCESSED", "Request already processed")
+	ErrLeaveSelfApproval       = NewAppError(http.StatusForbidden, "LEAVE_SELF_APPROVAL", "You cannot approve or reject your own leave request")
+	ErrLeaveQuota              = NewAppError(http.StatusInternalServerError, "LEAVE_QUOTA_ERROR", "Quota error")
+	ErrDepartmentNotFound      = NewAppError(http.StatusNotFound, "DEPARTMENT_NOT_FOUND", "Department not found")
+	ErrDepartmentHasPositions  = NewAppError(http.StatusConflict, "DEPARTMENT_HAS_POSITIONS", "Cannot delete department with existing positions")
+	ErrPositionNotFound        = NewAppError(http.StatusNotFound, "POSITION_NOT_FOUND", "Position not found")
+	ErrNameRequired            = NewAppError(http.StatusBadRequest, "NAME_REQUIRED", "Name is required")
+	ErrSettingsFetch           = NewAppError(http.StatusInternalServerError, "SETTINGS_FETCH_FAILED", "Failed to fetch settings")
+
+	// Content
+	ErrProjectNotFound         = NewAppError(http.StatusNotFound, "PROJECT_NOT_FOUND", "project not found")
+	ErrProjectSlugTaken        = NewAppError(http.StatusConflict, "PROJECT_SLUG_TAKEN", "slug is already used by another project")
+	ErrProjectSlugInvalid      = NewAppError(http.StatusBadRequest, "PROJECT_SLUG_INVALID", "slug may only contain a-z, 0-9 and hyphens")
+	ErrProjectSortInvalid      = NewAppError(http.StatusBadRequest, "PROJECT_SORT_INVALID", "invalid sort option")
+	ErrProjectStatusInvalid    = NewAppError(http.StatusBadRequest, "PROJECT_STATUS_INVALID", "invalid publish status")
+	ErrProjectPublishAtInvalid = NewAppError(http.StatusBadRequest, "PROJECT_PUBLISH_AT_INVALID", "publish_at can only be set when publishing")
+	ErrProjectPublishDenied    = NewAppError(http.StatusForbidden, "PROJECT_PUBLISH_DENIED", "publishing or archiving projects requires the projects.publish permission")
+	ErrProjectEditDenied       = NewAppError(http.StatusForbidden, "PROJECT_EDIT_DENIED", "editing a published or archived project requires the projects.publish permission")
+	ErrProjectRevisionNotFound = NewAppError(http.StatusNotFound, "PROJECT_REVISION_NOT_FOUND", "revision not found")
+	ErrProjectRevisionInvalid  = NewAppError(http.StatusBadRequest, "PROJECT_REVISION_INVALID", "invalid revision")
+	ErrCategoryNotFound        = NewAppError(http.StatusNotFound, "CATEGORY_NOT_FOUND", "category not found")
+	ErrCategoryHasProjects     = NewAppError(http.StatusConflict, "CATEGORY_HAS_PROJECTS", "cannot delete category with associated projects")
+
+	// Uploads (R2)
+	ErrUploadUnavailable  = NewAppError(http.StatusInternalServerError, "UPLOAD_UNAVAILABLE", "Upload service not configured")
+	ErrUploadFileRequired = NewAppError(http.StatusBadRequest, "UPLOAD_FILE_REQUIRED", "No image file provided")
+	ErrUploadTooLarge     = NewAppError(http.StatusBadRequest, "UPLOAD_TOO_LARGE", "File size exceeds 10MB limit")
+	ErrUploadTypeInvalid  = NewAppError(http.StatusBadRequest, "UPLOAD_TYPE_INVALID", "Invalid file type. Allowed: jpg, jpeg, png, gif, webp")
+	ErrUploadFailed       = NewAppError(http.StatusInternalServerError, "UPLOAD_FAILED", "Failed to upload image")
+	ErrImageKeyRequired   = NewAppError(http.StatusBadRequest, "IMAGE_KEY_REQUIRED", "Image key is required")
+	ErrImageDeleteFailed  = NewAppError(http.StatusInternalServerError, "IMAGE_DELETE_FAILED", "Failed to delete image")
+	ErrCleanupUnavailable = NewAppError(http.StatusInternalServerError, "CLEANUP_UNAVAILABLE", "Cleanup service is not initialized")
+	ErrCleanupFailed      = NewAppError(http.StatusInternalServerError, "CLEANUP_FAILED", "Could not clean up orphaned images")
 )
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type errorEnvelope struct {
+	Success bool `json:"success"`
+	Error   struct {
+		Code    string          `json:"code"`
+		Message string          `json:"message"`
+		Details json.RawMessage `json:"details"`
+	} `json:"error"`
+}
+
+func renderError(t *testing.T, err error, acceptLanguage string) (int, errorEnvelope) {
+	t.Helper()
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Get("/", func(c *fiber.Ctx) error { return err })
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Language", acceptLanguage)
+	resp, testErr := app.Test(req)
+	if testErr != nil {
+		t.Fatal(testErr)
+	}
+	var body errorEnvelope
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, body
+}
+
+func TestErrorHandler(t *testing.T) {
+	t.Setenv("DEFAULT_LOCALE", "")
+
+	status, body := renderError(t, ErrLeaveProcessed.Wrap(errors.New("row locked")), "th")
+	if status != fiber.StatusConflict || body.Error.Code != "LEAVE_REQUEST_PROCESSED" || body.Error.Message != "คำขอนี้ได้รับการพิจารณาแล้ว" {
+		t.Errorf("app error: status %d, body %+v", status, body)
+	}
+
+	status, body = renderError(t, errors.New("pq: connection refused"), "en")
+	if status != fiber.StatusInternalServerError || body.Error.Code != "INTERNAL_ERROR" || body.Error.Message != "internal server error" {
+		t.Errorf("internal errors must not leak their cause: status %d, body %+v", status, body)
+	}
+
+	status, body = renderError(t, fiber.ErrMethodNotAllowed, "en")
+	if status != fiber.StatusMethodNotAllowed || body.Error.Code != "METHOD_NOT_ALLOWED" || body.Success {
+		t.Errorf("fiber error: status %d, body %+v", status, body)
+	}
+}
+
+func TestErrorHandlerValidationDetails(t *testing.T) {
+	input := struct {
+		Name  string `json:"name" validate:"required"`
+		Email string `json:"email" validate:"required,email"`
+	}{Email: "not-an-email"}
+
+	status, body := renderError(t, ValidateStruct(input), "en")
+	if status != fiber.StatusBadRequest || body.Error.Code != "VALIDATION_FAILED" {
+		t.Fatalf("status %d, body %+v", status, body)
+	}
+
+	var fields []FieldError
+	if err := json.Unmarshal(body.Error.Details, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 2 {
+		t.Fatalf("expected 2 field errors, got %+v", fields)
+	}
+	if fields[0].Field != "name" || fields[0].Rule != "required" || fields[0].Message != "name is required" {
+		t.Errorf("unexpected field error %+v", fields[0])
+	}
+	if fields[1].Field != "email" || fields[1].Rule != "email" {
+		t.Errorf("unexpected field error %+v", fields[1])
+	}
+}
+
+func TestAppErrorIs(t *testing.T) {
+	wrapped := ErrNotFound.Wrap(errors.New("record not found"))
+	if !errors.Is(wrapped, ErrNotFound) {
+		t.Error("wrapped error should match its catalog entry")
+	}
+	if errors.Is(wrapped, ErrBadRequest) {
+		t.Error("different codes must not match")
+	}
+}
+
+func TestAsAppErrorHidesServerErrors(t *testing.T) {
+	cause := errors.New("pq: duplicate key value violates unique constraint")
+	appErr := AsAppError(cause, fiber.StatusInternalServerError)
+	if appErr.Code != "INTERNAL_ERROR" || appErr.Message != "internal server error" {
+		t.Errorf("5xx error should use the generic internal error, got %+v", appErr)
+	}
+	if !errors.Is(appErr, cause) {
+		t.Error("original error should be kept for logging")
+	}
+
+	appErr = AsAppError(errors.New("slug is required"), fiber.StatusBadRequest)
+	if appErr.Code != "BAD_REQUEST" || appErr.Message != "slug is required" {
+		t.Errorf("4xx error should keep its message, got %+v", appErr)
+	}
+}
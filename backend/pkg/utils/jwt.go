@@ -2,80 +2,124 @@ package utils
 
 import (
 	"errors"
-	"os"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+// AccessTokenTTL อายุของ access token
+const AccessTokenTTL = 15 * time.Minute
 
-func getSecret() []byte {
-	if len(jwtSecret) == 0 {
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			// Fallback for development if not set, but should be set in .env
-			return []byte("secret")
+type Claims struct {
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+	SessionID uint   `json:"sid,omitempty"`
+	// Purpose ว่างสำหรับ access token ปกติ - token ที่มี purpose (เช่น 2FA challenge)
+	// ใช้เรียก API ทั่วไปไม่ได้
+	Purpose     string `json:"purpose,omitempty"`
+	LoginMethod string `json:"amr,omitempty"`
+	// สิทธิ์ ณ เวลาที่ออก token - middleware ใช้ตรวจสิทธิ์โดยไม่ต้อง query DB
+	// TokenVersion ต้องตรงกับ users.token_version ตอน refresh (ดู services.BumpTokenVersion)
+	RoleID                 *uint    `json:"role_id,omitempty"`
+	RoleIDs                []uint   `json:"roles,omitempty"` // role หลัก + role เพิ่มเติมที่มีผลตอนออก token
+	Permissions            []string `json:"perms,omitempty"`
+	TokenVersion           int      `json:"ver"`
+	TwoFactorSetupRequired bool     `json:"tfa_setup,omitempty"` // role บังคับ 2FA แต่ user ยังไม่เปิด
+	EmailUnverified        bool     `json:"email_unverified,omitempty"`
+	PasswordChangeRequired bool     `json:"pwd_change,omitempty"` // รหัสผ่านหมดอายุ/ถูกบังคับเปลี่ยน - ใช้ได้เฉพาะ endpoint เปลี่ยนรหัสผ่าน
+	// ImpersonatorID admin ที่กำลัง "ดูในมุมมองของ user" - UserID คือ user ที่ถูก impersonate
+	// SessionID เป็น session ของ admin (revoke session ของ admin = ปิด impersonation ด้วย)
+	ImpersonatorID uint `json:"imp,omitempty"`
+	// Locale ภาษาที่ user ตั้งไว้ (ว่าง = ใช้ Accept-Language)
+	Locale string `json:"locale,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// HasPermission ตรวจสอบว่า token มี permission slug ที่ระบุหรือไม่
+func (c *Claims) HasPermission(slug string) bool {
+	for _, p := range c.Permissions {
+		if p == slug {
+			return true
 		}
-		jwtSecret = []byte(secret)
 	}
-	return jwtSecret
+	return false
 }
 
-type Claims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
-	jwt.RegisteredClaims
+// GenerateAccessToken สร้าง access token อายุสั้น (15 นาที) จาก claims ที่ caller เตรียมไว้
+// refresh token เป็น opaque token ที่เก็บแบบ hash ในฐานข้อมูล (ดู services.IssueTokens)
+func GenerateAccessToken(claims Claims) (string, error) {
+	claims.Purpose = ""
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	}
+	return signClaims(claims)
 }
 
-func GenerateTokens(userID uint, username string) (string, string, error) {
-	// Access Token (15 minutes)
-	accessClaims := Claims{
-		UserID:   userID,
-		Username: username,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+// GenerateImpersonationToken สร้าง access token ของ user ปลายทางที่ admin ใช้ดูระบบในมุมมองของ user
+// ไม่มี refresh token คู่กัน - หมดอายุแล้วต้องเริ่ม impersonate ใหม่
+func GenerateImpersonationToken(claims Claims, impersonatorID uint, ttl time.Duration) (string, error) {
+	claims.Purpose = ""
+	claims.ImpersonatorID = impersonatorID
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
 	}
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	signedAccessToken, err := accessToken.SignedString(getSecret())
+	return signClaims(claims)
+}
+
+// IsImpersonation token นี้ออกให้ admin ที่กำลัง impersonate user หรือไม่
+func (c *Claims) IsImpersonation() bool {
+	return c.ImpersonatorID != 0
+}
+
+func ParseToken(tokenStr string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, verificationKey)
+
 	if err != nil {
-		return "", "", err
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid && claims.Purpose == "" {
+		return claims, nil
 	}
 
-	// Refresh Token (7 days)
-	refreshClaims := Claims{
-		UserID:   userID,
-		Username: username,
+	return nil, errors.New("invalid token")
+}
+
+// Challenge token purposes
+const (
+	PurposeTwoFactor = "2fa"
+)
+
+// GenerateChallengeToken สร้าง token อายุสั้นสำหรับขั้นตอนระหว่าง login (เช่น รอกรอก 2FA code)
+// token นี้ใช้แทน access token ไม่ได้ (ParseToken จะปฏิเสธ)
+// jti (RegisteredClaims.ID) ใช้บันทึกว่า token ถูกใช้ไปแล้ว (ดู services.ConsumeTwoFactorChallenge)
+func GenerateChallengeToken(userID uint, username, purpose, loginMethod string, ttl time.Duration) (string, error) {
+	claims := Claims{
+		UserID:      userID,
+		Username:    username,
+		Purpose:     purpose,
+		LoginMethod: loginMethod,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)),
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	signedRefreshToken, err := refreshToken.SignedString(getSecret())
-	if err != nil {
-		return "", "", err
-	}
-
-	return signedAccessToken, signedRefreshToken, nil
+	return signClaims(claims)
 }
 
-func ParseToken(tokenStr string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return getSecret(), nil
-	})
-
+// ParseChallengeToken ตรวจสอบ challenge token และ purpose ที่คาดหวัง
+func ParseChallengeToken(tokenStr, purpose string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, verificationKey)
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid && claims.Purpose == purpose {
 		return claims, nil
 	}
 
@@ -99,3 +143,28 @@ func GetUserIDFromContext(c *fiber.Ctx) (uint, error) {
 		return 0, errors.New("invalid user id format")
 	}
 }
+
+// GetSessionIDFromContext คืน session ID ของ access token ปัจจุบัน (ตั้งค่าโดย middleware.Protected)
+func GetSessionIDFromContext(c *fiber.Ctx) uint {
+	if sid, ok := c.Locals("sessionID").(uint); ok {
+		return sid
+	}
+	return 0
+}
+
+// GetImpersonatorIDFromContext คืน ID ของ admin ที่กำลัง impersonate (0 = ไม่ได้ impersonate)
+func GetImpersonatorIDFromContext(c *fiber.Ctx) uint {
+	if id, ok := c.Locals("impersonatorID").(uint); ok {
+		return id
+	}
+	return 0
+}
+
+// GetClaimsFromContext คืน claims ของ access token ปัจจุบัน (ตั้งค่าโดย middleware.Protected)
+func GetClaimsFromContext(c *fiber.Ctx) (*Claims, error) {
+	claims, ok := c.Locals("claims").(*Claims)
+	if !ok || claims == nil {
+		return nil, errors.New("claims not found in context")
+	}
+	return claims, nil
+}
@@ -0,0 +1,271 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ============================================================
+// 🔑 JWT KEYRING - RS256 / EdDSA signing keys จาก JWT_KEY_DIR
+// ============================================================
+//
+// แต่ละไฟล์ *.pem ใน JWT_KEY_DIR คือ 1 key โดยใช้ชื่อไฟล์ (ไม่รวม .pem) เป็น kid
+//   - PRIVATE KEY (PKCS#8) / RSA PRIVATE KEY (PKCS#1) ใช้ sign และ verify
+//   - PUBLIC KEY ใช้ verify อย่างเดียว (key ที่ปลดระวางแล้วแต่ยังมี token ค้างอยู่)
+//
+// key ที่ใช้ sign คือ JWT_ACTIVE_KID หรือ private key ที่ชื่อเรียงท้ายสุด
+// (แนะนำตั้งชื่อตามวันที่ เช่น 2026-01.pem) - การ rotate ทำโดยเพิ่มไฟล์ใหม่แล้ว reload
+// token เก่ายัง verify ได้ตราบที่ key เดิมยังอยู่ใน directory
+
+type jwtKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer // nil = verify-only
+	public  crypto.PublicKey
+}
+
+type jwtKeyring struct {
+	keys   map[string]*jwtKey
+	active *jwtKey
+}
+
+var (
+	keyringMu sync.RWMutex
+	keyring   *jwtKeyring
+)
+
+var ErrNoSigningKey = errors.New("no JWT signing key configured")
+
+// EphemeralKeyID kid ของ key ชั่วคราวที่สร้างใน development เมื่อไม่มี JWT_KEY_DIR
+const EphemeralKeyID = "dev-ephemeral"
+
+// InitJWTKeys โหลด key จาก JWT_KEY_DIR
+// allowEphemeral = true (development) จะสร้าง Ed25519 key ชั่วคราวเมื่อไม่มี key ให้ใช้
+func InitJWTKeys(allowEphemeral bool) error {
+	dir := os.Getenv("JWT_KEY_DIR")
+	if dir == "" {
+		if !allowEphemeral {
+			return fmt.Errorf("%w: JWT_KEY_DIR is not set", ErrNoSigningKey)
+		}
+		return useEphemeralKey()
+	}
+
+	ring, err := loadKeyring(dir, os.Getenv("JWT_ACTIVE_KID"))
+	if err != nil {
+		if errors.Is(err, ErrNoSigningKey) && allowEphemeral {
+			return useEphemeralKey()
+		}
+		return err
+	}
+
+	setKeyring(ring)
+	return nil
+}
+
+// ReloadJWTKeys อ่าน JWT_KEY_DIR ใหม่ (ใช้ตอน rotate key) - ถ้าโหลดไม่สำเร็จจะใช้ keyring เดิมต่อ
+func ReloadJWTKeys() error {
+	dir := os.Getenv("JWT_KEY_DIR")
+	if dir == "" {
+		return fmt.Errorf("%w: JWT_KEY_DIR is not set", ErrNoSigningKey)
+	}
+	ring, err := loadKeyring(dir, os.Getenv("JWT_ACTIVE_KID"))
+	if err != nil {
+		return err
+	}
+	setKeyring(ring)
+	return nil
+}
+
+// ActiveKeyID คืน kid ของ key ที่ใช้ sign อยู่
+func ActiveKeyID() string {
+	ring := currentKeyring()
+	if ring == nil || ring.active == nil {
+		return ""
+	}
+	return ring.active.kid
+}
+
+func setKeyring(ring *jwtKeyring) {
+	keyringMu.Lock()
+	keyring = ring
+	keyringMu.Unlock()
+}
+
+func currentKeyring() *jwtKeyring {
+	keyringMu.RLock()
+	defer keyringMu.RUnlock()
+	return keyring
+}
+
+func useEphemeralKey() error {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	key := &jwtKey{kid: EphemeralKeyID, method: jwt.SigningMethodEdDSA, private: priv, public: priv.Public()}
+	setKeyring(&jwtKeyring{keys: map[string]*jwtKey{key.kid: key}, active: key})
+	return nil
+}
+
+func loadKeyring(dir, activeKID string) (*jwtKeyring, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	ring := &jwtKeyring{keys: make(map[string]*jwtKey)}
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		key, err := parseJWTKey(kid, data)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %s: %w", file, err)
+		}
+		ring.keys[kid] = key
+		if key.private != nil && activeKID == "" {
+			ring.active = key // files เรียงตามชื่อ - ตัวสุดท้ายชนะ
+		}
+	}
+
+	if activeKID != "" {
+		key, ok := ring.keys[activeKID]
+		if !ok || key.private == nil {
+			return nil, fmt.Errorf("%w: JWT_ACTIVE_KID %q has no private key in %s", ErrNoSigningKey, activeKID, dir)
+		}
+		ring.active = key
+	}
+	if ring.active == nil {
+		return nil, fmt.Errorf("%w: no private key found in %s", ErrNoSigningKey, dir)
+	}
+	return ring, nil
+}
+
+func parseJWTKey(kid string, data []byte) (*jwtKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid PEM data")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &jwtKey{kid: kid, method: jwt.SigningMethodRS256, private: k, public: &k.PublicKey}, nil
+	case ed25519.PrivateKey:
+		return &jwtKey{kid: kid, method: jwt.SigningMethodEdDSA, private: k, public: k.Public()}, nil
+	case *rsa.PublicKey:
+		return &jwtKey{kid: kid, method: jwt.SigningMethodRS256, public: k}, nil
+	case ed25519.PublicKey:
+		return &jwtKey{kid: kid, method: jwt.SigningMethodEdDSA, public: k}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T (use RSA or Ed25519)", parsed)
+	}
+}
+
+// signClaims sign token ด้วย active key พร้อมใส่ kid ใน header
+func signClaims(claims jwt.Claims) (string, error) {
+	ring := currentKeyring()
+	if ring == nil || ring.active == nil {
+		return "", ErrNoSigningKey
+	}
+	token := jwt.NewWithClaims(ring.active.method, claims)
+	token.Header["kid"] = ring.active.kid
+	return token.SignedString(ring.active.private)
+}
+
+// verificationKey คือ jwt.Keyfunc - เลือก public key ตาม kid และตรวจว่า alg ตรงกับชนิดของ key
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	ring := currentKeyring()
+	if ring == nil {
+		return nil, ErrNoSigningKey
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ring.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.public, nil
+}
+
+// JWK public key ในรูปแบบ RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS public keys ทั้งหมด (รวม verify-only) สำหรับ /.well-known/jwks.json
+func JWKS() []JWK {
+	ring := currentKeyring()
+	if ring == nil {
+		return []JWK{}
+	}
+
+	kids := make([]string, 0, len(ring.keys))
+	for kid := range ring.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	b64 := base64.RawURLEncoding
+	result := make([]JWK, 0, len(kids))
+	for _, kid := range kids {
+		key := ring.keys[kid]
+		jwk := JWK{Kid: kid, Use: "sig", Alg: key.method.Alg()}
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = b64.EncodeToString(pub.N.Bytes())
+			jwk.E = b64.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = b64.EncodeToString(pub)
+		default:
+			continue
+		}
+		result = append(result, jwk)
+	}
+	return result
+}
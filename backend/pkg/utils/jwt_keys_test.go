@@ -0,0 +1,189 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeKey(t *testing.T, dir, kid, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeRSAKey(t *testing.T, dir, kid string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeKey(t, dir, kid, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+}
+
+func writeEd25519Key(t *testing.T, dir, kid string) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeKey(t, dir, kid, "PRIVATE KEY", der)
+	return key
+}
+
+func TestInitJWTKeysRequiresKeysOutsideDevelopment(t *testing.T) {
+	t.Setenv("JWT_KEY_DIR", "")
+	if err := InitJWTKeys(false); err == nil {
+		t.Fatal("expected error without JWT_KEY_DIR in production")
+	}
+
+	t.Setenv("JWT_KEY_DIR", t.TempDir())
+	if err := InitJWTKeys(false); err == nil {
+		t.Fatal("expected error with empty key directory in production")
+	}
+
+	if err := InitJWTKeys(true); err != nil {
+		t.Fatalf("development should fall back to ephemeral key: %v", err)
+	}
+	if ActiveKeyID() != EphemeralKeyID {
+		t.Fatalf("ActiveKeyID() = %q, want %q", ActiveKeyID(), EphemeralKeyID)
+	}
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("JWT_KEY_DIR", dir)
+	t.Setenv("JWT_ACTIVE_KID", "")
+
+	writeRSAKey(t, dir, "2026-01")
+	if err := InitJWTKeys(false); err != nil {
+		t.Fatal(err)
+	}
+	oldToken, err := GenerateAccessToken(Claims{UserID: 1, Username: "alice", SessionID: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// เพิ่ม key ใหม่ - key ที่ชื่อเรียงท้ายสุดกลายเป็น active
+	writeEd25519Key(t, dir, "2026-02")
+	if err := ReloadJWTKeys(); err != nil {
+		t.Fatal(err)
+	}
+	if ActiveKeyID() != "2026-02" {
+		t.Fatalf("ActiveKeyID() = %q, want 2026-02", ActiveKeyID())
+	}
+
+	newToken, err := GenerateAccessToken(Claims{UserID: 1, Username: "alice", SessionID: 11})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// token ที่ออกก่อน rotate ยังใช้ได้
+	for _, tok := range []string{oldToken, newToken} {
+		if _, err := ParseToken(tok); err != nil {
+			t.Fatalf("ParseToken() error = %v", err)
+		}
+	}
+
+	// ลบ key เก่าออก - token เดิมใช้ไม่ได้แล้ว
+	if err := os.Remove(filepath.Join(dir, "2026-01.pem")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReloadJWTKeys(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseToken(oldToken); err == nil {
+		t.Fatal("token signed by removed key should be rejected")
+	}
+}
+
+func TestJWTVerifyOnlyPublicKey(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("JWT_KEY_DIR", dir)
+	t.Setenv("JWT_ACTIVE_KID", "")
+
+	retired := writeEd25519Key(t, dir, "a-retired")
+	writeEd25519Key(t, dir, "b-current")
+	if err := InitJWTKeys(false); err != nil {
+		t.Fatal(err)
+	}
+
+	// เปลี่ยนไฟล์ของ key เก่าเป็น public key อย่างเดียว
+	der, err := x509.MarshalPKIXPublicKey(retired.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeKey(t, dir, "a-retired", "PUBLIC KEY", der)
+
+	t.Setenv("JWT_ACTIVE_KID", "a-retired")
+	if err := ReloadJWTKeys(); err == nil {
+		t.Fatal("verify-only key must not be accepted as JWT_ACTIVE_KID")
+	}
+
+	t.Setenv("JWT_ACTIVE_KID", "")
+	if err := ReloadJWTKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := JWKS()
+	if len(keys) != 2 {
+		t.Fatalf("len(JWKS()) = %d, want 2", len(keys))
+	}
+	for _, k := range keys {
+		if k.Kty != "OKP" || k.Crv != "Ed25519" || k.Alg != "EdDSA" || k.X == "" {
+			t.Errorf("unexpected JWK %+v", k)
+		}
+	}
+}
+
+func TestChallengeTokenIsNotAccessToken(t *testing.T) {
+	t.Setenv("JWT_KEY_DIR", "")
+	if err := InitJWTKeys(true); err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err := GenerateChallengeToken(1, "alice", PurposeTwoFactor, "password", AccessTokenTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseToken(tok); err == nil {
+		t.Fatal("challenge token must not be accepted as access token")
+	}
+	if _, err := ParseChallengeToken(tok, PurposeTwoFactor); err != nil {
+		t.Fatalf("ParseChallengeToken() error = %v", err)
+	}
+}
+
+func TestImpersonationTokenCarriesBothUsers(t *testing.T) {
+	t.Setenv("JWT_KEY_DIR", "")
+	if err := InitJWTKeys(true); err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err := GenerateImpersonationToken(Claims{UserID: 7, Username: "bob", SessionID: 3}, 1, 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ParseToken(tok)
+	if err != nil {
+		t.Fatalf("ParseToken() error = %v", err)
+	}
+	if claims.UserID != 7 || claims.ImpersonatorID != 1 || !claims.IsImpersonation() {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if ttl := time.Until(claims.ExpiresAt.Time); ttl > 5*time.Minute {
+		t.Errorf("impersonation token lives %v, want <= 5m", ttl)
+	}
+}
@@ -0,0 +1,29 @@
+package utils
+
+// Success message codes - SendSuccess / SendCreated แปลเป็นข้อความใน i18n catalog ตามภาษาของ request
+// ข้อความที่ไม่ใช่ code ใน catalog ถูกส่งกลับไปตามเดิม
+const (
+	MsgLoggedOut              = "LOGGED_OUT"
+	MsgPasswordChanged        = "PASSWORD_CHANGED"
+	MsgPasswordReset          = "PASSWORD_RESET"
+	MsgPasswordResetLogin     = "PASSWORD_RESET_LOGIN"
+	MsgPasswordResetRequested = "PASSWORD_RESET_REQUESTED"
+	MsgProfileRetrieved       = "PROFILE_RETRIEVED"
+	MsgProfileUpdated         = "PROFILE_UPDATED"
+	MsgMenusRetrieved         = "MENUS_RETRIEVED"
+	MsgSessionRevoked         = "SESSION_REVOKED_OK"
+	MsgPINSet                 = "PIN_SET"
+	MsgPINDisabled            = "PIN_DISABLED"
+	MsgTwoFactorDisabled      = "TWO_FACTOR_DISABLED"
+	MsgUserDeleted            = "USER_DELETED"
+	MsgRoleDeleted            = "ROLE_DELETED"
+	MsgMenuDeleted            = "MENU_DELETED"
+	MsgProjectCreated         = "PROJECT_CREATED"
+	MsgProjectUpdated         = "PROJECT_UPDATED"
+	MsgProjectDeleted         = "PROJECT_DELETED"
+	MsgProjectMoved           = "PROJECT_MOVED"
+	MsgCategoryCreated        = "CATEGORY_CREATED"
+	MsgCategoryUpdated        = "CATEGORY_UPDATED"
+	MsgCategoryDeleted        = "CATEGORY_DELETED"
+	MsgContactSubmitted       = "CONTACT_SUBMITTED"
+)
@@ -2,9 +2,11 @@ package utils
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
-	"strings"
+	"fmt"
 	"unicode"
+	"unicode/utf8"
 )
 
 // PasswordStrengthResult ผลลัพธ์การตรวจสอบความปลอดภัยรหัสผ่าน
@@ -14,76 +16,83 @@ type PasswordStrengthResult struct {
 	Messages []string `json:"messages"` // ข้อความแสดง requirements ที่ไม่ผ่าน
 }
 
-// ValidatePasswordStrength ตรวจสอบความปลอดภัยรหัสผ่าน
-// Requirements:
-// - ความยาวขั้นต่ำ 8 ตัวอักษร
-// - ต้องมีตัวพิมพ์เล็กอย่างน้อย 1 ตัว
-// - ต้องมีตัวพิมพ์ใหญ่อย่างน้อย 1 ตัว
-// - ต้องมีตัวเลขอย่างน้อย 1 ตัว
-// - ต้องมีอักขระพิเศษอย่างน้อย 1 ตัว
+// PasswordPolicy กฎของรหัสผ่าน (ค่าจริงอ่านจากตาราง settings - ดู services.CurrentPasswordPolicy)
+type PasswordPolicy struct {
+	MinLength      int
+	RequireLower   bool
+	RequireUpper   bool
+	RequireDigit   bool
+	RequireSpecial bool
+	MaxAgeDays     int  // 0 = ไม่หมดอายุ
+	HistoryDepth   int  // ห้ามใช้รหัสผ่านซ้ำกับ N ครั้งล่าสุด (0 = ไม่ตรวจ)
+	CheckBreached  bool // ตรวจกับรายการรหัสผ่านที่รั่วไหล (ต้องตั้งค่า PASSWORD_BREACH_DIR)
+}
+
+// DefaultPasswordPolicy กฎเริ่มต้น (เท่ากับกฎเดิมก่อนมี settings)
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:      8,
+		RequireLower:   true,
+		RequireUpper:   true,
+		RequireDigit:   true,
+		RequireSpecial: true,
+		CheckBreached:  true,
+	}
+}
+
+// ValidatePasswordStrength ตรวจสอบความปลอดภัยรหัสผ่านด้วยกฎเริ่มต้น
 func ValidatePasswordStrength(password string) PasswordStrengthResult {
+	return ValidatePasswordPolicy(password, DefaultPasswordPolicy())
+}
+
+// ValidatePasswordPolicy ตรวจสอบรหัสผ่านตามกฎที่กำหนด (ความยาว + ชนิดตัวอักษร)
+// ความยาวนับเป็นตัวอักษร (rune) เพื่อรองรับภาษาไทย
+// อักขระพิเศษ = เครื่องหมายหรือสัญลักษณ์ใด ๆ (ไม่จำกัดเฉพาะ ASCII)
+// การตรวจ history / breached list ทำใน services.ValidateNewPassword
+func ValidatePasswordPolicy(password string, policy PasswordPolicy) PasswordStrengthResult {
 	var messages []string
 	score := 0
 
-	// Check minimum length (8 characters)
-	if len(password) < 8 {
-		messages = append(messages, "Password must be at least 8 characters long")
-	} else {
-		score++
-		if len(password) >= 12 {
-			score++ // Bonus for longer password
-		}
-	}
-
-	// Check for lowercase
-	hasLower := false
+	var hasLower, hasUpper, hasDigit, hasSpecial bool
 	for _, c := range password {
-		if unicode.IsLower(c) {
+		switch {
+		case unicode.IsLower(c):
 			hasLower = true
-			break
-		}
-	}
-	if !hasLower {
-		messages = append(messages, "Password must contain at least one lowercase letter")
-	} else {
-		score++
-	}
-
-	// Check for uppercase
-	hasUpper := false
-	for _, c := range password {
-		if unicode.IsUpper(c) {
+		case unicode.IsUpper(c):
 			hasUpper = true
-			break
-		}
-	}
-	if !hasUpper {
-		messages = append(messages, "Password must contain at least one uppercase letter")
-	} else {
-		score++
-	}
-
-	// Check for digit
-	hasDigit := false
-	for _, c := range password {
-		if unicode.IsDigit(c) {
+		case unicode.IsDigit(c):
 			hasDigit = true
-			break
+		case unicode.IsPunct(c) || unicode.IsSymbol(c):
+			hasSpecial = true
 		}
 	}
-	if !hasDigit {
-		messages = append(messages, "Password must contain at least one digit")
+
+	length := utf8.RuneCountInString(password)
+	if length < policy.MinLength {
+		messages = append(messages, fmt.Sprintf("Password must be at least %d characters long", policy.MinLength))
 	} else {
 		score++
+		if length >= 12 {
+			score++ // Bonus for longer password
+		}
 	}
 
-	// Check for special character
-	specialChars := "!@#$%^&*()_+-=[]{}|;':\",./<>?"
-	hasSpecial := strings.ContainsAny(password, specialChars)
-	if !hasSpecial {
-		messages = append(messages, "Password must contain at least one special character (!@#$%^&*...)")
-	} else {
-		score++
+	checks := []struct {
+		required bool
+		ok       bool
+		message  string
+	}{
+		{policy.RequireLower, hasLower, "Password must contain at least one lowercase letter"},
+		{policy.RequireUpper, hasUpper, "Password must contain at least one uppercase letter"},
+		{policy.RequireDigit, hasDigit, "Password must contain at least one digit"},
+		{policy.RequireSpecial, hasSpecial, "Password must contain at least one special character (!@#$%^&*...)"},
+	}
+	for _, check := range checks {
+		if check.ok {
+			score++
+		} else if check.required {
+			messages = append(messages, check.message)
+		}
 	}
 
 	// Cap score at 5
@@ -122,3 +131,10 @@ func GenerateResetToken() (string, error) {
 	}
 	return hex.EncodeToString(bytes), nil
 }
+
+// HashToken คืนค่า SHA-256 (hex) ของ token สำหรับเก็บลงฐานข้อมูล
+// ใช้กับ token ที่สุ่มมาแล้ว (entropy สูง) จึงไม่จำเป็นต้องใช้ bcrypt
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
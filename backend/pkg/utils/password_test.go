@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidatePasswordPolicy(t *testing.T) {
+	policy := DefaultPasswordPolicy()
+
+	if res := ValidatePasswordPolicy("Str0ng!Pass", policy); !res.IsValid {
+		t.Fatalf("expected valid password, got %v", res.Messages)
+	}
+	if res := ValidatePasswordPolicy("weak", policy); res.IsValid || len(res.Messages) != 4 {
+		t.Fatalf("expected 4 violations, got %v", res.Messages)
+	}
+
+	// อักขระพิเศษนอก ASCII และความยาวนับเป็นตัวอักษร
+	if res := ValidatePasswordPolicy("Passw0rd€", policy); !res.IsValid {
+		t.Fatalf("expected non-ASCII symbol to count as special, got %v", res.Messages)
+	}
+	thai := PasswordPolicy{MinLength: 6}
+	if res := ValidatePasswordPolicy("รหัสผ่าน", thai); !res.IsValid {
+		t.Fatalf("expected Thai password to satisfy rune length, got %v", res.Messages)
+	}
+
+	relaxed := PasswordPolicy{MinLength: 12}
+	if res := ValidatePasswordPolicy("alllowercaseletters", relaxed); !res.IsValid {
+		t.Fatalf("expected relaxed policy to accept, got %v", res.Messages)
+	}
+	if res := ValidatePasswordPolicy("Sh0rt!", relaxed); res.IsValid {
+		t.Fatal("expected min length violation")
+	}
+}
+
+func TestIsPasswordBreached(t *testing.T) {
+	dir := t.TempDir()
+	// SHA-1("password") = 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	content := "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:3730471\r\n"
+	if err := os.WriteFile(filepath.Join(dir, "5BAA6.txt"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	breached, err := IsPasswordBreached(dir, "password")
+	if err != nil || !breached {
+		t.Fatalf("expected breached, got %v (%v)", breached, err)
+	}
+
+	breached, err = IsPasswordBreached(dir, "Str0ng!Pass-not-listed")
+	if err != nil || breached {
+		t.Fatalf("expected not breached, got %v (%v)", breached, err)
+	}
+
+	if breached, err := IsPasswordBreached("", "password"); err != nil || breached {
+		t.Fatal("expected empty dir to disable the check")
+	}
+}
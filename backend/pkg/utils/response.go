@@ -1,8 +1,11 @@
 package utils
 
 import (
-	"net/http" // Added for http.StatusText
+	"backend/pkg/i18n"
+	"errors"
+	"log"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -24,9 +27,9 @@ type SuccessResponse struct {
 }
 
 type ErrorDetail struct {
-	Code    string `json:"code,omitempty"`
-	Message string `json:"message,omitempty"`
-	Details string `json:"details,omitempty"`
+	Code    string      `json:"code,omitempty"`
+	Message string      `json:"message,omitempty"`
+	Details interface{} `json:"details,omitempty"` // string หรือ []FieldError (validation)
 }
 
 type ErrorResponse struct {
@@ -34,11 +37,33 @@ type ErrorResponse struct {
 	Error   ErrorDetail `json:"error"`
 }
 
+// GetLocale คืนภาษาของ response: ภาษาที่ user ตั้งไว้ (ตั้งโดย middleware.Protected) -> Accept-Language -> ภาษาเริ่มต้น
+func GetLocale(c *fiber.Ctx) string {
+	if locale, ok := c.Locals("locale").(string); ok && locale != "" {
+		return locale
+	}
+	if locale := i18n.Match(c.Get(fiber.HeaderAcceptLanguage)); locale != "" {
+		return locale
+	}
+	return i18n.Default()
+}
+
+// Translate แปล message code (เช่น MsgProjectMoved) ตามภาษาของ request
+// ข้อความที่ไม่ใช่ code ใน catalog คืนค่าเดิม
+func Translate(c *fiber.Ctx, code string) string {
+	locale := GetLocale(c)
+	c.Set(fiber.HeaderContentLanguage, locale)
+	if message, ok := i18n.Message(locale, code); ok {
+		return message
+	}
+	return code
+}
+
 func SendSuccess(c *fiber.Ctx, data interface{}, message string) error {
 	return c.Status(fiber.StatusOK).JSON(SuccessResponse{
 		Success: true,
 		Data:    data,
-		Message: message,
+		Message: Translate(c, message),
 	})
 }
 
@@ -55,7 +80,7 @@ func SendSuccessWithPagination(c *fiber.Ctx, data interface{}, pagination *Pagin
 		Data:       data,
 		Pagination: pagination,
 		Filters:    filters,
-		Message:    message,
+		Message:    Translate(c, message),
 	})
 }
 
@@ -63,28 +88,75 @@ func SendCreated(c *fiber.Ctx, data interface{}, message string) error {
 	return c.Status(fiber.StatusCreated).JSON(SuccessResponse{
 		Success: true,
 		Data:    data,
-		Message: message,
+		Message: Translate(c, message),
 	})
 }
 
+// SendError ส่ง error ใน envelope มาตรฐาน - error ที่ไม่ใช่ AppError ใช้ status ที่ให้มา
+// (ดู AsAppError สำหรับการเลือก code)
 func SendError(c *fiber.Ctx, status int, err error) error {
-	return c.Status(status).JSON(ErrorResponse{
-		Success: false,
-		Error: ErrorDetail{
-			Code:    http.StatusText(status), // Changed from utils.StatusMessage
-			Message: err.Error(),
-		},
-	})
+	return SendAppError(c, AsAppError(err, status))
 }
 
 // SendDetailedError allows sending clearer error codes and details
+// message ถูกแทนด้วยข้อความของ code ใน catalog ตามภาษาของ request (ถ้ามี)
 func SendDetailedError(c *fiber.Ctx, status int, code, message, details string) error {
+	appErr := NewAppError(status, code, message)
+	if details != "" {
+		appErr.Details = details
+	}
+	return SendAppError(c, appErr)
+}
+
+// SendAppError ส่ง AppError ใน envelope มาตรฐาน พร้อมแปลข้อความตามภาษาของ request
+// error 5xx ถูก log พร้อมสาเหตุ (Err) ฝั่ง server - client เห็นแค่ code และข้อความ
+func SendAppError(c *fiber.Ctx, err *AppError) error {
+	locale := GetLocale(c)
+	c.Set(fiber.HeaderContentLanguage, locale)
+
+	message := err.Message
+	if !err.custom {
+		if translated, ok := i18n.Message(locale, err.Code); ok {
+			message = translated
+		}
+	}
+
+	details := err.Details
+	if fields, ok := details.([]FieldError); ok {
+		details = localizeFieldErrors(locale, fields)
+	}
+
+	status := err.Status
+	if status == 0 {
+		status = fiber.StatusInternalServerError
+	}
+	if status >= fiber.StatusInternalServerError {
+		log.Printf("[Error] %s %s: %v", c.Method(), c.Path(), err)
+	}
 	return c.Status(status).JSON(ErrorResponse{
 		Success: false,
 		Error: ErrorDetail{
-			Code:    code,
+			Code:    err.Code,
 			Message: message,
 			Details: details,
 		},
 	})
 }
+
+// ErrorHandler ใช้เป็น fiber.Config.ErrorHandler - error ทุกชนิดที่ handler/middleware คืนมา
+// (รวม panic ที่ recover จับได้) ถูกส่งใน envelope เดียวกัน
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	var appErr *AppError
+	var fiberErr *fiber.Error
+	var validationErrs validator.ValidationErrors
+	switch {
+	case errors.As(err, &appErr):
+	case errors.As(err, &validationErrs):
+		appErr = AsAppError(err, fiber.StatusBadRequest)
+	case errors.As(err, &fiberErr):
+		appErr = &AppError{Status: fiberErr.Code, Code: CodeForStatus(fiberErr.Code), Message: fiberErr.Message, custom: true}
+	default:
+		appErr = ErrInternalServer.Wrap(err)
+	}
+	return SendAppError(c, appErr)
+}
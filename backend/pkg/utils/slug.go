@@ -0,0 +1,322 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// MaxSlugLength ความยาวสูงสุดของ slug (ตัดที่ขีดกลางถ้าทำได้)
+const MaxSlugLength = 80
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// IsValidSlug ตรวจว่า slug ประกอบด้วย a-z, 0-9 และขีดกลางคั่นเท่านั้น
+func IsValidSlug(slug string) bool {
+	return len(slug) <= MaxSlugLength && slugPattern.MatchString(slug)
+}
+
+// Slugify สร้าง slug สำหรับ URL จากข้อความ
+// ภาษาไทยถอดเป็นอักษรโรมัน (อิงระบบราชบัณฑิตฯ แบบย่อ) ตัวอักษรอื่นที่ไม่ใช่ a-z / 0-9 ถูกตัดทิ้ง
+// คืนค่าว่างถ้าไม่เหลืออะไรเลย - caller ต้องมี fallback เอง
+func Slugify(s string) string {
+	s = transliterateThai(strings.ToLower(s))
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case unicode.IsSpace(r), r == '-', r == '_', r == '/', r == '.':
+			b.WriteByte('-')
+		}
+	}
+
+	slug := strings.Join(strings.FieldsFunc(b.String(), func(r rune) bool { return r == '-' }), "-")
+	if len(slug) > MaxSlugLength {
+		slug = slug[:MaxSlugLength]
+		if i := strings.LastIndexByte(slug, '-'); i > MaxSlugLength/2 {
+			slug = slug[:i]
+		}
+		slug = strings.Trim(slug, "-")
+	}
+	return slug
+}
+
+// เสียงพยัญชนะต้น
+var thaiInitials = map[rune]string{
+	'ก': "k", 'ข': "kh", 'ฃ': "kh", 'ค': "kh", 'ฅ': "kh", 'ฆ': "kh", 'ง': "ng",
+	'จ': "ch", 'ฉ': "ch", 'ช': "ch", 'ซ': "s", 'ฌ': "ch", 'ญ': "y",
+	'ฎ': "d", 'ฏ': "t", 'ฐ': "th", 'ฑ': "th", 'ฒ': "th", 'ณ': "n",
+	'ด': "d", 'ต': "t", 'ถ': "th", 'ท': "th", 'ธ': "th", 'น': "n",
+	'บ': "b", 'ป': "p", 'ผ': "ph", 'ฝ': "f", 'พ': "ph", 'ฟ': "f", 'ภ': "ph", 'ม': "m",
+	'ย': "y", 'ร': "r", 'ล': "l", 'ว': "w", 'ศ': "s", 'ษ': "s", 'ส': "s",
+	'ห': "h", 'ฬ': "l", 'อ': "", 'ฮ': "h",
+}
+
+// เสียงพยัญชนะสะกด
+var thaiFinals = map[rune]string{
+	'ก': "k", 'ข': "k", 'ค': "k", 'ฆ': "k", 'ง': "ng",
+	'จ': "t", 'ช': "t", 'ซ': "t", 'ฌ': "t", 'ญ': "n",
+	'ฎ': "t", 'ฏ': "t", 'ฐ': "t", 'ฑ': "t", 'ฒ': "t", 'ณ': "n",
+	'ด': "t", 'ต': "t", 'ถ': "t", 'ท': "t", 'ธ': "t", 'น': "n",
+	'บ': "p", 'ป': "p", 'พ': "p", 'ฟ': "p", 'ภ': "p", 'ม': "m",
+	'ย': "i", 'ร': "n", 'ล': "n", 'ว': "o", 'ศ': "t", 'ษ': "t", 'ส': "t",
+	'ฬ': "n",
+}
+
+// สระที่ตามหลังพยัญชนะ
+var thaiVowels = map[rune]string{
+	'ะ': "a", 'ั': "a", 'า': "a", 'ำ': "am", 'ิ': "i", 'ี': "i",
+	'ึ': "ue", 'ื': "ue", 'ุ': "u", 'ู': "u", '็': "",
+}
+
+func isThaiConsonant(r rune) bool {
+	_, ok := thaiInitials[r]
+	return ok
+}
+
+func isThaiLeadingVowel(r rune) bool { return r >= 'เ' && r <= 'ไ' }
+
+func isThaiVowel(r rune) bool {
+	_, ok := thaiVowels[r]
+	return ok
+}
+
+// วรรณยุกต์ - ไม่มีผลกับการถอดเสียง
+func isThaiToneMark(r rune) bool { return r >= '่' && r <= '๋' }
+
+// ตัวที่ควบกล้ำกับ ร / ล / ว ได้
+func isThaiClusterHead(r rune) bool { return strings.ContainsRune("กขคตปผพ", r) }
+
+func isThaiClusterTail(r rune) bool { return r == 'ร' || r == 'ล' || r == 'ว' }
+
+// อักษรต่ำเดี่ยวที่ ห นำได้ (ห ไม่ออกเสียง)
+func isThaiSilentHTail(r rune) bool { return strings.ContainsRune("งญนมยรลว", r) }
+
+// thaiTranslit สถานะระหว่างถอดเสียงทีละพยางค์
+type thaiTranslit struct {
+	runes []rune
+	out   strings.Builder
+
+	pending  bool // มีพยัญชนะต้นที่ยังไม่มีสระ
+	hasVowel bool // พยางค์ปัจจุบันมีสระแล้ว - พยัญชนะตัวถัดไปเป็นตัวสะกด
+	runStart int  // ความยาวของ output ก่อนพยัญชนะต้นของพยางค์ (ใช้ตอนเจอการันต์)
+	lastMark int  // ความยาวของ output ก่อนพยัญชนะตัวล่าสุด
+}
+
+// next คืนตัวอักษรถัดไปหลัง index i โดยข้ามวรรณยุกต์ (0 ถ้าหมดข้อความ)
+func (t *thaiTranslit) next(i int) (rune, int) {
+	for j := i + 1; j < len(t.runes); j++ {
+		if !isThaiToneMark(t.runes[j]) {
+			return t.runes[j], j
+		}
+	}
+	return 0, len(t.runes)
+}
+
+// prev คืนตัวอักษรก่อน index i โดยข้ามวรรณยุกต์
+func (t *thaiTranslit) prev(i int) rune {
+	for j := i - 1; j >= 0; j-- {
+		if !isThaiToneMark(t.runes[j]) {
+			return t.runes[j]
+		}
+	}
+	return 0
+}
+
+// after เหมือน next แต่คืนเฉพาะตัวอักษร
+func (t *thaiTranslit) after(i int) rune {
+	r, _ := t.next(i)
+	return r
+}
+
+func (t *thaiTranslit) closeSyllable() {
+	t.pending = false
+	t.hasVowel = false
+}
+
+func (t *thaiTranslit) emitInitial(r rune) {
+	t.lastMark = t.out.Len()
+	if !t.pending {
+		t.runStart = t.lastMark
+	}
+	t.out.WriteString(thaiInitials[r])
+	t.pending = true
+}
+
+func (t *thaiTranslit) emitFinal(r rune) {
+	t.lastMark = t.out.Len()
+	final := thaiFinals[r]
+	// ย ตามหลังสระ ai / i ไม่ออกเสียงซ้ำ (เช่น ไทย)
+	if !(r == 'ย' && strings.HasSuffix(t.out.String(), "i")) {
+		t.out.WriteString(final)
+	}
+	t.closeSyllable()
+}
+
+// transliterateThai ถอดตัวอักษรไทยในข้อความเป็นอักษรโรมัน (ตัวอักษรอื่นคงเดิม)
+func transliterateThai(s string) string {
+	t := &thaiTranslit{runes: []rune(s)}
+	for i := 0; i < len(t.runes); i++ {
+		r := t.runes[i]
+		switch {
+		case isThaiLeadingVowel(r):
+			i = t.leadingVowel(i)
+		case isThaiConsonant(r):
+			i = t.consonant(i)
+		case isThaiVowel(r):
+			i = t.vowel(i)
+		case r == 'ฤ' || r == 'ฦ':
+			if r == 'ฤ' {
+				t.out.WriteString("rue")
+			} else {
+				t.out.WriteString("lue")
+			}
+			t.closeSyllable()
+		case r == '์':
+			// การันต์ - ตัดเสียงพยัญชนะที่อยู่หน้า (ถ้ายังไม่มีสระ ตัดทั้งกลุ่ม เช่น จันทร์)
+			cut := t.lastMark
+			if t.pending && !t.hasVowel {
+				cut = t.runStart
+			}
+			kept := t.out.String()[:cut]
+			t.out.Reset()
+			t.out.WriteString(kept)
+			t.closeSyllable()
+		case r >= '๐' && r <= '๙':
+			t.out.WriteRune('0' + (r - '๐'))
+			t.closeSyllable()
+		case r >= 0x0E00 && r <= 0x0E7F:
+			// วรรณยุกต์, ไม้ยมก, ไปยาลน้อย ฯลฯ
+		default:
+			t.out.WriteRune(r)
+			t.closeSyllable()
+		}
+	}
+	return t.out.String()
+}
+
+// leadingVowel สระที่เขียนหน้าพยัญชนะ (เ แ โ ใ ไ) - อ่านพยัญชนะต้นก่อนแล้วจึงใส่สระ
+func (t *thaiTranslit) leadingVowel(i int) int {
+	lead := t.runes[i]
+	t.closeSyllable()
+
+	j := i + 1
+	if j >= len(t.runes) || !isThaiConsonant(t.runes[j]) {
+		return i
+	}
+	head := t.runes[j]
+	// ห นำ (เช่น ใหม่ -> mai)
+	if n, k := t.next(j); head == 'ห' && isThaiSilentHTail(n) {
+		head, j = n, k
+	}
+	t.emitInitial(head)
+	if n, k := t.next(j); isThaiClusterTail(n) && isThaiClusterHead(head) && k+1 < len(t.runes) {
+		t.out.WriteString(thaiInitials[n])
+		j = k
+	}
+
+	vowel := map[rune]string{'เ': "e", 'แ': "ae", 'โ': "o", 'ใ': "ai", 'ไ': "ai"}[lead]
+	n1, k1 := t.next(j)
+	n2, k2 := t.next(k1)
+	switch {
+	case lead == 'เ' && n1 == 'ี' && n2 == 'ย':
+		vowel, j = "ia", k2
+	case lead == 'เ' && n1 == 'ื' && n2 == 'อ':
+		vowel, j = "uea", k2
+	case lead == 'เ' && n1 == 'า' && n2 == 'ะ':
+		vowel, j = "o", k2
+	case lead == 'เ' && n1 == 'า':
+		vowel, j = "ao", k1
+	case lead == 'เ' && (n1 == 'อ' || n1 == 'ิ'):
+		vowel, j = "oe", k1
+	case (lead == 'เ' || lead == 'แ' || lead == 'โ') && (n1 == 'ะ' || n1 == '็'):
+		j = k1
+	}
+	t.out.WriteString(vowel)
+	t.pending = false
+	t.hasVowel = true
+	return j
+}
+
+// consonant พยัญชนะ - แยกว่าเป็นพยัญชนะต้น ตัวควบกล้ำ สระ (ว / อ) หรือตัวสะกด
+func (t *thaiTranslit) consonant(i int) int {
+	r := t.runes[i]
+	n, _ := t.next(i)
+	followedByVowel := isThaiVowel(n)
+
+	switch {
+	case t.hasVowel:
+		if followedByVowel {
+			t.closeSyllable()
+			t.emitInitial(r)
+			return i
+		}
+		t.emitFinal(r)
+	case t.pending:
+		switch {
+		case isThaiClusterTail(r) && isThaiClusterHead(t.prev(i)) && followedByVowel:
+			t.lastMark = t.out.Len()
+			t.out.WriteString(thaiInitials[r])
+		case followedByVowel:
+			// พยัญชนะต้นตัวก่อนออกเสียง อะ (เช่น สวัสดี -> sa-wat-di)
+			t.out.WriteString("a")
+			t.pending = false
+			t.emitInitial(r)
+		case r == 'ว' && isThaiConsonant(n):
+			t.out.WriteString("ua")
+			t.pending = false
+			t.hasVowel = true
+		case r == 'อ':
+			t.out.WriteString("o")
+			t.pending = false
+			t.hasVowel = true
+		case n == '์':
+			// ตัวการันต์ - ปล่อยให้ตัดทั้งกลุ่มพยัญชนะ (เช่น จันทร์)
+		default:
+			// ไม่มีรูปสระ - ออกเสียง โอะ (เช่น คน -> khon)
+			t.out.WriteString("o")
+			t.lastMark = t.out.Len()
+			t.out.WriteString(thaiFinals[r])
+			t.closeSyllable()
+		}
+	default:
+		// ห นำอักษรต่ำ (เช่น หน้า, หมู) ไม่ออกเสียง
+		if r == 'ห' && isThaiSilentHTail(n) {
+			if _, k := t.next(i); isThaiVowel(t.after(k)) {
+				return i
+			}
+		}
+		t.emitInitial(r)
+	}
+	return i
+}
+
+// vowel สระที่ตามหลังพยัญชนะ
+func (t *thaiTranslit) vowel(i int) int {
+	r := t.runes[i]
+	n, k := t.next(i)
+	switch {
+	case r == 'ั' && n == 'ว':
+		t.out.WriteString("ua")
+		i = k
+	case r == 'ั' && n == 'ย':
+		t.out.WriteString("ai")
+		t.closeSyllable()
+		return k
+	case r == 'ื' && n == 'อ':
+		t.out.WriteString("ue")
+		i = k
+	default:
+		t.out.WriteString(thaiVowels[r])
+	}
+
+	t.pending = false
+	t.hasVowel = true
+	// สระเสียงสั้นที่มีรูป ะ / ำ ปิดพยางค์แล้ว
+	if r == 'ะ' || r == 'ำ' {
+		t.closeSyllable()
+	}
+	return i
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Modern House 2024", "modern-house-2024"},
+		{"  Café & Bar -- Phase_2 ", "caf-bar-phase-2"},
+		{"บ้านสวน", "bansuan"},
+		{"ประเทศไทย", "prathetthai"},
+		{"โครงการ", "khrongkan"},
+		{"สวัสดี", "sawatdi"},
+		{"เชียงใหม่", "chiangmai"},
+		{"ห้องนั่งเล่น", "hongnanglen"},
+		{"จันทร์", "chan"},
+		{"ร้านกาแฟ เขาใหญ่", "rankafae-khaoyai"},
+		{"บ้าน ๒๕๖๘", "ban-2568"},
+		{"!!!", ""},
+		{"北京", ""},
+	}
+	for _, tt := range tests {
+		if got := Slugify(tt.in); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSlugifyLength(t *testing.T) {
+	got := Slugify(strings.Repeat("residence ", 20))
+	if len(got) > MaxSlugLength || !IsValidSlug(got) {
+		t.Fatalf("Slugify() = %q (len %d), want a valid slug within %d chars", got, len(got), MaxSlugLength)
+	}
+}
+
+func TestIsValidSlug(t *testing.T) {
+	for _, slug := range []string{"ban-suan", "project-2", "a"} {
+		if !IsValidSlug(slug) {
+			t.Errorf("IsValidSlug(%q) = false, want true", slug)
+		}
+	}
+	for _, slug := range []string{"", "-ban", "ban-", "ban--suan", "Ban", "บ้าน", strings.Repeat("a", MaxSlugLength+1)} {
+		if IsValidSlug(slug) {
+			t.Errorf("IsValidSlug(%q) = true, want false", slug)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"backend/pkg/i18n"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError รายละเอียด validation error ของแต่ละ field (ส่งใน error.details)
+type FieldError struct {
+	Field   string `json:"field"`           // ชื่อตาม json tag
+	Rule    string `json:"rule"`            // validate tag ที่ไม่ผ่าน เช่น required, email, min
+	Param   string `json:"param,omitempty"` // ค่าของ rule เช่น min=2 -> "2"
+	Message string `json:"message"`
+}
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	// ใช้ชื่อ field ตาม json tag ให้ตรงกับที่ client ส่งมา
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" || name == "" {
+			return field.Name
+		}
+		return name
+	})
+	return v
+}
+
+// ValidateStruct ตรวจ struct ตาม validate tag - คืน ErrValidation พร้อม []FieldError หรือ nil
+func ValidateStruct(s interface{}) error {
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return ErrValidation.WithDetails(fieldErrors(validationErrs))
+	}
+	return ErrInvalidInput.Wrap(err)
+}
+
+func fieldErrors(errs validator.ValidationErrors) []FieldError {
+	fields := make([]FieldError, 0, len(errs))
+	for _, fe := range errs {
+		fields = append(fields, FieldError{Field: fe.Field(), Rule: fe.Tag(), Param: fe.Param()})
+	}
+	return fields
+}
+
+// localizeFieldErrors เติมข้อความของแต่ละ field ตามภาษา (VALIDATION_<RULE> ใน catalog)
+func localizeFieldErrors(locale string, fields []FieldError) []FieldError {
+	result := make([]FieldError, len(fields))
+	for i, f := range fields {
+		if f.Message == "" {
+			template, ok := i18n.Message(locale, "VALIDATION_"+strings.ToUpper(f.Rule))
+			if !ok {
+				template, _ = i18n.Message(locale, "VALIDATION_INVALID")
+			}
+			f.Message = strings.NewReplacer("{field}", f.Field, "{param}", f.Param).Replace(template)
+		}
+		result[i] = f
+	}
+	return result
+}
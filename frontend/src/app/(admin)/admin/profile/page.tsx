@@ -27,7 +27,7 @@ interface ApiError {
             message?: string;
             error?: {
                 message?: string;
-                messages?: string[];
+                details?: string[];
             };
         };
     };
@@ -164,7 +164,7 @@ export default function ProfilePage() {
             }
         } catch (err: unknown) {
             const error = err as ApiError;
-            const messages = error.response?.data?.error?.messages;
+            const messages = error.response?.data?.error?.details;
             if (messages && Array.isArray(messages)) {
                 setMessage({ type: 'error', text: messages.join('\n') });
             } else {
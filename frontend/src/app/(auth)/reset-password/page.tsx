@@ -84,9 +84,9 @@ function ResetPasswordContent() {
             }
         } catch (err: unknown) {
             const error = err as {
-                response?: { data?: { error?: { message?: string; messages?: string[] } } };
+                response?: { data?: { error?: { message?: string; details?: string[] } } };
             };
-            const messages = error.response?.data?.error?.messages;
+            const messages = error.response?.data?.error?.details;
             if (messages && Array.isArray(messages)) {
                 setError(messages.join('\n'));
             } else {